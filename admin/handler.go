@@ -0,0 +1,294 @@
+// Package admin provides an HTTP/JSON API to inspect and modify the caches of
+// a running process.
+//
+// The Handler serves the caches of a Registry under paths relative to where
+// it is mounted:
+//
+//	GET    /                   list the caches
+//	GET    /{cache}            show the algorithm, limit, size and stats of a cache
+//	GET    /{cache}/keys       list the keys in eviction order, next victim first
+//	POST   /{cache}/clear      remove all the items of a cache
+//	GET    /{cache}/keys/{key} get the value of a key
+//	PUT    /{cache}/keys/{key} set the value of a key
+//	DELETE /{cache}/keys/{key} remove a key
+//
+// Mount it under a prefix with http.StripPrefix:
+//
+//	mux.Handle("/debug/caches/", http.StripPrefix("/debug/caches", admin.NewHandler(registry)))
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/raghavgh/gofast"
+)
+
+// maxBodySize is the largest value accepted by PUT.
+const maxBodySize = 1 << 20
+
+// ttlCache is implemented by caches supporting per-entry time to live, such as gofast.Expiring.
+type ttlCache interface {
+	PutWithTTL(key string, val any, ttl time.Duration)
+}
+
+// CacheInfo describes a cache.
+type CacheInfo struct {
+	Name string `json:"name"`
+	// Algorithm is empty for caches not created by gofast.NewCache.
+	Algorithm string `json:"algorithm,omitempty"`
+	// Limit is 0 for caches not implementing gofast.Inspector.
+	Limit int    `json:"limit,omitempty"`
+	Size  int    `json:"size"`
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Stats are the statistics of a cache implementing gofast.StatsReporter.
+type Stats struct {
+	gofast.Stats
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// Option configures a Handler.
+type Option func(h *Handler)
+
+// WithReadOnly makes the handler reject the requests modifying a cache with
+// 403 Forbidden.
+func WithReadOnly() Option {
+	return func(h *Handler) {
+		h.readOnly = true
+	}
+}
+
+// Handler is an http.Handler serving the caches of a Registry.
+type Handler struct {
+	registry *Registry
+	readOnly bool
+}
+
+// NewHandler returns a handler for the caches of registry.
+func NewHandler(registry *Registry, opts ...Option) *Handler {
+	h := &Handler{registry: registry}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP routes the request to the cache and operation named by its path.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == "" {
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+		h.list(w)
+		return
+	}
+
+	name, rest, _ := strings.Cut(path, "/")
+	c, ok := h.registry.Lookup(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "cache not found")
+		return
+	}
+
+	switch {
+	case rest == "":
+		if allow(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, describe(name, c))
+		}
+	case rest == "clear":
+		if allow(w, r, http.MethodPost) && h.writable(w) {
+			c.Clear()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case rest == "keys":
+		if allow(w, r, http.MethodGet) {
+			h.keys(w, r, c)
+		}
+	case strings.HasPrefix(rest, "keys/") && len(rest) > len("keys/"):
+		h.key(w, r, c, strings.TrimPrefix(rest, "keys/"))
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// list writes the description of every registered cache.
+func (h *Handler) list(w http.ResponseWriter) {
+	caches := []CacheInfo{}
+	for _, name := range h.registry.Names() {
+		if c, ok := h.registry.Lookup(name); ok {
+			caches = append(caches, describe(name, c))
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Caches []CacheInfo `json:"caches"`
+	}{caches})
+}
+
+// keys writes the keys of c in eviction order, at most as many as the limit
+// query parameter if it is set.
+func (h *Handler) keys(w http.ResponseWriter, r *http.Request, c gofast.Cache) {
+	inspector, ok := c.(gofast.Inspector)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "cache does not list its keys")
+		return
+	}
+	keys := inspector.Keys()
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		if limit < len(keys) {
+			keys = keys[:limit]
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Keys []string `json:"keys"`
+	}{keys})
+}
+
+// key serves the requests on a single key.
+func (h *Handler) key(w http.ResponseWriter, r *http.Request, c gofast.Cache, key string) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(w, c, key)
+	case http.MethodPut:
+		if h.writable(w) {
+			h.put(w, r, c, key)
+		}
+	case http.MethodDelete:
+		if !h.writable(w) {
+			return
+		}
+		if !c.Contains(key) {
+			writeError(w, http.StatusNotFound, "key not found")
+			return
+		}
+		c.Remove(key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// get writes the value of key as JSON. []byte values are written as strings
+// when they are valid UTF-8, and base64 encoded otherwise.
+func (h *Handler) get(w http.ResponseWriter, c gofast.Cache, key string) {
+	val, ok := c.Get(key)
+	if !ok {
+		writeError(w, http.StatusNotFound, "key not found")
+		return
+	}
+	if b, ok := val.([]byte); ok && utf8.Valid(b) {
+		val = string(b)
+	}
+	body, err := json.Marshal(struct {
+		Key   string `json:"key"`
+		Value any    `json:"value"`
+	}{key, val})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "value is not JSON encodable: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// put stores the request body under key: as []byte for an
+// application/octet-stream body, and as the decoded JSON value otherwise.
+// The ttl query parameter sets the time to live of the value, for caches
+// supporting it.
+func (h *Handler) put(w http.ResponseWriter, r *http.Request, c gofast.Cache, key string) {
+	var ttl time.Duration
+	if s := r.URL.Query().Get("ttl"); s != "" {
+		var err error
+		if ttl, err = time.ParseDuration(s); err != nil || ttl <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid ttl")
+			return
+		}
+		if _, ok := c.(ttlCache); !ok {
+			writeError(w, http.StatusBadRequest, "cache does not support ttl")
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "value too large")
+		return
+	}
+	var val any
+	if r.Header.Get("Content-Type") == "application/octet-stream" {
+		val = body
+	} else if err := json.Unmarshal(body, &val); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON value: "+err.Error())
+		return
+	}
+
+	if ttl > 0 {
+		c.(ttlCache).PutWithTTL(key, val, ttl)
+	} else {
+		c.Put(key, val)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writable reports whether the handler accepts modifications, replying with
+// 403 Forbidden if it does not.
+func (h *Handler) writable(w http.ResponseWriter) bool {
+	if h.readOnly {
+		writeError(w, http.StatusForbidden, "read-only")
+		return false
+	}
+	return true
+}
+
+// describe returns the description of c.
+func describe(name string, c gofast.Cache) CacheInfo {
+	info := CacheInfo{Name: name, Size: c.Len()}
+	if algo, ok := gofast.AlgorithmOf(c); ok {
+		info.Algorithm = algo.String()
+	}
+	if inspector, ok := c.(gofast.Inspector); ok {
+		info.Limit = inspector.Limit()
+	}
+	if reporter, ok := c.(gofast.StatsReporter); ok {
+		stats := reporter.Stats()
+		info.Stats = &Stats{Stats: stats, HitRatio: stats.HitRatio()}
+	}
+	return info
+}
+
+// allow reports whether the request method is method, replying with
+// 405 Method Not Allowed if it is not. HEAD is allowed with GET.
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{msg})
+}
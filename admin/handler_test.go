@@ -0,0 +1,179 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raghavgh/gofast"
+)
+
+// newTestServer serves a handler for registry under /debug/caches/.
+func newTestServer(t *testing.T, registry *Registry, opts ...Option) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("/debug/caches/", http.StripPrefix("/debug/caches", NewHandler(registry, opts...)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// do sends a request and returns the status code and the body.
+func do(t *testing.T, method, url, contentType, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestHandler(t *testing.T) {
+	users := gofast.NewCache(2, gofast.LRU)
+	sessions := gofast.NewExpiring(gofast.NewCache(10, gofast.SIEVE))
+	registry := NewRegistry()
+	registry.Register("users", users)
+	registry.Register("sessions", sessions)
+	srv := newTestServer(t, registry)
+	base := srv.URL + "/debug/caches"
+
+	t.Run("list caches", func(t *testing.T) {
+		users.Put("1", "one")
+		users.Put("2", "two")
+		users.Put("3", "three")
+		users.Get("2")
+		users.Get("1")
+
+		status, body := do(t, http.MethodGet, base+"/", "", "")
+		assert.Equal(t, http.StatusOK, status)
+
+		var list struct {
+			Caches []CacheInfo `json:"caches"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &list))
+		require.Len(t, list.Caches, 2)
+		assert.Equal(t, "sessions", list.Caches[0].Name)
+		assert.Equal(t, "SIEVE", list.Caches[0].Algorithm)
+		assert.Equal(t, CacheInfo{
+			Name:      "users",
+			Algorithm: "LRU",
+			Limit:     2,
+			Size:      2,
+			Stats: &Stats{
+				Stats:    gofast.Stats{Hits: 1, Misses: 1, Evictions: 1},
+				HitRatio: 0.5,
+			},
+		}, list.Caches[1])
+	})
+
+	t.Run("describe a cache", func(t *testing.T) {
+		status, body := do(t, http.MethodGet, base+"/users", "", "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"name":"users","algorithm":"LRU","limit":2,"size":2,
+			"stats":{"hits":1,"misses":1,"evictions":1,"expirations":0,"hit_ratio":0.5}}`, body)
+
+		status, _ = do(t, http.MethodGet, base+"/nope", "", "")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("keys in eviction order", func(t *testing.T) {
+		status, body := do(t, http.MethodGet, base+"/users/keys", "", "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"keys":["3","2"]}`, body)
+
+		_, body = do(t, http.MethodGet, base+"/users/keys?limit=1", "", "")
+		assert.JSONEq(t, `{"keys":["3"]}`, body)
+	})
+
+	t.Run("get put delete", func(t *testing.T) {
+		status, _ := do(t, http.MethodPut, base+"/sessions/keys/a/b", "application/json", `{"user":7}`)
+		assert.Equal(t, http.StatusNoContent, status)
+		status, body := do(t, http.MethodGet, base+"/sessions/keys/a/b", "", "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"key":"a/b","value":{"user":7}}`, body)
+
+		status, _ = do(t, http.MethodPut, base+"/sessions/keys/raw", "application/octet-stream", "bytes")
+		assert.Equal(t, http.StatusNoContent, status)
+		val, _ := sessions.Get("raw")
+		assert.Equal(t, []byte("bytes"), val)
+		_, body = do(t, http.MethodGet, base+"/sessions/keys/raw", "", "")
+		assert.JSONEq(t, `{"key":"raw","value":"bytes"}`, body)
+
+		status, _ = do(t, http.MethodDelete, base+"/sessions/keys/raw", "", "")
+		assert.Equal(t, http.StatusNoContent, status)
+		status, _ = do(t, http.MethodDelete, base+"/sessions/keys/raw", "", "")
+		assert.Equal(t, http.StatusNotFound, status)
+		status, _ = do(t, http.MethodGet, base+"/sessions/keys/raw", "", "")
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, _ = do(t, http.MethodPut, base+"/sessions/keys/bad", "", `{`)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("put with ttl", func(t *testing.T) {
+		status, _ := do(t, http.MethodPut, base+"/sessions/keys/t?ttl=1h", "", `"v"`)
+		assert.Equal(t, http.StatusNoContent, status)
+		ttl, ok := sessions.TTL("t")
+		assert.True(t, ok)
+		assert.InDelta(t, time.Hour, ttl, float64(time.Minute))
+
+		status, _ = do(t, http.MethodPut, base+"/users/keys/t?ttl=1h", "", `"v"`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		status, _ = do(t, http.MethodPut, base+"/sessions/keys/t?ttl=soon", "", `"v"`)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		status, _ := do(t, http.MethodGet, base+"/sessions/clear", "", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+		status, _ = do(t, http.MethodPost, base+"/sessions/clear", "", "")
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, 0, sessions.Len())
+	})
+}
+
+func TestHandler_ReadOnly(t *testing.T) {
+	cache := gofast.NewCache(10, gofast.LRU)
+	cache.Put("a", "1")
+	registry := NewRegistry()
+	registry.Register("c", cache)
+	srv := newTestServer(t, registry, WithReadOnly())
+	base := srv.URL + "/debug/caches/c"
+
+	status, _ := do(t, http.MethodGet, base+"/keys/a", "", "")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = do(t, http.MethodPut, base+"/keys/a", "", `"2"`)
+	assert.Equal(t, http.StatusForbidden, status)
+	status, _ = do(t, http.MethodDelete, base+"/keys/a", "", "")
+	assert.Equal(t, http.StatusForbidden, status)
+	status, _ = do(t, http.MethodPost, base+"/clear", "", "")
+	assert.Equal(t, http.StatusForbidden, status)
+
+	val, _ := cache.Get("a")
+	assert.Equal(t, "1", val)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("b", gofast.NewCache(1, gofast.LRU))
+	registry.Register("a", gofast.NewCache(1, gofast.LRU))
+	assert.Equal(t, []string{"a", "b"}, registry.Names())
+
+	registry.Unregister("a")
+	_, ok := registry.Lookup("a")
+	assert.False(t, ok)
+	assert.Panics(t, func() { registry.Register("a/b", gofast.NewCache(1, gofast.LRU)) })
+}
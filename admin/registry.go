@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/raghavgh/gofast"
+)
+
+// Registry is a set of named caches. It is safe for concurrent use.
+type Registry struct {
+	mu     *sync.RWMutex
+	caches map[string]gofast.Cache
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		mu:     &sync.RWMutex{},
+		caches: make(map[string]gofast.Cache),
+	}
+}
+
+// Register adds c under name, replacing the cache previously registered
+// under that name. It panics if name is empty or contains a slash, as the
+// name is used as a path segment.
+func (r *Registry) Register(name string, c gofast.Cache) {
+	if name == "" || strings.Contains(name, "/") {
+		panic("admin: invalid cache name " + name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.caches[name] = c
+}
+
+// Unregister removes the cache registered under name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.caches, name)
+}
+
+// Lookup returns the cache registered under name.
+func (r *Registry) Lookup(name string) (gofast.Cache, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.caches[name]
+	return c, ok
+}
+
+// Names returns the names of the registered caches in lexical order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.caches))
+	for name := range r.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,44 @@
+package gofast
+
+import "github.com/raghavgh/gofast/internal/cache/arena"
+
+// BytesCache is a cache of []byte values stored in large preallocated
+// chunks, indexed by a map holding no pointers, so that the garbage collector
+// does not scan its entries however many it holds. Get appends values to a
+// caller-provided buffer, and neither Get nor Set allocates in steady state.
+//
+// Entries are evicted a chunk of 64 KiB at a time, oldest first, as the
+// chunks are reused. Entries whose key and value are longer than
+// BytesMaxEntrySize together are not stored.
+type BytesCache = arena.Cache
+
+// BytesMaxEntrySize is the largest key plus value length a BytesCache stores.
+const BytesMaxEntrySize = arena.MaxEntrySize
+
+// BytesOption configures a BytesCache.
+type BytesOption func(o *bytesOptions)
+
+// bytesOptions are the settings of a BytesCache.
+type bytesOptions struct {
+	promote bool
+}
+
+// WithPromotion makes a BytesCache evict in an LRU-ish rather than FIFO
+// order: entries read from the oldest quarter of the cache are copied to its
+// head, so entries in use survive the reuse of their chunk. Reads then take
+// a write lock.
+func WithPromotion() BytesOption {
+	return func(o *bytesOptions) {
+		o.promote = true
+	}
+}
+
+// NewBytesCache returns a cache storing up to about maxBytes of keys and
+// values, with a minimum of 128 KiB.
+func NewBytesCache(maxBytes int, opts ...BytesOption) *BytesCache {
+	var o bytesOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return arena.New(maxBytes, o.promote)
+}
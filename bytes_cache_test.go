@@ -0,0 +1,76 @@
+package gofast
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ StatsReporter = (*BytesCache)(nil)
+
+func TestBytesCache(t *testing.T) {
+	cache := NewBytesCache(1<<20, WithPromotion())
+	cache.Set("a", []byte("1"))
+
+	val, ok := cache.Get(nil, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), val)
+	assert.Equal(t, uint64(1), cache.Stats().Hits)
+}
+
+// benchEntries is the number of entries the byte-slice benchmarks fill the caches with.
+const benchEntries = 1 << 18
+
+// BenchmarkBytes compares a BytesCache with an LRU cache holding the same []byte
+// values, and reports the heap objects, which the garbage collector scans, held
+// by each cache once full.
+func BenchmarkBytes(b *testing.B) {
+	keys := make([]string, benchEntries)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	val := make([]byte, 64)
+
+	b.Run("BytesCache", func(b *testing.B) {
+		before := heapObjects()
+		cache := NewBytesCache(benchEntries * 128)
+		buf := make([]byte, 0, len(val))
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			if buf, _ = cache.Get(buf[:0], key); len(buf) == 0 {
+				cache.Set(key, val)
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(heapObjects()-before), "heap-objects")
+		runtime.KeepAlive(cache)
+	})
+
+	b.Run("LRU", func(b *testing.B) {
+		before := heapObjects()
+		cache := NewCache(benchEntries, LRU)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			if _, ok := cache.Get(key); !ok {
+				cache.Put(key, append([]byte(nil), val...))
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(heapObjects()-before), "heap-objects")
+		runtime.KeepAlive(cache)
+	})
+}
+
+// heapObjects returns the number of live heap objects.
+func heapObjects() int64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapObjects)
+}
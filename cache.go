@@ -15,3 +15,63 @@ type Cache interface {
 	// Contains returns true if the cache contains the given key
 	Contains(key string) bool
 }
+
+// EvictionNotifier is implemented by caches that report the entries they evict
+// to make room for new ones. All caches returned by NewCache implement it.
+type EvictionNotifier interface {
+	// AddEvictionHook registers fn to be called with every evicted entry.
+	// fn is called with the cache's lock held, so it must not call back into the cache.
+	AddEvictionHook(fn func(key string, val any))
+}
+
+// ExpirationNotifier is implemented by caches that report the entries they drop
+// because their time to live ran out, such as Expiring.
+type ExpirationNotifier interface {
+	// AddExpirationHook registers fn to be called with every expired entry dropped.
+	// fn is called with the cache's lock held, so it must not call back into the cache.
+	AddExpirationHook(fn func(key string, val any))
+}
+
+// Tagger is implemented by caches that attach tags to entries, to remove
+// every entry carrying a tag at once. All caches returned by NewCache implement it.
+type Tagger interface {
+	// PutWithTags adds a value to the cache carrying tags, replacing the tags of key.
+	// Put removes the tags of key, and entries lose their tags when they are removed or evicted.
+	PutWithTags(key string, val any, tags ...string)
+	// InvalidateTag removes every entry carrying tag and returns the number of entries removed.
+	InvalidateTag(tag string) int
+	// TaggedKeys returns the keys carrying tag, in no particular order.
+	TaggedKeys(tag string) []string
+}
+
+// Inspector is implemented by caches that report their capacity and list their
+// keys. All caches returned by NewCache implement it.
+type Inspector interface {
+	// Limit returns the maximum number of items in the cache.
+	Limit() int
+	// Keys returns the keys in the cache in eviction order, the next key to be evicted first.
+	Keys() []string
+}
+
+// Peeker is implemented by caches that read entries without counting the
+// read as a use. All caches returned by NewCache implement it.
+type Peeker interface {
+	// Peek returns the value of key like Get, without updating the eviction
+	// policy or the stats of the cache.
+	Peek(key string) (any, bool)
+}
+
+// Resizer is implemented by caches whose limit can be changed at runtime.
+// All caches returned by NewCache implement it.
+type Resizer interface {
+	// Resize sets the maximum number of items in the cache, evicting items
+	// according to the cache's policy until it fits. It panics if limit is
+	// not greater than 0.
+	Resize(limit int)
+}
+
+// Wrapper is implemented by caches that wrap another cache, such as Expiring.
+type Wrapper interface {
+	// Unwrap returns the wrapped cache.
+	Unwrap() Cache
+}
@@ -1,9 +1,17 @@
 package gofast
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/raghavgh/gofast/internal/cache/fifo"
+	"github.com/raghavgh/gofast/internal/cache/lfu"
 	"github.com/raghavgh/gofast/internal/cache/lifo"
 	"github.com/raghavgh/gofast/internal/cache/lru"
+	"github.com/raghavgh/gofast/internal/cache/mru"
+	"github.com/raghavgh/gofast/internal/cache/s3fifo"
+	"github.com/raghavgh/gofast/internal/cache/sieve"
 )
 
 type Algorithm int
@@ -27,19 +35,152 @@ const (
 	LIFO
 	// TTL is the time to live cache algorithm.
 	TTL
+	// S3FIFO is the simple, scalable FIFO cache algorithm with a small, main and ghost queue.
+	S3FIFO
+	// SIEVE is the sieve cache algorithm with a single FIFO queue, a visited bit and a moving hand.
+	SIEVE
 )
 
+// algorithmNames maps each algorithm to its display name.
+var algorithmNames = map[Algorithm]string{
+	LRU:    "LRU",
+	LFU:    "LFU",
+	FIFO:   "FIFO",
+	MRU:    "MRU",
+	RR:     "RR",
+	ARC:    "ARC",
+	SLRU:   "SLRU",
+	LIFO:   "LIFO",
+	TTL:    "TTL",
+	S3FIFO: "S3FIFO",
+	SIEVE:  "SIEVE",
+}
+
+// String returns the name of the algorithm.
+func (a Algorithm) String() string {
+	if name, ok := algorithmNames[a]; ok {
+		return name
+	}
+	return "Algorithm(" + strconv.Itoa(int(a)) + ")"
+}
+
+// ParseAlgorithm returns the algorithm with the given name, ignoring case.
+// Only the algorithms returned by Algorithms are accepted.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, algo := range Algorithms() {
+		if strings.EqualFold(algo.String(), name) {
+			return algo, nil
+		}
+	}
+	return 0, fmt.Errorf("gofast: unknown algorithm %q", name)
+}
+
+// Algorithms returns the algorithms that NewCache implements,
+// as opposed to the ones that fall back to LRU.
+func Algorithms() []Algorithm {
+	return []Algorithm{LRU, LFU, FIFO, MRU, LIFO, S3FIFO, SIEVE}
+}
+
+// AlgorithmOf returns the algorithm of a cache returned by NewCache, looking
+// through wrappers implementing Wrapper, and false for other caches.
+func AlgorithmOf(c Cache) (Algorithm, bool) {
+	for {
+		switch c.(type) {
+		case *lru.LRU:
+			return LRU, true
+		case *lfu.LFU:
+			return LFU, true
+		case *fifo.Fifo:
+			return FIFO, true
+		case *mru.MRU:
+			return MRU, true
+		case *lifo.Lifo:
+			return LIFO, true
+		case *s3fifo.S3FIFO:
+			return S3FIFO, true
+		case *sieve.Sieve:
+			return SIEVE, true
+		}
+		w, ok := c.(Wrapper)
+		if !ok {
+			return 0, false
+		}
+		c = w.Unwrap()
+	}
+}
+
+// Resize resizes c to limit if it, or a cache it wraps looking through
+// wrappers implementing Wrapper, implements Resizer, and reports whether one did.
+func Resize(c Cache, limit int) bool {
+	for {
+		if r, ok := c.(Resizer); ok {
+			r.Resize(limit)
+			return true
+		}
+		w, ok := c.(Wrapper)
+		if !ok {
+			return false
+		}
+		c = w.Unwrap()
+	}
+}
+
+// putWithTags puts val in c carrying tags, or without them if c does not
+// implement Tagger.
+func putWithTags(c Cache, key string, val any, tags []string) {
+	if tagger, ok := c.(Tagger); ok {
+		tagger.PutWithTags(key, val, tags...)
+		return
+	}
+	c.Put(key, val)
+}
+
+// taggedKeys returns the keys of c carrying tag, or nil if c does not
+// implement Tagger.
+func taggedKeys(c Cache, tag string) []string {
+	if tagger, ok := c.(Tagger); ok {
+		return tagger.TaggedKeys(tag)
+	}
+	return nil
+}
+
+// Option configures a cache returned by NewCache.
+type Option func(c Cache)
+
+// WithEvictionHook registers fn to be called with every entry the cache evicts
+// to make room for a new one. See EvictionNotifier for the constraints on fn.
+func WithEvictionHook(fn func(key string, val any)) Option {
+	return func(c Cache) {
+		c.(EvictionNotifier).AddEvictionHook(fn)
+	}
+}
+
 // NewCache returns a new cache with the given limit and algorithm.
-func NewCache(limit int, algo Algorithm) Cache {
+func NewCache(limit int, algo Algorithm, opts ...Option) Cache {
+	c := newCache(limit, algo)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// newCache returns the cache implementing algo.
+func newCache(limit int, algo Algorithm) Cache {
 	switch algo {
 	case LRU:
 		return lru.NewLRU(limit)
 	case FIFO:
 		return fifo.NewFifo(limit)
 	case LFU:
-		return lifo.NewLifo(limit)
+		return lfu.NewLFU(limit)
+	case MRU:
+		return mru.NewMRU(limit)
 	case LIFO:
 		return lifo.NewLifo(limit)
+	case S3FIFO:
+		return s3fifo.NewS3FIFO(limit)
+	case SIEVE:
+		return sieve.NewSieve(limit)
 	default:
 		return lru.NewLRU(limit)
 	}
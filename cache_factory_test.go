@@ -0,0 +1,251 @@
+package gofast
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/raghavgh/gofast/internal/cache/fifo"
+	"github.com/raghavgh/gofast/internal/cache/lfu"
+	"github.com/raghavgh/gofast/internal/cache/lifo"
+	"github.com/raghavgh/gofast/internal/cache/lru"
+	"github.com/raghavgh/gofast/internal/cache/mru"
+	"github.com/raghavgh/gofast/internal/cache/s3fifo"
+	"github.com/raghavgh/gofast/internal/cache/sieve"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCache(t *testing.T) {
+	assert.IsType(t, &lru.LRU{}, NewCache(10, LRU))
+	assert.IsType(t, &lfu.LFU{}, NewCache(10, LFU))
+	assert.IsType(t, &fifo.Fifo{}, NewCache(10, FIFO))
+	assert.IsType(t, &mru.MRU{}, NewCache(10, MRU))
+	assert.IsType(t, &lifo.Lifo{}, NewCache(10, LIFO))
+	assert.IsType(t, &s3fifo.S3FIFO{}, NewCache(10, S3FIFO))
+	assert.IsType(t, &sieve.Sieve{}, NewCache(10, SIEVE))
+	assert.IsType(t, &lru.LRU{}, NewCache(10, ARC))
+}
+
+func TestAlgorithm_String(t *testing.T) {
+	assert.Equal(t, "LRU", LRU.String())
+	assert.Equal(t, "SIEVE", SIEVE.String())
+	assert.Equal(t, "Algorithm(99)", Algorithm(99).String())
+
+	for _, algo := range Algorithms() {
+		assert.NotContains(t, algo.String(), "Algorithm(")
+	}
+}
+
+func TestWithEvictionHook(t *testing.T) {
+	for _, algo := range Algorithms() {
+		t.Run(algo.String(), func(t *testing.T) {
+			evicted := map[string]any{}
+			cache := NewCache(10, algo, WithEvictionHook(func(key string, val any) {
+				evicted[key] = val
+			}))
+
+			for i := 0; i < 100; i++ {
+				cache.Put(strconv.Itoa(i), i)
+			}
+			// removals and updates are not evictions.
+			cache.Put("99", 99)
+			cache.Remove("99")
+
+			assert.Len(t, evicted, 90)
+			for key, val := range evicted {
+				assert.False(t, cache.Contains(key), "evicted key %s is still cached", key)
+				assert.Equal(t, key, strconv.Itoa(val.(int)))
+			}
+		})
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for _, algo := range Algorithms() {
+		parsed, err := ParseAlgorithm(strings.ToLower(algo.String()))
+		assert.NoError(t, err)
+		assert.Equal(t, algo, parsed)
+	}
+
+	_, err := ParseAlgorithm("nope")
+	assert.Error(t, err)
+	// ARC falls back to LRU in NewCache, so it is not accepted.
+	_, err = ParseAlgorithm("arc")
+	assert.Error(t, err)
+}
+
+func TestStats(t *testing.T) {
+	for _, algo := range Algorithms() {
+		t.Run(algo.String(), func(t *testing.T) {
+			cache := NewCache(2, algo)
+			cache.Put("1", 1)
+			cache.Get("1")
+			cache.Get("2")
+			cache.Put("2", 2)
+			cache.Put("3", 3)
+
+			stats := cache.(StatsReporter).Stats()
+			assert.Equal(t, Stats{Hits: 1, Misses: 1, Evictions: 1}, stats)
+			assert.Equal(t, 0.5, stats.HitRatio())
+		})
+	}
+}
+
+func TestInspector(t *testing.T) {
+	tests := []struct {
+		algo Algorithm
+		want []string
+	}{
+		{LRU, []string{"3", "1", "2"}},
+		{MRU, []string{"2", "1", "3"}},
+		{FIFO, []string{"1", "2", "3"}},
+		{LIFO, []string{"3", "2", "1"}},
+		{LFU, []string{"3", "2", "1"}},
+		{S3FIFO, []string{"1", "2", "3"}},
+		{SIEVE, []string{"1", "2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algo.String(), func(t *testing.T) {
+			cache := NewCache(5, tt.algo)
+			cache.Put("1", 1)
+			cache.Put("2", 2)
+			cache.Put("3", 3)
+			cache.Get("1")
+			cache.Get("1")
+			cache.Get("2")
+
+			inspector := cache.(Inspector)
+			assert.Equal(t, 5, inspector.Limit())
+			assert.Equal(t, tt.want, inspector.Keys())
+		})
+	}
+}
+
+func TestTagger(t *testing.T) {
+	for _, algo := range Algorithms() {
+		t.Run(algo.String(), func(t *testing.T) {
+			cache := NewCache(10, algo)
+			tagger := cache.(Tagger)
+			for i := 0; i < 100; i++ {
+				tagger.PutWithTags(strconv.Itoa(i), i, "all", "mod3:"+strconv.Itoa(i%3))
+			}
+			assert.ElementsMatch(t, cache.(Inspector).Keys(), tagger.TaggedKeys("all"))
+
+			// Only the entries still cached are invalidated.
+			mod0 := 0
+			for _, key := range cache.(Inspector).Keys() {
+				if n, _ := strconv.Atoi(key); n%3 == 0 {
+					mod0++
+				}
+			}
+			assert.Equal(t, mod0, tagger.InvalidateTag("mod3:0"))
+			assert.Equal(t, 10-mod0, cache.Len())
+			assert.Equal(t, 0, tagger.InvalidateTag("mod3:0"))
+
+			// Put and Remove drop the tags of a key.
+			keys := cache.(Inspector).Keys()
+			cache.Put(keys[0], 0)
+			cache.Remove(keys[1])
+			assert.Equal(t, len(keys)-2, tagger.InvalidateTag("all"))
+			assert.Equal(t, []string{keys[0]}, cache.(Inspector).Keys())
+
+			tagger.PutWithTags("a", 1, "t", "t")
+			cache.Clear()
+			assert.Equal(t, 0, tagger.InvalidateTag("t"))
+		})
+	}
+}
+
+func TestPeeker(t *testing.T) {
+	for _, algo := range Algorithms() {
+		t.Run(algo.String(), func(t *testing.T) {
+			cache := NewCache(10, algo)
+			for i := 0; i < 5; i++ {
+				cache.Put(strconv.Itoa(i), i)
+			}
+			keys := cache.(Inspector).Keys()
+
+			val, ok := cache.(Peeker).Peek("0")
+			assert.True(t, ok)
+			assert.Equal(t, 0, val)
+			_, ok = cache.(Peeker).Peek("missing")
+			assert.False(t, ok)
+
+			assert.Equal(t, keys, cache.(Inspector).Keys())
+			assert.Equal(t, Stats{}, cache.(StatsReporter).Stats())
+		})
+	}
+}
+
+func TestResizer(t *testing.T) {
+	// The caches whose Keys are their exact eviction order.
+	exact := map[Algorithm]bool{LRU: true, MRU: true, FIFO: true, LIFO: true, LFU: true}
+
+	for _, algo := range Algorithms() {
+		t.Run(algo.String(), func(t *testing.T) {
+			var evicted []string
+			cache := NewCache(10, algo, WithEvictionHook(func(key string, _ any) {
+				evicted = append(evicted, key)
+			}))
+			for i := 0; i < 10; i++ {
+				cache.Put(strconv.Itoa(i), i)
+				for j := 0; j < i%3; j++ {
+					cache.Get(strconv.Itoa(i))
+				}
+			}
+			keys := cache.(Inspector).Keys()
+
+			cache.(Resizer).Resize(4)
+			assert.Equal(t, 4, cache.Len())
+			assert.Equal(t, 4, cache.(Inspector).Limit())
+			assert.Len(t, evicted, 6)
+			assert.Equal(t, uint64(6), cache.(StatsReporter).Stats().Evictions)
+			if exact[algo] {
+				assert.Equal(t, keys[:6], evicted)
+				assert.Equal(t, keys[6:], cache.(Inspector).Keys())
+			}
+
+			// The cache keeps its limit as new items come in.
+			for i := 10; i < 20; i++ {
+				cache.Put(strconv.Itoa(i), i)
+			}
+			assert.Equal(t, 4, cache.Len())
+
+			cache.(Resizer).Resize(8)
+			for i := 20; i < 30; i++ {
+				cache.Put(strconv.Itoa(i), i)
+			}
+			assert.Equal(t, 8, cache.Len())
+			assert.Panics(t, func() { cache.(Resizer).Resize(0) })
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	cache := NewNamespaced(NewExpiring(NewCache(10, LRU)))
+	for i := 0; i < 10; i++ {
+		cache.Put(strconv.Itoa(i), i)
+	}
+	assert.True(t, Resize(cache, 5))
+	assert.Equal(t, 5, cache.Len())
+	assert.Equal(t, 5, cache.Limit())
+
+	// Embedding hides the Resize method of the cache.
+	hidden := struct{ Cache }{NewCache(10, LRU)}
+	assert.False(t, Resize(hidden, 5))
+}
+
+func TestAlgorithmOf(t *testing.T) {
+	for _, algo := range Algorithms() {
+		got, ok := AlgorithmOf(NewCache(10, algo))
+		assert.True(t, ok)
+		assert.Equal(t, algo, got)
+
+		got, ok = AlgorithmOf(NewExpiring(NewCache(10, algo)))
+		assert.True(t, ok)
+		assert.Equal(t, algo, got)
+	}
+
+	_, ok := AlgorithmOf(NewTiered(NewCache(10, LRU), CacheL2(NewCache(10, LRU))))
+	assert.False(t, ok)
+}
@@ -0,0 +1,189 @@
+package gofast
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time to the time-aware parts of gofast, such as Expiring and
+// Refreshing. RealClock is used by default; tests use a FakeClock to control
+// expiry and refresh without sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer returns a timer sending the current time on its channel after d.
+	NewTimer(d time.Duration) Timer
+	// After returns a channel receiving the current time after d.
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc calls f after d and returns a timer to cancel the call.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a single event of a Clock, like time.Timer.
+type Timer interface {
+	// C returns the channel the time is sent on. It is nil for AfterFunc timers.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing and reports whether it was active.
+	Stop() bool
+	// Reset makes the timer fire after d and reports whether it was active.
+	Reset(d time.Duration) bool
+}
+
+// RealClock returns the clock of the time package.
+func RealClock() Clock {
+	return realClock{}
+}
+
+// realClock is the Clock of the time package.
+type realClock struct{}
+
+// Now returns time.Now().
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns a timer wrapping time.NewTimer.
+func (realClock) NewTimer(d time.Duration) Timer {
+	t := time.NewTimer(d)
+	return &realTimer{timer: t, c: t.C}
+}
+
+// After returns time.After(d).
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// AfterFunc returns a timer wrapping time.AfterFunc.
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return &realTimer{timer: time.AfterFunc(d, f)}
+}
+
+// realTimer adapts a time.Timer to Timer.
+type realTimer struct {
+	timer *time.Timer
+	c     <-chan time.Time
+}
+
+// C returns the channel of the timer, nil for AfterFunc timers.
+func (t *realTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop stops the timer, like time.Timer.Stop.
+func (t *realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// Reset restarts the timer, like time.Timer.Reset.
+func (t *realTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+// FakeClock is a Clock whose time only moves when Advance is called, for
+// deterministic tests. It is safe for concurrent use.
+type FakeClock struct {
+	mu     *sync.Mutex
+	now    time.Time
+	timers map[*fakeTimer]struct{}
+}
+
+// fakeTimer is a pending event of a FakeClock.
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	// c is buffered, so firing never blocks; it is nil for AfterFunc timers.
+	c  chan time.Time
+	fn func()
+}
+
+// NewFakeClock returns a fake clock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		mu:     &sync.Mutex{},
+		now:    now,
+		timers: make(map[*fakeTimer]struct{}),
+	}
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d and fires the timers due by then, in
+// order. AfterFunc functions are called before Advance returns.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	var due []*fakeTimer
+	for t := range c.timers {
+		if !t.when.After(now) {
+			due = append(due, t)
+			delete(c.timers, t)
+		}
+	}
+	c.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].when.Before(due[j].when) })
+	for _, t := range due {
+		if t.fn != nil {
+			t.fn()
+			continue
+		}
+		select {
+		case t.c <- now:
+		default:
+		}
+	}
+}
+
+// NewTimer returns a timer firing once the clock is advanced by d.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.schedule(d, make(chan time.Time, 1), nil)
+}
+
+// After returns a channel receiving the time once the clock is advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// AfterFunc calls f once the clock is advanced by d, from Advance.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.schedule(d, nil, f)
+}
+
+// schedule adds a timer firing after d.
+func (c *FakeClock) schedule(d time.Duration, ch chan time.Time, fn func()) *fakeTimer {
+	t := &fakeTimer{clock: c, c: ch, fn: fn}
+	t.Reset(d)
+	return t
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	_, active := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	_, active := t.clock.timers[t]
+	t.when = t.clock.now.Add(d)
+	t.clock.timers[t] = struct{}{}
+	t.clock.mu.Unlock()
+	if d <= 0 {
+		// Like time.Timer, a timer with no duration fires at once.
+		t.clock.Advance(0)
+	}
+	return active
+}
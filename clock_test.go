@@ -0,0 +1,60 @@
+package gofast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fired reports whether ch has received a time.
+func fired(ch <-chan time.Time) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("advance", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		clock.Advance(time.Minute)
+		assert.Equal(t, start.Add(time.Minute), clock.Now())
+	})
+
+	t.Run("timers", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		timer := clock.NewTimer(10 * time.Second)
+		after := clock.After(20 * time.Second)
+		stopped := clock.NewTimer(5 * time.Second)
+		assert.True(t, stopped.Stop())
+		assert.False(t, stopped.Stop())
+
+		clock.Advance(9 * time.Second)
+		assert.False(t, fired(timer.C()))
+		clock.Advance(time.Second)
+		assert.True(t, fired(timer.C()))
+		assert.False(t, fired(after))
+		assert.False(t, fired(stopped.C()))
+
+		assert.False(t, timer.Reset(time.Second))
+		clock.Advance(15 * time.Second)
+		assert.True(t, fired(timer.C()))
+		assert.True(t, fired(after))
+	})
+
+	t.Run("after func", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		var order []int
+		clock.AfterFunc(2*time.Second, func() { order = append(order, 2) })
+		clock.AfterFunc(time.Second, func() { order = append(order, 1) })
+		clock.AfterFunc(0, func() { order = append(order, 0) })
+
+		clock.Advance(time.Hour)
+		assert.Equal(t, []int{0, 1, 2}, order)
+	})
+}
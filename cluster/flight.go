@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"errors"
+	"sync"
+)
+
+// errLoadPanicked is returned to the callers waiting on a load that panicked.
+var errLoadPanicked = errors.New("cluster: load panicked")
+
+// call is a load in progress.
+type call struct {
+	wg  sync.WaitGroup
+	val []byte
+	err error
+}
+
+// flight deduplicates concurrent loads of the same key.
+type flight struct {
+	mu    *sync.Mutex
+	calls map[string]*call
+}
+
+func newFlight() *flight {
+	return &flight{mu: &sync.Mutex{}, calls: make(map[string]*call)}
+}
+
+// do calls fn once for all the concurrent callers with the same key and
+// returns its result to each of them. fn runs with the context of the first
+// caller, so the others get its error if that context is canceled. If fn
+// panics, the panic propagates to the first caller and the others get
+// errLoadPanicked.
+func (f *flight) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	f.mu.Lock()
+	if c, ok := f.calls[key]; ok {
+		f.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+	c := &call{err: errLoadPanicked}
+	c.wg.Add(1)
+	f.calls[key] = c
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.calls, key)
+		f.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err
+}
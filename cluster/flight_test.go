@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlight_Panic(t *testing.T) {
+	f := newFlight()
+	started, release := make(chan struct{}), make(chan struct{})
+	waited := make(chan error)
+
+	go func() {
+		defer func() { recover() }()
+		f.do("k", func() ([]byte, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+	go func() {
+		// The load in progress is joined unless it already finished.
+		_, err := f.do("k", func() ([]byte, error) { return nil, nil })
+		waited <- err
+	}()
+	for i := 0; i < 100; i++ {
+		runtime.Gosched()
+	}
+	close(release)
+	if err := <-waited; err != nil {
+		assert.ErrorIs(t, err, errLoadPanicked)
+	}
+
+	// The key can be loaded again.
+	val, err := f.do("k", func() ([]byte, error) { return []byte("v"), nil })
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v"), val)
+}
@@ -0,0 +1,299 @@
+// Package cluster spreads a cache over a set of peers, in the style of
+// groupcache. A consistent-hash ring assigns every key an owner peer: the
+// owner loads the key from the source of truth and caches it, and the other
+// peers fetch it from the owner instead of caching their own copy. Keys that
+// are fetched often can be replicated locally in a small hot cache, so the
+// owner of a hot key does not serve every request for it.
+package cluster
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/raghavgh/gofast"
+)
+
+// DefaultReplicas is the default number of virtual nodes of every peer.
+const DefaultReplicas = 50
+
+// ErrNotFound is returned by a Getter for keys missing from the source of
+// truth. It is reported as such by peers, so Node.Get returns it too.
+var ErrNotFound = errors.New("cluster: key not found")
+
+// Getter loads the value of a key from the source of truth. It is called by
+// the key's owner on a miss, once for all the concurrent requests of the key.
+type Getter func(ctx context.Context, key string) ([]byte, error)
+
+// Transport fetches values from the other peers.
+type Transport interface {
+	// Fetch returns the value of key from peer, which owns it. Errors of the
+	// peer itself, such as its Getter failing, are returned as *PeerError, and
+	// ErrNotFound as is; any other error means peer could not be reached.
+	Fetch(ctx context.Context, peer, key string) ([]byte, error)
+}
+
+// PeerError is an error a peer reached by a Transport returned for a key.
+// Node.Get returns it as is, rather than loading the key itself as it does
+// when the owner of the key cannot be reached.
+type PeerError struct {
+	Peer string
+	Err  error
+}
+
+// Error returns the message of the error, prefixed with the peer.
+func (e *PeerError) Error() string {
+	return "cluster: peer " + e.Peer + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the peer.
+func (e *PeerError) Unwrap() error {
+	return e.Err
+}
+
+// NodeStats are counters of a node's activity.
+type NodeStats struct {
+	// Gets counts the calls to Node.Get.
+	Gets int64 `json:"gets"`
+	// Hits counts the gets served from the main or hot cache.
+	Hits int64 `json:"hits"`
+	// HotHits counts the gets served from the hot cache.
+	HotHits int64 `json:"hot_hits"`
+	// Loads counts the calls to the Getter.
+	Loads int64 `json:"loads"`
+	// PeerFetches counts the values fetched from other peers.
+	PeerFetches int64 `json:"peer_fetches"`
+	// PeerErrors counts the failed fetches from other peers, after which the
+	// node loads the value itself.
+	PeerErrors int64 `json:"peer_errors"`
+	// PeerRequests counts the requests served to other peers.
+	PeerRequests int64 `json:"peer_requests"`
+}
+
+// NodeOption configures a Node.
+type NodeOption func(n *Node)
+
+// WithReplicas sets the number of virtual nodes of every peer on the ring.
+// Defaults to DefaultReplicas. Every peer must use the same value.
+func WithReplicas(replicas int) NodeOption {
+	return func(n *Node) {
+		n.replicas = replicas
+	}
+}
+
+// WithHash sets the hash function of the ring. Defaults to crc32.ChecksumIEEE.
+// Every peer must use the same function.
+func WithHash(hash HashFunc) NodeOption {
+	return func(n *Node) {
+		n.hash = hash
+	}
+}
+
+// WithHotCache replicates values fetched from other peers in hot, one fetch in
+// every n on average. Keys fetched often are therefore soon replicated, while
+// keys fetched once rarely are. hot should be small: its entries are not
+// updated when the owner's copy changes, so bound their age with an expiring
+// cache if needed.
+func WithHotCache(hot gofast.Cache, n int) NodeOption {
+	return func(node *Node) {
+		node.hot = hot
+		node.hotEvery = n
+	}
+}
+
+// Node is a peer of a cluster. It serves Get for every key, caching in its
+// main cache only the keys it owns.
+type Node struct {
+	// The counters come first to be 64-bit aligned for the atomic package.
+	gets, hits, hotHits, numLoads, peerFetches, peerErrors, peerRequests int64
+
+	self      string
+	main      gofast.Cache
+	getter    Getter
+	transport Transport
+
+	replicas int
+	hash     HashFunc
+
+	hot      gofast.Cache
+	hotEvery int
+
+	mu   *sync.RWMutex
+	ring *Ring
+
+	// loads and fetches are kept apart so that serving a peer never waits on
+	// a fetch of this node, which could be waiting on that peer.
+	loads, fetches *flight
+	rand           *rand.Rand
+	// randMu guards rand, which is not safe for concurrent use.
+	randMu *sync.Mutex
+}
+
+// NewNode returns the node named self, caching its own keys in main and
+// loading them with getter. self is the name other peers reach it by, the
+// same as in SetPeers. transport reaches the other peers.
+func NewNode(self string, main gofast.Cache, getter Getter, transport Transport, opts ...NodeOption) *Node {
+	n := &Node{
+		self:      self,
+		main:      main,
+		getter:    getter,
+		transport: transport,
+		replicas:  DefaultReplicas,
+		mu:        &sync.RWMutex{},
+		loads:     newFlight(),
+		fetches:   newFlight(),
+		rand:      rand.New(rand.NewSource(rand.Int63())),
+		randMu:    &sync.Mutex{},
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	n.ring = NewRing(n.replicas, n.hash)
+	return n
+}
+
+// Self returns the name of the node.
+func (n *Node) Self() string {
+	return n.self
+}
+
+// SetPeers replaces the peers of the cluster, which should include the node
+// itself. Until it is called, the node owns every key.
+func (n *Node) SetPeers(peers ...string) {
+	ring := NewRing(n.replicas, n.hash)
+	ring.Add(peers...)
+	n.mu.Lock()
+	n.ring = ring
+	n.mu.Unlock()
+}
+
+// Owner returns the peer owning key.
+func (n *Node) Owner(key string) string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if owner := n.ring.Get(key); owner != "" {
+		return owner
+	}
+	return n.self
+}
+
+// Get returns the value of key, from the local caches, the owner of key or
+// the Getter. The returned slice must not be modified. Concurrent calls for
+// the same key share one fetch or load, made with the context of the first
+// call: if it is canceled, they all fail with its error.
+func (n *Node) Get(ctx context.Context, key string) ([]byte, error) {
+	atomic.AddInt64(&n.gets, 1)
+	if val, ok := lookup(n.main, key); ok {
+		atomic.AddInt64(&n.hits, 1)
+		return val, nil
+	}
+	if val, ok := lookup(n.hot, key); ok {
+		atomic.AddInt64(&n.hits, 1)
+		atomic.AddInt64(&n.hotHits, 1)
+		return val, nil
+	}
+
+	owner := n.Owner(key)
+	if owner == n.self || n.transport == nil {
+		return n.load(ctx, key)
+	}
+	return n.fetches.do(key, func() ([]byte, error) {
+		val, err := n.transport.Fetch(ctx, owner, key)
+		if err == nil {
+			atomic.AddInt64(&n.peerFetches, 1)
+			n.maybeReplicate(key, val)
+			return val, nil
+		}
+		var peerErr *PeerError
+		if errors.Is(err, ErrNotFound) || errors.As(err, &peerErr) || ctx.Err() != nil {
+			return nil, err
+		}
+		// The owner is unreachable: serve the key without caching it, the
+		// owner will be asked again next time.
+		atomic.AddInt64(&n.peerErrors, 1)
+		atomic.AddInt64(&n.numLoads, 1)
+		return n.getter(ctx, key)
+	})
+}
+
+// Remove drops key from the node's caches. Other peers may still hold it in
+// their hot caches.
+func (n *Node) Remove(key string) {
+	n.main.Remove(key)
+	if n.hot != nil {
+		n.hot.Remove(key)
+	}
+}
+
+// Stats returns the counters of the node.
+func (n *Node) Stats() NodeStats {
+	return NodeStats{
+		Gets:         atomic.LoadInt64(&n.gets),
+		Hits:         atomic.LoadInt64(&n.hits),
+		HotHits:      atomic.LoadInt64(&n.hotHits),
+		Loads:        atomic.LoadInt64(&n.numLoads),
+		PeerFetches:  atomic.LoadInt64(&n.peerFetches),
+		PeerErrors:   atomic.LoadInt64(&n.peerErrors),
+		PeerRequests: atomic.LoadInt64(&n.peerRequests),
+	}
+}
+
+// serve answers the request of another peer for key. The node loads the key
+// itself rather than forwarding it, even if it does not own it by its own
+// ring, so that peers disagreeing on membership cannot loop.
+func (n *Node) serve(ctx context.Context, key string) ([]byte, error) {
+	atomic.AddInt64(&n.peerRequests, 1)
+	if val, ok := lookup(n.main, key); ok {
+		return val, nil
+	}
+	return n.load(ctx, key)
+}
+
+// load calls the Getter for key, once for concurrent callers, and caches the
+// value in the main cache.
+func (n *Node) load(ctx context.Context, key string) ([]byte, error) {
+	return n.loads.do(key, func() ([]byte, error) {
+		// Another caller may have loaded the key since the lookup.
+		if val, ok := lookup(n.main, key); ok {
+			return val, nil
+		}
+		atomic.AddInt64(&n.numLoads, 1)
+		val, err := n.getter(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		n.main.Put(key, val)
+		return val, nil
+	})
+}
+
+// maybeReplicate puts a fetched value in the hot cache one time in hotEvery.
+func (n *Node) maybeReplicate(key string, val []byte) {
+	if n.hot == nil {
+		return
+	}
+	if n.hotEvery > 1 {
+		n.randMu.Lock()
+		skip := n.rand.Intn(n.hotEvery) != 0
+		n.randMu.Unlock()
+		if skip {
+			return
+		}
+	}
+	n.hot.Put(key, val)
+}
+
+// lookup returns the value of key in c, which may be nil.
+func lookup(c gofast.Cache, key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	val, ok := c.Get(key)
+	if !ok {
+		return nil, false
+	}
+	b, ok := val.([]byte)
+	return b, ok
+}
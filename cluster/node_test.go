@@ -0,0 +1,221 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raghavgh/gofast"
+)
+
+// source is a source of truth counting its loads.
+type source struct {
+	mu    sync.Mutex
+	loads map[string]int
+}
+
+func newSource() *source {
+	return &source{loads: make(map[string]int)}
+}
+
+func (s *source) get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key == "missing" {
+		return nil, ErrNotFound
+	}
+	if key == "failing" {
+		s.loads[key]++
+		return nil, errors.New("backend down")
+	}
+	s.loads[key]++
+	return []byte("value of " + key), nil
+}
+
+// newCluster returns nodes named "a", "b", ... connected in process.
+func newCluster(src *source, size int, opts ...NodeOption) ([]*Node, *InProcess) {
+	transport := NewInProcess()
+	var nodes []*Node
+	var names []string
+	for i := 0; i < size; i++ {
+		name := string(rune('a' + i))
+		n := NewNode(name, gofast.NewCache(100, gofast.LRU), src.get, transport, opts...)
+		transport.Register(n)
+		nodes = append(nodes, n)
+		names = append(names, name)
+	}
+	for _, n := range nodes {
+		n.SetPeers(names...)
+	}
+	return nodes, transport
+}
+
+func TestNode(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("every key is cached once", func(t *testing.T) {
+		src := newSource()
+		nodes, _ := newCluster(src, 3)
+
+		for i := 0; i < 30; i++ {
+			key := strconv.Itoa(i)
+			for _, n := range nodes {
+				val, err := n.Get(ctx, key)
+				require.NoError(t, err)
+				assert.Equal(t, "value of "+key, string(val))
+			}
+			assert.Equal(t, 1, src.loads[key])
+		}
+
+		total := 0
+		for _, n := range nodes {
+			total += n.main.Len()
+			for _, key := range n.main.(gofast.Inspector).Keys() {
+				assert.Equal(t, n.Self(), n.Owner(key))
+			}
+		}
+		assert.Equal(t, 30, total)
+	})
+
+	t.Run("single owner", func(t *testing.T) {
+		src := newSource()
+		n := NewNode("solo", gofast.NewCache(10, gofast.LRU), src.get, nil)
+		for i := 0; i < 3; i++ {
+			_, err := n.Get(ctx, "a")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, NodeStats{Gets: 3, Hits: 2, Loads: 1}, n.Stats())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		nodes, _ := newCluster(newSource(), 3)
+		for _, n := range nodes {
+			_, err := n.Get(ctx, "missing")
+			assert.True(t, errors.Is(err, ErrNotFound))
+		}
+	})
+
+	t.Run("hot cache", func(t *testing.T) {
+		src := newSource()
+		nodes, _ := newCluster(src, 2, WithHotCache(gofast.NewCache(10, gofast.LRU), 1))
+		a, b := nodes[0], nodes[1]
+		key := keyOwnedBy(b)
+
+		for i := 0; i < 3; i++ {
+			_, err := a.Get(ctx, key)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int64(1), a.Stats().PeerFetches)
+		assert.Equal(t, int64(2), a.Stats().HotHits)
+		assert.Equal(t, int64(1), b.Stats().PeerRequests)
+	})
+
+	t.Run("owner down", func(t *testing.T) {
+		src := newSource()
+		nodes, transport := newCluster(src, 2)
+		a, b := nodes[0], nodes[1]
+		key := keyOwnedBy(b)
+		transport.Unregister(b.Self())
+
+		val, err := a.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "value of "+key, string(val))
+		assert.Equal(t, int64(1), a.Stats().PeerErrors)
+		assert.Equal(t, 0, a.main.Len())
+	})
+
+	t.Run("owner's getter fails", func(t *testing.T) {
+		src := newSource()
+		nodes, _ := newCluster(src, 2)
+		a, b := nodes[0], nodes[1]
+		if a.Owner("failing") == a.Self() {
+			a, b = b, a
+		}
+
+		_, err := a.Get(ctx, "failing")
+		var peerErr *PeerError
+		require.True(t, errors.As(err, &peerErr))
+		assert.Equal(t, b.Self(), peerErr.Peer)
+		assert.Contains(t, err.Error(), "backend down")
+		// The backend is only asked by the owner.
+		assert.Equal(t, 1, src.loads["failing"])
+		assert.Equal(t, int64(0), a.Stats().PeerErrors)
+	})
+
+	t.Run("concurrent gets load once", func(t *testing.T) {
+		src := newSource()
+		nodes, _ := newCluster(src, 3)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(n *Node) {
+				defer wg.Done()
+				_, err := n.Get(ctx, "shared")
+				assert.NoError(t, err)
+			}(nodes[i%len(nodes)])
+		}
+		wg.Wait()
+		assert.Equal(t, 1, src.loads["shared"])
+	})
+}
+
+func TestHTTPTransport(t *testing.T) {
+	ctx := context.Background()
+	src := newSource()
+	transport := NewHTTPTransport(nil, "")
+
+	var nodes []*Node
+	var peers []string
+	for i := 0; i < 2; i++ {
+		// The server is started first for the node to be named by its URL.
+		var handler http.Handler
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		n := NewNode(srv.URL, gofast.NewCache(10, gofast.LRU), src.get, transport)
+		handler = HTTPHandler(n)
+		nodes = append(nodes, n)
+		peers = append(peers, srv.URL)
+	}
+	for _, n := range nodes {
+		n.SetPeers(peers...)
+	}
+
+	a, b := nodes[0], nodes[1]
+	key := keyOwnedBy(b)
+	val, err := a.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "value of "+key, string(val))
+	assert.Equal(t, int64(1), a.Stats().PeerFetches)
+	assert.True(t, b.main.Contains(key))
+
+	_, err = a.Get(ctx, "missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = transport.Fetch(ctx, b.Self(), "failing")
+	var peerErr *PeerError
+	require.True(t, errors.As(err, &peerErr))
+	assert.Equal(t, "cluster: peer "+b.Self()+": backend down", err.Error())
+
+	_, err = transport.Fetch(ctx, "http://127.0.0.1:1", "a")
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &peerErr))
+}
+
+// keyOwnedBy returns a key owned by n.
+func keyOwnedBy(n *Node) string {
+	for i := 0; ; i++ {
+		key := "key" + strconv.Itoa(i)
+		if n.Owner(key) == n.Self() {
+			return key
+		}
+	}
+}
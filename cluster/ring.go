@@ -0,0 +1,78 @@
+package cluster
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// HashFunc hashes keys and virtual node names onto the ring.
+type HashFunc func(data []byte) uint32
+
+// Ring is a consistent-hash ring assigning keys to peers. Each peer is placed
+// on the ring at several points, its virtual nodes, which spreads the keys
+// evenly and moves only about 1/n of them when a peer joins or leaves.
+//
+// A Ring is not safe for concurrent modification; Node builds a new one on
+// every change of peers.
+type Ring struct {
+	hash     HashFunc
+	replicas int
+	// points are the sorted hashes of the virtual nodes.
+	points []uint32
+	owners map[uint32]string
+}
+
+// NewRing returns an empty ring placing every peer at replicas virtual nodes.
+// hash defaults to crc32.ChecksumIEEE when nil.
+func NewRing(replicas int, hash HashFunc) *Ring {
+	if replicas <= 0 {
+		panic("cluster: replicas must be greater than 0")
+	}
+	if hash == nil {
+		hash = crc32.ChecksumIEEE
+	}
+	return &Ring{
+		hash:     hash,
+		replicas: replicas,
+		owners:   make(map[uint32]string),
+	}
+}
+
+// Add places peers on the ring. When virtual nodes of two peers hash to the
+// same point, the peer with the smaller name owns it, so that rings built
+// from the same peers agree whatever the order they were added in.
+func (r *Ring) Add(peers ...string) {
+	for _, peer := range peers {
+		for i := 0; i < r.replicas; i++ {
+			point := r.hash([]byte(strconv.Itoa(i) + peer))
+			owner, taken := r.owners[point]
+			if !taken {
+				r.points = append(r.points, point)
+			} else if owner < peer {
+				continue
+			}
+			r.owners[point] = peer
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+// Empty reports whether the ring has no peers.
+func (r *Ring) Empty() bool {
+	return len(r.points) == 0
+}
+
+// Get returns the peer owning key: the peer of the first virtual node at or
+// after the hash of key, wrapping around. It returns "" if the ring is empty.
+func (r *Ring) Get(key string) string {
+	if r.Empty() {
+		return ""
+	}
+	h := r.hash([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		r := NewRing(10, nil)
+		assert.True(t, r.Empty())
+		assert.Equal(t, "", r.Get("a"))
+	})
+
+	t.Run("owner", func(t *testing.T) {
+		// Hash decimal strings to their value: the virtual nodes of "2" are
+		// at 2, 12 and 22, those of "4" at 4, 14 and 24.
+		r := NewRing(3, func(data []byte) uint32 {
+			n, _ := strconv.Atoi(string(data))
+			return uint32(n)
+		})
+		r.Add("2", "4")
+
+		for key, owner := range map[string]string{
+			"2": "2", "3": "4", "11": "2", "13": "4", "23": "4", "25": "2",
+		} {
+			assert.Equal(t, owner, r.Get(key), key)
+		}
+	})
+
+	t.Run("collisions", func(t *testing.T) {
+		// Every virtual node hashes to the same point.
+		collide := func(data []byte) uint32 { return 7 }
+		forward, backward := NewRing(3, collide), NewRing(3, collide)
+		forward.Add("a", "b", "c")
+		backward.Add("c", "b")
+		backward.Add("a")
+
+		assert.Equal(t, "a", forward.Get("key"))
+		assert.Equal(t, "a", backward.Get("key"))
+	})
+
+	t.Run("balance and stability", func(t *testing.T) {
+		r := NewRing(DefaultReplicas, nil)
+		peers := []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://10.0.0.3:8080"}
+		r.Add(peers...)
+
+		counts := make(map[string]int)
+		before := make(map[string]string)
+		for i := 0; i < 3000; i++ {
+			key := strconv.Itoa(i)
+			before[key] = r.Get(key)
+			counts[before[key]]++
+		}
+		for _, peer := range peers {
+			assert.InDelta(t, 1000, counts[peer], 400, peer)
+		}
+
+		// Adding a peer only moves keys to it.
+		r.Add("http://10.0.0.4:8080")
+		moved := 0
+		for key, owner := range before {
+			if now := r.Get(key); now != owner {
+				assert.Equal(t, "http://10.0.0.4:8080", now)
+				moved++
+			}
+		}
+		assert.InDelta(t, 750, moved, 400)
+	})
+}
@@ -0,0 +1,140 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultBasePath is the path peers serve their keys under by default.
+const DefaultBasePath = "/_gofast/"
+
+// HTTPHandler returns the handler answering the fetches of other peers for
+// the keys n owns. Mount it at the base path of the HTTPTransport of the
+// peers, DefaultBasePath by default. Requests are GET with the key in the
+// "key" query parameter; the response is the value, or 404 if the Getter
+// returned ErrNotFound.
+func HTTPHandler(n *Node) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			http.Error(w, "missing key", http.StatusBadRequest)
+			return
+		}
+		val, err := n.serve(r.Context(), key)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(val)
+	})
+}
+
+// HTTPTransport fetches values from peers serving HTTPHandler. Peers are
+// named by their base URL, such as "http://10.0.0.1:8080".
+type HTTPTransport struct {
+	client   *http.Client
+	basePath string
+}
+
+// NewHTTPTransport returns a transport fetching values with client, or
+// http.DefaultClient if nil, from the handlers mounted at basePath, or
+// DefaultBasePath if empty.
+func NewHTTPTransport(client *http.Client, basePath string) *HTTPTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if basePath == "" {
+		basePath = DefaultBasePath
+	}
+	return &HTTPTransport{client: client, basePath: basePath}
+}
+
+// Fetch returns the value of key from peer.
+func (t *HTTPTransport) Fetch(ctx context.Context, peer, key string) ([]byte, error) {
+	u := strings.TrimSuffix(peer, "/") + t.basePath + "?key=" + url.QueryEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusInternalServerError:
+		// HTTPHandler replies 500 when the Getter of the peer failed.
+		return nil, &PeerError{Peer: peer, Err: errors.New(strings.TrimSpace(string(body)))}
+	default:
+		return nil, fmt.Errorf("cluster: peer %s: %s: %s", peer, resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+// InProcess is a Transport calling nodes of the same process directly,
+// for tests and simulations.
+type InProcess struct {
+	mu    *sync.RWMutex
+	nodes map[string]*Node
+}
+
+// NewInProcess returns a transport with no nodes.
+func NewInProcess() *InProcess {
+	return &InProcess{mu: &sync.RWMutex{}, nodes: make(map[string]*Node)}
+}
+
+// Register makes n reachable under its name.
+func (t *InProcess) Register(n *Node) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nodes[n.Self()] = n
+}
+
+// Unregister makes the node named peer unreachable, as if it were down.
+func (t *InProcess) Unregister(peer string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.nodes, peer)
+}
+
+// Fetch returns the value of key from peer. The value is copied, as it would
+// be over a network.
+func (t *InProcess) Fetch(ctx context.Context, peer, key string) ([]byte, error) {
+	t.mu.RLock()
+	n, ok := t.nodes[peer]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("cluster: unknown peer %s", peer)
+	}
+	val, err := n.serve(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &PeerError{Peer: peer, Err: err}
+	}
+	return append([]byte(nil), val...), nil
+}
@@ -0,0 +1,86 @@
+// Command gofast-server serves a gofast cache over the Redis protocol or the
+// memcached text protocol, so that Redis or memcached clients can use it.
+//
+// Usage:
+//
+//	gofast-server -addr :6379 -limit 100000 -algorithm sieve
+//	redis-cli -p 6379 SET greeting hello EX 60
+//	gofast-server -protocol memcache -addr :11211
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/raghavgh/gofast"
+	"github.com/raghavgh/gofast/server/memcache"
+	"github.com/raghavgh/gofast/server/resp"
+)
+
+// server is implemented by the protocol servers.
+type server interface {
+	ListenAndServe(addr string) error
+	Close() error
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "gofast-server:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses args and serves the cache until SIGINT or SIGTERM.
+func run(args []string) error {
+	flags := flag.NewFlagSet("gofast-server", flag.ContinueOnError)
+	protocol := flags.String("protocol", "resp", "protocol to serve: resp or memcache")
+	addr := flags.String("addr", "", "TCP address to listen on, :6379 for resp and :11211 for memcache by default")
+	limit := flags.Int("limit", 10000, "maximum number of keys in the cache")
+	algorithm := flags.String("algorithm", "lru", "eviction algorithm")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *limit <= 0 {
+		return fmt.Errorf("invalid limit %d", *limit)
+	}
+	algo, err := gofast.ParseAlgorithm(*algorithm)
+	if err != nil {
+		return err
+	}
+
+	cache := gofast.NewExpiring(gofast.NewCache(*limit, algo))
+	var srv server
+	switch *protocol {
+	case "resp":
+		srv = resp.NewServer(cache)
+		if *addr == "" {
+			*addr = ":6379"
+		}
+	case "memcache":
+		srv = memcache.NewServer(cache)
+		if *addr == "" {
+			*addr = ":11211"
+		}
+	default:
+		return fmt.Errorf("unknown protocol %q", *protocol)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		srv.Close()
+	}()
+
+	log.Printf("gofast-server: serving %s cache of %d keys over %s on %s", algo, *limit, *protocol, *addr)
+	if err := srv.ListenAndServe(*addr); !errors.Is(err, resp.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Command gofast-sim replays access traces against the gofast cache algorithms
+// and reports hit ratio, byte hit ratio and throughput for each of them.
+//
+// Usage:
+//
+//	gofast-sim -trace trace.txt -format keys -capacities 100,1000,10000
+//	gofast-sim -trace OLTP.lis -format arc -algorithms lru,sieve -output csv
+//	cat trace.csv | gofast-sim -format csv
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/raghavgh/gofast"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "gofast-sim:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses args, replays the trace and writes the report to stdout.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gofast-sim", flag.ContinueOnError)
+	tracePath := flags.String("trace", "-", "trace file to replay, - for stdin")
+	format := flags.String("format", FormatKeys, "trace format: keys, arc, lirs or csv")
+	capacities := flags.String("capacities", "100,1000,10000", "comma separated cache capacities, in entries")
+	algorithms := flags.String("algorithms", "", "comma separated algorithms to compare, all when empty")
+	output := flags.String("output", "table", "output format: table or csv")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	caps, err := parseCapacities(*capacities)
+	if err != nil {
+		return err
+	}
+	algos, err := parseAlgorithms(*algorithms)
+	if err != nil {
+		return err
+	}
+
+	var write func(io.Writer, []Result) error
+	switch *output {
+	case "table":
+		write = WriteTable
+	case "csv":
+		write = WriteCSV
+	default:
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	in := stdin
+	if *tracePath != "-" {
+		f, err := os.Open(*tracePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	trace, err := ReadTrace(in, *format)
+	if err != nil {
+		return fmt.Errorf("reading trace: %w", err)
+	}
+
+	results := make([]Result, 0, len(caps)*len(algos))
+	for _, capacity := range caps {
+		for _, algo := range algos {
+			results = append(results, Simulate(trace, algo, capacity))
+		}
+	}
+	return write(stdout, results)
+}
+
+// parseCapacities parses a comma separated list of positive integers.
+func parseCapacities(s string) ([]int, error) {
+	var caps []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		capacity, err := strconv.Atoi(field)
+		if err != nil || capacity <= 0 {
+			return nil, fmt.Errorf("invalid capacity %q", field)
+		}
+		caps = append(caps, capacity)
+	}
+	if len(caps) == 0 {
+		return nil, fmt.Errorf("no capacities given")
+	}
+	return caps, nil
+}
+
+// parseAlgorithms parses a comma separated list of algorithm names,
+// returning every algorithm supported by gofast.NewCache when s is empty.
+func parseAlgorithms(s string) ([]gofast.Algorithm, error) {
+	if strings.TrimSpace(s) == "" {
+		return gofast.Algorithms(), nil
+	}
+
+	var algos []gofast.Algorithm
+	for _, name := range strings.Split(s, ",") {
+		algo, err := gofast.ParseAlgorithm(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		algos = append(algos, algo)
+	}
+	return algos, nil
+}
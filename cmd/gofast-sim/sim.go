@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/raghavgh/gofast"
+)
+
+// Result holds the outcome of replaying a trace against one cache.
+type Result struct {
+	Algorithm gofast.Algorithm
+	Capacity  int
+	Requests  int
+	Hits      int
+	Bytes     int64
+	HitBytes  int64
+	Elapsed   time.Duration
+}
+
+// HitRatio returns the fraction of requests that were hits.
+func (r Result) HitRatio() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Requests)
+}
+
+// ByteHitRatio returns the fraction of requested bytes that were served by hits.
+func (r Result) ByteHitRatio() float64 {
+	if r.Bytes == 0 {
+		return 0
+	}
+	return float64(r.HitBytes) / float64(r.Bytes)
+}
+
+// Throughput returns the number of requests replayed per second.
+func (r Result) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// Simulate replays trace against a fresh cache built with NewCache.
+// Every request is a Get, and every miss is followed by a Put of the key.
+func Simulate(trace []Request, algo gofast.Algorithm, capacity int) Result {
+	cache := gofast.NewCache(capacity, algo)
+	result := Result{Algorithm: algo, Capacity: capacity, Requests: len(trace)}
+
+	start := time.Now()
+	for _, req := range trace {
+		result.Bytes += req.Size
+		if _, ok := cache.Get(req.Key); ok {
+			result.Hits++
+			result.HitBytes += req.Size
+			continue
+		}
+		cache.Put(req.Key, req.Size)
+	}
+	result.Elapsed = time.Since(start)
+
+	return result
+}
+
+// header is the column header of both output formats.
+var header = []string{"algorithm", "capacity", "requests", "hit_ratio", "byte_hit_ratio", "ops_per_sec"}
+
+// record formats r as a row of the output.
+func record(r Result) []string {
+	return []string{
+		r.Algorithm.String(),
+		strconv.Itoa(r.Capacity),
+		strconv.Itoa(r.Requests),
+		strconv.FormatFloat(r.HitRatio(), 'f', 4, 64),
+		strconv.FormatFloat(r.ByteHitRatio(), 'f', 4, 64),
+		strconv.FormatFloat(r.Throughput(), 'f', 0, 64),
+	}
+}
+
+// WriteTable writes results as an aligned text table.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	rows := [][]string{header}
+	for _, r := range results {
+		rows = append(rows, record(r))
+	}
+	for _, row := range rows {
+		for _, col := range row {
+			if _, err := fmt.Fprint(tw, col, "\t"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(tw); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes results as CSV with a header row.
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if err := cw.Write(record(r)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
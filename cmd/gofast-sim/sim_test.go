@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/raghavgh/gofast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTrace(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   string
+		input    string
+		expected []Request
+	}{
+		{
+			name:   "keys",
+			format: FormatKeys,
+			input:  "# comment\na\n\nb extra\n a \n",
+			expected: []Request{
+				{Key: "a", Size: 1}, {Key: "b", Size: 1}, {Key: "a", Size: 1},
+			},
+		},
+		{
+			name:   "arc",
+			format: FormatARC,
+			input:  "10 3 0 1\n5 1 0 2\n",
+			expected: []Request{
+				{Key: "10", Size: 1}, {Key: "11", Size: 1}, {Key: "12", Size: 1}, {Key: "5", Size: 1},
+			},
+		},
+		{
+			name:   "lirs",
+			format: FormatLIRS,
+			input:  "1\n2\n1\n*\n",
+			expected: []Request{
+				{Key: "1", Size: 1}, {Key: "2", Size: 1}, {Key: "1", Size: 1},
+			},
+		},
+		{
+			name:   "csv with header and sizes",
+			format: FormatCSV,
+			input:  "key,size\na,100\nb,\n\"c,d\",7\n",
+			expected: []Request{
+				{Key: "a", Size: 100}, {Key: "b", Size: 1}, {Key: "c,d", Size: 7},
+			},
+		},
+		{
+			name:   "csv without sizes",
+			format: FormatCSV,
+			input:  "a\nb\n",
+			expected: []Request{
+				{Key: "a", Size: 1}, {Key: "b", Size: 1},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			trace, err := ReadTrace(strings.NewReader(tc.input), tc.format)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, trace)
+		})
+	}
+}
+
+func TestReadTrace_Errors(t *testing.T) {
+	_, err := ReadTrace(strings.NewReader("a"), "unknown")
+	assert.Error(t, err)
+
+	_, err = ReadTrace(strings.NewReader("10 x 0 1\n"), FormatARC)
+	assert.ErrorContains(t, err, "line 1")
+
+	_, err = ReadTrace(strings.NewReader("a,1\nb,big\n"), FormatCSV)
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestSimulate(t *testing.T) {
+	trace := []Request{
+		{Key: "a", Size: 10}, {Key: "b", Size: 20}, {Key: "a", Size: 10}, {Key: "c", Size: 30}, {Key: "b", Size: 20},
+	}
+
+	result := Simulate(trace, gofast.LRU, 2)
+	assert.Equal(t, 5, result.Requests)
+	// "a" hits, "b" is evicted by "c" before it is requested again.
+	assert.Equal(t, 1, result.Hits)
+	assert.InDelta(t, 0.2, result.HitRatio(), 1e-9)
+	assert.InDelta(t, 10.0/90.0, result.ByteHitRatio(), 1e-9)
+	assert.Greater(t, result.Throughput(), 0.0)
+}
+
+func TestRun(t *testing.T) {
+	stdin := strings.NewReader("a\nb\na\nc\na\nb\n")
+
+	out := &bytes.Buffer{}
+	err := run([]string{"-capacities", "1,2", "-algorithms", "lru,sieve", "-output", "csv"}, stdin, out)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "algorithm,capacity,requests,hit_ratio,byte_hit_ratio,ops_per_sec", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "LRU,1,6,0.0000,0.0000,"))
+	assert.True(t, strings.HasPrefix(lines[3], "LRU,2,6,0.3333,0.3333,"))
+}
+
+func TestRun_Table(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := run([]string{"-capacities", "10"}, strings.NewReader("a\na\n"), out)
+	require.NoError(t, err)
+
+	for _, algo := range gofast.Algorithms() {
+		assert.Contains(t, out.String(), algo.String())
+	}
+}
+
+func TestRun_InvalidFlags(t *testing.T) {
+	assert.Error(t, run([]string{"-capacities", "0"}, strings.NewReader(""), &bytes.Buffer{}))
+	assert.Error(t, run([]string{"-algorithms", "nope"}, strings.NewReader(""), &bytes.Buffer{}))
+	assert.Error(t, run([]string{"-output", "xml"}, strings.NewReader(""), &bytes.Buffer{}))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Request is a single access of a trace.
+type Request struct {
+	Key string
+	// Size is the object size in bytes, 1 when the trace carries no sizes.
+	Size int64
+}
+
+// Supported trace formats.
+const (
+	// FormatKeys is one key per line, only the first whitespace separated field is used.
+	FormatKeys = "keys"
+	// FormatARC is the format of the ARC traces: "start_block num_blocks ignored request_id" per line,
+	// each line expands to num_blocks consecutive block accesses.
+	FormatARC = "arc"
+	// FormatLIRS is the format of the LIRS traces: one block number per line,
+	// non numeric lines (like the trailing "*") are skipped.
+	FormatLIRS = "lirs"
+	// FormatCSV is "key[,size]" per line, a first line with a non numeric size is treated as a header.
+	FormatCSV = "csv"
+)
+
+// ReadTrace parses all requests of r in the given format.
+func ReadTrace(r io.Reader, format string) ([]Request, error) {
+	switch format {
+	case FormatKeys:
+		return readLines(r, parseKeyLine)
+	case FormatARC:
+		return readLines(r, parseARCLine)
+	case FormatLIRS:
+		return readLines(r, parseLIRSLine)
+	case FormatCSV:
+		return readCSV(r)
+	default:
+		return nil, fmt.Errorf("unknown trace format %q", format)
+	}
+}
+
+// lineParser appends the requests of a single trimmed, non empty line to trace.
+type lineParser func(trace []Request, line string) ([]Request, error)
+
+// readLines runs parse on each line of r, skipping blank lines and '#' comments.
+func readLines(r io.Reader, parse lineParser) ([]Request, error) {
+	var trace []Request
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var err error
+		if trace, err = parse(trace, line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+func parseKeyLine(trace []Request, line string) ([]Request, error) {
+	return append(trace, Request{Key: strings.Fields(line)[0], Size: 1}), nil
+}
+
+func parseARCLine(trace []Request, line string) ([]Request, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil, errors.New("expected at least start block and block count")
+	}
+	start, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start block: %w", err)
+	}
+	count, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid block count %q", fields[1])
+	}
+	for block := start; block < start+count; block++ {
+		trace = append(trace, Request{Key: strconv.FormatInt(block, 10), Size: 1})
+	}
+	return trace, nil
+}
+
+func parseLIRSLine(trace []Request, line string) ([]Request, error) {
+	if _, err := strconv.ParseInt(line, 10, 64); err != nil {
+		return trace, nil
+	}
+	return append(trace, Request{Key: line, Size: 1}), nil
+}
+
+// readCSV parses "key[,size]" records.
+func readCSV(r io.Reader) ([]Request, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var trace []Request
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return trace, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 0 || record[0] == "" {
+			continue
+		}
+
+		req := Request{Key: record[0], Size: 1}
+		if len(record) > 1 && record[1] != "" {
+			size, err := strconv.ParseInt(record[1], 10, 64)
+			if err != nil || size < 0 {
+				if first {
+					continue
+				}
+				line, _ := reader.FieldPos(1)
+				return nil, fmt.Errorf("line %d: invalid size %q", line, record[1])
+			}
+			req.Size = size
+		}
+		trace = append(trace, req)
+	}
+}
@@ -0,0 +1,90 @@
+// Package codec converts cache values to and from bytes, for the caches that
+// store values outside the Go heap or send them over the network, and
+// optionally compresses the encoded bytes.
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec converts cache values to and from bytes.
+type Codec interface {
+	Encode(val any) ([]byte, error)
+	Decode(data []byte) (any, error)
+}
+
+// Gob encodes values with encoding/gob. Values of types other than the Go
+// basic types must be registered with gob.Register before they are stored.
+type Gob struct{}
+
+// Encode encodes val.
+func (Gob) Encode(val any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(&val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a value encoded by Encode.
+func (Gob) Decode(data []byte) (any, error) {
+	var val any
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// JSON encodes values with encoding/json.
+type JSON struct {
+	// New returns a pointer to the value to decode into, such as a new struct
+	// of the type stored. If New is nil, values are decoded into an any, so
+	// objects come back as map[string]any and numbers as float64.
+	New func() any
+}
+
+// Encode encodes val.
+func (j JSON) Encode(val any) ([]byte, error) {
+	return json.Marshal(val)
+}
+
+// Decode decodes a value encoded by Encode.
+func (j JSON) Decode(data []byte) (any, error) {
+	if j.New == nil {
+		var val any
+		if err := json.Unmarshal(data, &val); err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+	val := j.New()
+	if err := json.Unmarshal(data, val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// Raw stores []byte and string values as they are, for values that are
+// already serialized, such as protobuf messages or JSON documents. Values are
+// always decoded as []byte.
+type Raw struct{}
+
+// Encode returns a copy of val, which must be a []byte or a string.
+func (Raw) Encode(val any) ([]byte, error) {
+	switch v := val.(type) {
+	case []byte:
+		return append([]byte(nil), v...), nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("codec: raw codec cannot encode %T", val)
+	}
+}
+
+// Decode returns a copy of data, so that callers may modify it.
+func (Raw) Decode(data []byte) (any, error) {
+	return append([]byte(nil), data...), nil
+}
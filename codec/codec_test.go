@@ -0,0 +1,116 @@
+package codec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestCodecs(t *testing.T) {
+	t.Run("gob", func(t *testing.T) {
+		data, err := Gob{}.Encode(42)
+		require.NoError(t, err)
+		val, err := Gob{}.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, 42, val)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		data, err := JSON{}.Encode(user{Name: "ada", Age: 36})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"ada","age":36}`, string(data))
+
+		val, err := JSON{}.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"name": "ada", "age": float64(36)}, val)
+
+		typed := JSON{New: func() any { return &user{} }}
+		val, err = typed.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, &user{Name: "ada", Age: 36}, val)
+
+		_, err = typed.Decode([]byte("{"))
+		assert.Error(t, err)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		in := []byte("payload")
+		data, err := Raw{}.Encode(in)
+		require.NoError(t, err)
+		in[0] = 'P'
+		val, err := Raw{}.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("payload"), val)
+
+		data, err = Raw{}.Encode("text")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("text"), data)
+
+		_, err = Raw{}.Encode(42)
+		assert.EqualError(t, err, "codec: raw codec cannot encode int")
+	})
+}
+
+func TestCompression(t *testing.T) {
+	large := bytes.Repeat([]byte("gofast "), 100)
+
+	for name, comp := range map[string]Compressor{
+		"flate": Flate(flate.BestSpeed),
+		"gzip":  Gzip(gzip.DefaultCompression),
+	} {
+		t.Run(name, func(t *testing.T) {
+			packed, err := Pack(comp, 64, large)
+			require.NoError(t, err)
+			assert.True(t, IsCompressed(packed))
+			assert.Less(t, len(packed), len(large)/4)
+			data, err := Unpack(comp, packed)
+			require.NoError(t, err)
+			assert.Equal(t, large, data)
+
+			// Below the threshold.
+			packed, err = Pack(comp, 64, []byte("small"))
+			require.NoError(t, err)
+			assert.False(t, IsCompressed(packed))
+			data, err = Unpack(comp, packed)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("small"), data)
+		})
+	}
+
+	t.Run("incompressible data is stored", func(t *testing.T) {
+		data := []byte{0x8f, 0x13, 0xa2, 0x77, 0x05, 0xe9}
+		packed, err := Pack(Flate(flate.BestCompression), 0, data)
+		require.NoError(t, err)
+		assert.False(t, IsCompressed(packed))
+	})
+
+	t.Run("corrupt data", func(t *testing.T) {
+		_, err := Unpack(Flate(flate.BestSpeed), nil)
+		assert.ErrorIs(t, err, ErrCorrupt)
+		_, err = Unpack(Flate(flate.BestSpeed), []byte{7, 1})
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+
+	t.Run("compressed codec", func(t *testing.T) {
+		c := Compressed(Raw{}, Flate(flate.BestSpeed), 64)
+		data, err := c.Encode(large)
+		require.NoError(t, err)
+		assert.True(t, IsCompressed(data))
+		val, err := c.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, large, val)
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		assert.Panics(t, func() { Flate(42) })
+	})
+}
@@ -0,0 +1,172 @@
+package codec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Compressor compresses encoded values.
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// Flate returns a Compressor using DEFLATE at level, from flate.HuffmanOnly
+// to flate.BestCompression. flate.BestSpeed suits caches best, compressing
+// several hundred MB/s. It panics if level is invalid.
+func Flate(level int) Compressor {
+	return newStreamCompressor(level,
+		func(w io.Writer, level int) (streamWriter, error) {
+			return flate.NewWriter(w, level)
+		},
+		func(r io.Reader) (io.ReadCloser, error) {
+			return flate.NewReader(r), nil
+		})
+}
+
+// Gzip returns a Compressor using gzip at level, from gzip.HuffmanOnly to
+// gzip.BestCompression. It compresses as Flate does, with a larger header and
+// a checksum. It panics if level is invalid.
+func Gzip(level int) Compressor {
+	return newStreamCompressor(level,
+		func(w io.Writer, level int) (streamWriter, error) {
+			return gzip.NewWriterLevel(w, level)
+		},
+		func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		})
+}
+
+// streamWriter is a compressing writer that can be reused, as flate and gzip writers can.
+type streamWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// streamCompressor compresses with a stream format, reusing its writers.
+type streamCompressor struct {
+	writers   *sync.Pool
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+func newStreamCompressor(
+	level int,
+	newWriter func(w io.Writer, level int) (streamWriter, error),
+	newReader func(r io.Reader) (io.ReadCloser, error),
+) *streamCompressor {
+	if _, err := newWriter(io.Discard, level); err != nil {
+		panic(err)
+	}
+	return &streamCompressor{
+		writers: &sync.Pool{New: func() any {
+			w, _ := newWriter(io.Discard, level)
+			return w
+		}},
+		newReader: newReader,
+	}
+}
+
+// Compress returns the compressed data.
+func (c *streamCompressor) Compress(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := c.writers.Get().(streamWriter)
+	defer c.writers.Put(w)
+	w.Reset(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress returns the data compressed by Compress.
+func (c *streamCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := c.newReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// The first byte of the data returned by Pack tells whether the rest is compressed.
+const (
+	stored     byte = 0
+	compressed byte = 1
+)
+
+// ErrCorrupt is returned when unpacking data that was not returned by Pack.
+var ErrCorrupt = errors.New("codec: corrupt packed data")
+
+// Pack compresses data with comp if it is at least threshold bytes long and
+// compressing makes it smaller, and returns it prefixed with a byte telling
+// Unpack whether it did.
+func Pack(comp Compressor, threshold int, data []byte) ([]byte, error) {
+	if len(data) >= threshold {
+		packed, err := comp.Compress(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(packed) < len(data) {
+			return append([]byte{compressed}, packed...), nil
+		}
+	}
+	return append([]byte{stored}, data...), nil
+}
+
+// Unpack returns the data packed by Pack with comp.
+func Unpack(comp Compressor, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrCorrupt
+	}
+	switch data[0] {
+	case stored:
+		return data[1:], nil
+	case compressed:
+		return comp.Decompress(data[1:])
+	default:
+		return nil, ErrCorrupt
+	}
+}
+
+// IsCompressed reports whether data returned by Pack was compressed.
+func IsCompressed(data []byte) bool {
+	return len(data) > 0 && data[0] == compressed
+}
+
+// Compressed returns a codec encoding values with c, and compressing the
+// encoded values at least threshold bytes long with comp.
+func Compressed(c Codec, comp Compressor, threshold int) Codec {
+	return &compressedCodec{codec: c, comp: comp, threshold: threshold}
+}
+
+// compressedCodec is the Codec returned by Compressed.
+type compressedCodec struct {
+	codec     Codec
+	comp      Compressor
+	threshold int
+}
+
+// Encode encodes and packs val.
+func (c *compressedCodec) Encode(val any) ([]byte, error) {
+	data, err := c.codec.Encode(val)
+	if err != nil {
+		return nil, err
+	}
+	return Pack(c.comp, c.threshold, data)
+}
+
+// Decode unpacks and decodes a value encoded by Encode.
+func (c *compressedCodec) Decode(data []byte) (any, error) {
+	data, err := Unpack(c.comp, data)
+	if err != nil {
+		return nil, err
+	}
+	return c.codec.Decode(data)
+}
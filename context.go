@@ -0,0 +1,121 @@
+package gofast
+
+import (
+	"context"
+	"sync"
+)
+
+// LoaderFunc loads the value of a key that is missing from the cache.
+// It should return early with ctx.Err() once ctx is done.
+type LoaderFunc func(ctx context.Context, key string) (any, error)
+
+// ContextCache is a Cache whose operations accept a context, so that
+// implementations that can block (loaders, remote or on-disk tiers) can be
+// cancelled and bounded by deadlines.
+type ContextCache interface {
+	Cache
+	// GetCtx returns the value (if any) and a boolean representing whether the value was found or not.
+	// The error is non-nil when ctx is done or the lookup itself failed.
+	GetCtx(ctx context.Context, key string) (any, bool, error)
+	// PutCtx adds a value to the cache.
+	PutCtx(ctx context.Context, key string, val any) error
+	// GetOrLoadCtx returns the cached value of key, calling load and caching its result on a miss.
+	GetOrLoadCtx(ctx context.Context, key string, load LoaderFunc) (any, error)
+}
+
+// WithContext returns c as a ContextCache. Caches that already implement
+// ContextCache are returned as is. Any other cache, like the in-memory caches
+// returned by NewCache, never blocks, so it is wrapped in an adapter that only
+// checks ctx before each operation and runs loaders under ctx.
+//
+// GetOrLoadCtx returns as soon as ctx is done, but a loader that ignores ctx
+// keeps running in its own goroutine until it returns. Its result is then
+// dropped, as the key may have been written or removed since.
+func WithContext(c Cache) ContextCache {
+	if cc, ok := c.(ContextCache); ok {
+		return cc
+	}
+	return &contextCache{Cache: c}
+}
+
+// contextCache adapts a non-blocking Cache to ContextCache.
+type contextCache struct {
+	Cache
+}
+
+// Unwrap returns the wrapped cache.
+func (c *contextCache) Unwrap() Cache {
+	return c.Cache
+}
+
+// GetCtx retrieves a value from the cache unless ctx is already done.
+func (c *contextCache) GetCtx(ctx context.Context, key string) (any, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	val, ok := c.Get(key)
+	return val, ok, nil
+}
+
+// PutCtx adds a new key-value pair to the cache unless ctx is already done.
+func (c *contextCache) PutCtx(ctx context.Context, key string, val any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.Put(key, val)
+	return nil
+}
+
+// GetOrLoadCtx returns the cached value of key or loads it.
+func (c *contextCache) GetOrLoadCtx(ctx context.Context, key string, load LoaderFunc) (any, error) {
+	val, ok, err := c.GetCtx(ctx, key)
+	if err != nil || ok {
+		return val, err
+	}
+	return loadCtx(ctx, c, key, load)
+}
+
+// loadResult is the outcome of a LoaderFunc call.
+type loadResult struct {
+	val any
+	err error
+}
+
+// loadCtx calls load in its own goroutine and stores its result in c.
+// It returns ctx.Err() as soon as ctx is done, even if load ignores ctx;
+// a load that completes after that is dropped rather than cached, so that it
+// cannot overwrite a newer value or undo a removal of key.
+func loadCtx(ctx context.Context, c Cache, key string, load LoaderFunc) (any, error) {
+	done := make(chan loadResult, 1)
+	// mu makes storing the result and abandoning the load exclusive.
+	var mu sync.Mutex
+	abandoned := false
+	go func() {
+		val, err := load(ctx, key)
+		mu.Lock()
+		defer mu.Unlock()
+		if abandoned {
+			return
+		}
+		if err == nil {
+			c.Put(key, val)
+		}
+		done <- loadResult{val: val, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.val, res.err
+	case <-ctx.Done():
+		mu.Lock()
+		abandoned = true
+		mu.Unlock()
+		// The result may have been stored just before.
+		select {
+		case res := <-done:
+			return res.val, res.err
+		default:
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package gofast
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithContext(t *testing.T) {
+	ctx := context.Background()
+	cache := WithContext(NewCache(10, LRU))
+
+	t.Run("put and get", func(t *testing.T) {
+		require.NoError(t, cache.PutCtx(ctx, "1", 1))
+
+		val, ok, err := cache.GetCtx(ctx, "1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+	})
+
+	t.Run("get or load", func(t *testing.T) {
+		calls := 0
+		load := func(ctx context.Context, key string) (any, error) {
+			calls++
+			return key + "-loaded", nil
+		}
+
+		val, err := cache.GetOrLoadCtx(ctx, "2", load)
+		require.NoError(t, err)
+		assert.Equal(t, "2-loaded", val)
+
+		val, err = cache.GetOrLoadCtx(ctx, "2", load)
+		require.NoError(t, err)
+		assert.Equal(t, "2-loaded", val)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("loader error is not cached", func(t *testing.T) {
+		errLoad := errors.New("load failed")
+		_, err := cache.GetOrLoadCtx(ctx, "3", func(ctx context.Context, key string) (any, error) {
+			return nil, errLoad
+		})
+		assert.ErrorIs(t, err, errLoad)
+		assert.False(t, cache.Contains("3"))
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, _, err := cache.GetCtx(cancelled, "1")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, cache.PutCtx(cancelled, "4", 4), context.Canceled)
+		assert.False(t, cache.Contains("4"))
+	})
+
+	t.Run("deadline bounds a slow loader", func(t *testing.T) {
+		release := make(chan struct{})
+		deadline, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		returned := make(chan struct{})
+		_, err := cache.GetOrLoadCtx(deadline, "5", func(ctx context.Context, key string) (any, error) {
+			defer close(returned)
+			<-release
+			return 5, nil
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// the abandoned load does not overwrite the value written since.
+		cache.Put("5", 6)
+		close(release)
+		<-returned
+		val, ok := cache.Get("5")
+		assert.True(t, ok)
+		assert.Equal(t, 6, val)
+	})
+}
+
+// blockingCache is a ContextCache that WithContext must not wrap again.
+type blockingCache struct {
+	ContextCache
+}
+
+func TestWithContext_ReturnsContextCache(t *testing.T) {
+	c := &blockingCache{ContextCache: WithContext(NewCache(1, LRU))}
+	assert.Same(t, c, WithContext(c))
+}
+
+func TestWithContext_Unwrap(t *testing.T) {
+	inner := NewCache(10, SIEVE)
+	c := WithContext(inner)
+	assert.Same(t, inner, c.(Wrapper).Unwrap())
+
+	algo, ok := AlgorithmOf(c)
+	assert.True(t, ok)
+	assert.Equal(t, SIEVE, algo)
+	assert.True(t, Resize(c, 5))
+	assert.Equal(t, 5, inner.(Inspector).Limit())
+}
@@ -0,0 +1,350 @@
+package gofast
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMaxDependents is the default number of entries that may depend on one key.
+const DefaultMaxDependents = 1024
+
+// ErrTooManyDependents is returned by PutWithDeps when a dependency already
+// has the maximum number of dependents.
+var ErrTooManyDependents = errors.New("gofast: too many dependents")
+
+// DependentOption configures a Dependent cache.
+type DependentOption func(d *Dependent)
+
+// WithMaxDependents sets the number of entries that may depend on one key,
+// bounding the fan-out of each step of an invalidation. Defaults to
+// DefaultMaxDependents.
+func WithMaxDependents(n int) DependentOption {
+	return func(d *Dependent) {
+		d.maxDependents = n
+	}
+}
+
+// Dependent wraps a Cache and lets entries declare the keys they are derived
+// from, such as a rendered page depending on a user record and a template.
+// When a key is updated, removed, evicted or expires, the entries depending
+// on it are removed, and the entries depending on those in turn, each once
+// however many paths lead to it. Putting an entry that closes a cycle of
+// dependencies updates a key the other entries of the cycle depend on, so it
+// removes them: the dependencies never form a cycle.
+//
+// Entries the wrapped cache drops are only cascaded when it reports them
+// through EvictionNotifier or ExpirationNotifier, which all caches returned
+// by NewCache and Expiring do; the wrapped cache must not be written to other
+// than through Dependent.
+type Dependent struct {
+	// cascaded is accessed atomically and kept first for 64-bit alignment.
+	cascaded uint64
+
+	cache         Cache
+	maxDependents int
+
+	// mu orders writes, so that the dependencies are updated in the order
+	// the wrapped cache is.
+	mu *sync.Mutex
+
+	// depMu guards the dependency graph and the pending keys. It is taken by
+	// the hooks of the wrapped cache, so it must not be held while calling it.
+	depMu *sync.Mutex
+	// deps maps each key to the keys it depends on.
+	deps map[string][]string
+	// dependents maps each key to the set of keys depending on it.
+	dependents map[string]map[string]struct{}
+	// pending holds the keys the wrapped cache dropped whose dependents are
+	// still to be removed.
+	pending map[string]struct{}
+}
+
+// NewDependent returns a dependency-aware cache storing its entries in c.
+func NewDependent(c Cache, opts ...DependentOption) *Dependent {
+	d := &Dependent{
+		cache:         c,
+		maxDependents: DefaultMaxDependents,
+		mu:            &sync.Mutex{},
+		depMu:         &sync.Mutex{},
+		deps:          make(map[string][]string),
+		dependents:    make(map[string]map[string]struct{}),
+		pending:       make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	// The hooks run with the wrapped cache's lock held, so the dependents
+	// are removed once the call that dropped the key returns.
+	dropped := func(key string, _ any) {
+		d.depMu.Lock()
+		defer d.depMu.Unlock()
+		d.pending[key] = struct{}{}
+	}
+	if notifier, ok := c.(EvictionNotifier); ok {
+		notifier.AddEvictionHook(dropped)
+	}
+	if notifier, ok := c.(ExpirationNotifier); ok {
+		notifier.AddExpirationHook(dropped)
+	}
+	return d
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (d *Dependent) Get(key string) (any, bool) {
+	val, ok := d.cache.Get(key)
+	d.flushPending()
+	return val, ok
+}
+
+// Put adds a new key-value pair to the cache that depends on no other key,
+// removing the entries that depended on the previous value of key.
+func (d *Dependent) Put(key string, val any) {
+	// Without dependencies, PutWithDeps cannot fail.
+	_ = d.PutWithDeps(key, val)
+}
+
+// PutWithDeps adds a new key-value pair to the cache that depends on deps,
+// removing the entries that depended on the previous value of key. If one of
+// deps already has the maximum number of dependents, key is removed instead
+// and ErrTooManyDependents is returned.
+func (d *Dependent) PutWithDeps(key string, val any, deps ...string) error {
+	return d.put(key, val, deps, nil)
+}
+
+// PutWithTags adds a new key-value pair to the cache that depends on no other
+// key, carrying tags, removing the entries that depended on the previous value
+// of key. The tags are dropped if the wrapped cache does not implement Tagger.
+func (d *Dependent) PutWithTags(key string, val any, tags ...string) {
+	// Without dependencies, put cannot fail.
+	_ = d.put(key, val, nil, tags)
+}
+
+// put adds key depending on deps and carrying tags, as PutWithDeps does.
+func (d *Dependent) put(key string, val any, deps, tags []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.depMu.Lock()
+	for _, dep := range deps {
+		dependents := d.dependents[dep]
+		if _, ok := dependents[key]; !ok && dep != key && len(dependents) >= d.maxDependents {
+			stale := d.detach(key)
+			d.depMu.Unlock()
+			d.cascade(stale)
+			d.cache.Remove(key)
+			return fmt.Errorf("%w: %q", ErrTooManyDependents, dep)
+		}
+	}
+	stale := d.detach(key)
+	d.link(key, deps)
+	d.depMu.Unlock()
+
+	d.cascade(stale)
+	putWithTags(d.cache, key, val, tags)
+	d.flush()
+	return nil
+}
+
+// Remove deletes a specific key-value pair from the cache, and the entries
+// depending on it.
+func (d *Dependent) Remove(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, and the entries depending
+// on them, and returns the number of entries carrying tag removed. It returns
+// 0 if the wrapped cache does not implement Tagger.
+func (d *Dependent) InvalidateTag(tag string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	keys := taggedKeys(d.cache, tag)
+	for _, key := range keys {
+		d.remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, or nil if the wrapped cache does
+// not implement Tagger.
+func (d *Dependent) TaggedKeys(tag string) []string {
+	return taggedKeys(d.cache, tag)
+}
+
+// Len returns the number of items in the cache.
+func (d *Dependent) Len() int {
+	return d.cache.Len()
+}
+
+// Clear removes all items from the cache, and their dependencies.
+func (d *Dependent) Clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cache.Clear()
+
+	d.depMu.Lock()
+	defer d.depMu.Unlock()
+	d.deps = make(map[string][]string)
+	d.dependents = make(map[string]map[string]struct{})
+	d.pending = make(map[string]struct{})
+}
+
+// Contains checks if a key is present in the cache.
+func (d *Dependent) Contains(key string) bool {
+	ok := d.cache.Contains(key)
+	d.flushPending()
+	return ok
+}
+
+// Dependents returns the keys depending directly on key, in no particular order.
+func (d *Dependent) Dependents(key string) []string {
+	d.depMu.Lock()
+	defer d.depMu.Unlock()
+
+	keys := make([]string, 0, len(d.dependents[key]))
+	for dependent := range d.dependents[key] {
+		keys = append(keys, dependent)
+	}
+	return keys
+}
+
+// Cascaded returns the number of entries removed because a key they depended
+// on was updated, removed, evicted or expired.
+func (d *Dependent) Cascaded() uint64 {
+	return atomic.LoadUint64(&d.cascaded)
+}
+
+// Stats returns the stats of the wrapped cache, or zero Stats if it does not
+// implement StatsReporter.
+func (d *Dependent) Stats() Stats {
+	if reporter, ok := d.cache.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+// Limit returns the limit of the wrapped cache, or 0 if it does not implement Inspector.
+func (d *Dependent) Limit() int {
+	if inspector, ok := d.cache.(Inspector); ok {
+		return inspector.Limit()
+	}
+	return 0
+}
+
+// Keys returns the keys of the wrapped cache in eviction order, or nil if it
+// does not implement Inspector.
+func (d *Dependent) Keys() []string {
+	if inspector, ok := d.cache.(Inspector); ok {
+		return inspector.Keys()
+	}
+	return nil
+}
+
+// Unwrap returns the wrapped cache.
+func (d *Dependent) Unwrap() Cache {
+	return d.cache
+}
+
+// detach returns the keys depending on key, directly or not, and drops them
+// and key from the dependency graph. d.depMu must be held.
+func (d *Dependent) detach(key string) []string {
+	visited := map[string]struct{}{key: {}}
+	var stale []string
+	for queue := []string{key}; len(queue) > 0; queue = queue[1:] {
+		for dependent := range d.dependents[queue[0]] {
+			if _, ok := visited[dependent]; ok {
+				continue
+			}
+			visited[dependent] = struct{}{}
+			stale = append(stale, dependent)
+			queue = append(queue, dependent)
+		}
+	}
+
+	for k := range visited {
+		d.unlink(k)
+		delete(d.dependents, k)
+		delete(d.pending, k)
+	}
+	return stale
+}
+
+// link records that key depends on deps. d.depMu must be held.
+func (d *Dependent) link(key string, deps []string) {
+	own := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		if dep == key {
+			continue
+		}
+		dependents, ok := d.dependents[dep]
+		if !ok {
+			dependents = make(map[string]struct{})
+			d.dependents[dep] = dependents
+		}
+		if _, dup := dependents[key]; dup {
+			continue
+		}
+		dependents[key] = struct{}{}
+		own = append(own, dep)
+	}
+	if len(own) > 0 {
+		d.deps[key] = own
+	}
+}
+
+// unlink drops the dependencies of key. d.depMu must be held.
+func (d *Dependent) unlink(key string) {
+	for _, dep := range d.deps[key] {
+		dependents := d.dependents[dep]
+		delete(dependents, key)
+		if len(dependents) == 0 {
+			delete(d.dependents, dep)
+		}
+	}
+	delete(d.deps, key)
+}
+
+// remove deletes key and the entries depending on it. d.mu must be held.
+func (d *Dependent) remove(key string) {
+	d.depMu.Lock()
+	stale := d.detach(key)
+	d.depMu.Unlock()
+	d.cascade(stale)
+	d.cache.Remove(key)
+}
+
+// cascade removes the dependents of a key from the wrapped cache. d.mu must be held.
+func (d *Dependent) cascade(stale []string) {
+	for _, key := range stale {
+		d.cache.Remove(key)
+	}
+	atomic.AddUint64(&d.cascaded, uint64(len(stale)))
+}
+
+// flush removes the dependents of the keys the wrapped cache dropped.
+// d.mu must be held.
+func (d *Dependent) flush() {
+	d.depMu.Lock()
+	var stale []string
+	for key := range d.pending {
+		stale = append(stale, d.detach(key)...)
+	}
+	d.depMu.Unlock()
+	d.cascade(stale)
+}
+
+// flushPending runs flush if the wrapped cache dropped keys.
+func (d *Dependent) flushPending() {
+	d.depMu.Lock()
+	pending := len(d.pending)
+	d.depMu.Unlock()
+	if pending == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.flush()
+}
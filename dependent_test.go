@@ -0,0 +1,140 @@
+package gofast
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ StatsReporter = (*Dependent)(nil)
+	_ Inspector     = (*Dependent)(nil)
+	_ Wrapper       = (*Dependent)(nil)
+	_ Tagger        = (*Dependent)(nil)
+)
+
+func TestDependent(t *testing.T) {
+	t.Run("cascades", func(t *testing.T) {
+		cache := NewDependent(NewCache(100, LRU))
+		cache.Put("user:7", "ada")
+		cache.Put("template", "<p>{{.}}</p>")
+		assert.NoError(t, cache.PutWithDeps("page:7", "<p>ada</p>", "user:7", "template"))
+		assert.NoError(t, cache.PutWithDeps("feed", "ada's page", "page:7"))
+		assert.NoError(t, cache.PutWithDeps("other", "x", "template"))
+		assert.ElementsMatch(t, []string{"page:7", "other"}, cache.Dependents("template"))
+
+		// Updating a parent removes its dependents, transitively.
+		cache.Put("user:7", "grace")
+		assert.True(t, cache.Contains("user:7"))
+		assert.False(t, cache.Contains("page:7"))
+		assert.False(t, cache.Contains("feed"))
+		assert.True(t, cache.Contains("other"))
+		assert.Equal(t, uint64(2), cache.Cascaded())
+		// The page no longer depends on the template.
+		assert.Equal(t, []string{"other"}, cache.Dependents("template"))
+
+		cache.Remove("template")
+		assert.False(t, cache.Contains("other"))
+		assert.Empty(t, cache.Dependents("template"))
+		assert.Equal(t, 1, cache.Len())
+		assert.Equal(t, uint64(3), cache.Cascaded())
+	})
+
+	t.Run("updating a dependent keeps its parents", func(t *testing.T) {
+		cache := NewDependent(NewCache(100, LRU))
+		assert.NoError(t, cache.PutWithDeps("child", 1, "parent"))
+		assert.NoError(t, cache.PutWithDeps("child", 2, "parent"))
+		cache.Put("unrelated", 3)
+		assert.True(t, cache.Contains("child"))
+
+		// Put drops the dependencies of the key it replaces.
+		cache.Put("child", 4)
+		cache.Put("parent", 5)
+		assert.True(t, cache.Contains("child"))
+		assert.Equal(t, uint64(0), cache.Cascaded())
+	})
+
+	t.Run("cycles", func(t *testing.T) {
+		cache := NewDependent(NewCache(100, LRU))
+		assert.NoError(t, cache.PutWithDeps("a", 1, "b", "a"))
+		// Closing the cycle updates b, which a depends on.
+		assert.NoError(t, cache.PutWithDeps("b", 2, "a"))
+		assert.False(t, cache.Contains("a"))
+		assert.True(t, cache.Contains("b"))
+
+		cache.Put("a", 1)
+		assert.False(t, cache.Contains("b"))
+	})
+
+	t.Run("diamonds", func(t *testing.T) {
+		cache := NewDependent(NewCache(100, LRU))
+		assert.NoError(t, cache.PutWithDeps("left", 1, "root"))
+		assert.NoError(t, cache.PutWithDeps("right", 2, "root"))
+		assert.NoError(t, cache.PutWithDeps("bottom", 3, "left", "right"))
+		cache.Remove("root")
+		assert.Equal(t, 0, cache.Len())
+		assert.Equal(t, uint64(3), cache.Cascaded())
+	})
+
+	t.Run("evictions and expirations cascade", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		expiring := NewExpiring(NewCache(3, LRU), WithExpiringClock(clock))
+		cache := NewDependent(expiring)
+
+		cache.Put("parent", 1)
+		assert.NoError(t, cache.PutWithDeps("child", 2, "parent"))
+		cache.Get("child")
+		cache.Put("other", 3)
+		cache.Put("new", 4)
+		assert.False(t, cache.Contains("parent"))
+		assert.False(t, cache.Contains("child"))
+
+		assert.NoError(t, cache.PutWithDeps("derived", 5, "other"))
+		expiring.Touch("other", time.Second)
+		clock.Advance(time.Minute)
+		_, ok := cache.Get("other")
+		assert.False(t, ok)
+		assert.False(t, cache.Contains("derived"))
+		assert.Equal(t, []string{"new"}, cache.Keys())
+	})
+
+	t.Run("bounded fan-out", func(t *testing.T) {
+		cache := NewDependent(NewCache(100, LRU), WithMaxDependents(3))
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, cache.PutWithDeps(strconv.Itoa(i), i, "parent"))
+		}
+		cache.Put("3", "old")
+		err := cache.PutWithDeps("3", 3, "parent")
+		assert.ErrorIs(t, err, ErrTooManyDependents)
+		assert.False(t, cache.Contains("3"))
+		// Existing dependents may still be updated.
+		assert.NoError(t, cache.PutWithDeps("2", 2, "parent"))
+
+		cache.Remove("parent")
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		cache := NewDependent(NewCache(100, LRU))
+		assert.NoError(t, cache.PutWithDeps("child", 1, "parent"))
+		cache.Clear()
+		assert.Empty(t, cache.Dependents("parent"))
+		assert.Equal(t, 0, cache.Len())
+	})
+}
+
+func TestDependent_Tagger(t *testing.T) {
+	cache := NewDependent(NewCache(10, LRU))
+	cache.PutWithTags("user:7", "ada", "users")
+	cache.PutWithTags("user:8", "grace", "users")
+	assert.NoError(t, cache.PutWithDeps("page:7", "<p>ada</p>", "user:7"))
+	cache.Put("template", "<p>{{.}}</p>")
+
+	assert.Equal(t, 2, cache.InvalidateTag("users"))
+	assert.False(t, cache.Contains("page:7"))
+	assert.Equal(t, uint64(1), cache.Cascaded())
+	assert.Equal(t, 1, cache.Len())
+	assert.Empty(t, cache.Dependents("user:7"))
+}
@@ -0,0 +1,158 @@
+package disk
+
+import (
+	"context"
+
+	"github.com/raghavgh/gofast/codec"
+)
+
+// Codec converts cache values to and from the bytes kept on disk.
+type Codec = codec.Codec
+
+// GobCodec encodes values with encoding/gob. Values of types other than the
+// Go basic types must be registered with gob.Register before they are stored.
+type GobCodec = codec.Gob
+
+// CacheOption configures a Cache.
+type CacheOption func(c *Cache)
+
+// WithCodec sets the codec used to store values. Defaults to GobCodec.
+func WithCodec(codec Codec) CacheOption {
+	return func(c *Cache) {
+		c.codec = codec
+	}
+}
+
+// WithErrorHandler sets fn to be called with the errors of the Cache methods,
+// which have no error result. By default those errors are dropped.
+func WithErrorHandler(fn func(err error)) CacheOption {
+	return func(c *Cache) {
+		c.onError = fn
+	}
+}
+
+// Cache is a persistent gofast.Cache backed by a Store.
+type Cache struct {
+	store   *Store
+	codec   Codec
+	onError func(err error)
+}
+
+// NewCache returns a cache storing its values in store.
+func NewCache(store *Store, opts ...CacheOption) *Cache {
+	c := &Cache{store: store, codec: GobCodec{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (c *Cache) Get(key string) (any, bool) {
+	val, ok, err := c.get(key)
+	c.report(err)
+	return val, ok
+}
+
+// Put adds a new key-value pair to the cache.
+func (c *Cache) Put(key string, val any) {
+	c.report(c.put(key, val))
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (c *Cache) Remove(key string) {
+	c.report(c.store.Delete(key))
+}
+
+// Len returns the number of items in the cache.
+func (c *Cache) Len() int {
+	return c.store.Len()
+}
+
+// Clear removes all items from the cache.
+func (c *Cache) Clear() {
+	c.report(c.store.Clear())
+}
+
+// Contains checks if a key is present in the cache.
+func (c *Cache) Contains(key string) bool {
+	return c.store.Contains(key)
+}
+
+// Tier returns c as the second tier of a gofast.Tiered cache.
+func (c *Cache) Tier() *Tier {
+	return &Tier{cache: c}
+}
+
+func (c *Cache) get(key string) (any, bool, error) {
+	data, ok, err := c.store.Get(key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	val, err := c.codec.Decode(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return val, true, nil
+}
+
+func (c *Cache) put(key string, val any) error {
+	data, err := c.codec.Encode(val)
+	if err != nil {
+		return err
+	}
+	return c.store.Put(key, data)
+}
+
+// report passes a non-nil error to the error handler.
+func (c *Cache) report(err error) {
+	if err != nil && c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// Tier implements gofast.L2 on top of a Cache. Disk operations are not
+// interruptible, so the context is only checked before each operation.
+type Tier struct {
+	cache *Cache
+}
+
+// Get retrieves a value from the cache unless ctx is already done.
+func (t *Tier) Get(ctx context.Context, key string) (any, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	return t.cache.get(key)
+}
+
+// Put adds a new key-value pair to the cache unless ctx is already done.
+func (t *Tier) Put(ctx context.Context, key string, val any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.cache.put(key, val)
+}
+
+// Remove deletes a specific key-value pair from the cache unless ctx is already done.
+func (t *Tier) Remove(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.cache.store.Delete(key)
+}
+
+// Len returns the number of items in the cache unless ctx is already done.
+func (t *Tier) Len(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return t.cache.store.Len(), nil
+}
+
+// Clear removes all items from the cache unless ctx is already done.
+func (t *Tier) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.cache.store.Clear()
+}
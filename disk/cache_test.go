@@ -0,0 +1,93 @@
+package disk
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"testing"
+
+	"github.com/raghavgh/gofast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func init() {
+	gob.Register(user{})
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	var cache gofast.Cache = NewCache(openStore(t, dir, Options{}))
+
+	cache.Put("s", "string")
+	cache.Put("i", 42)
+	cache.Put("u", user{Name: "ada", Age: 36})
+
+	val, ok := cache.Get("u")
+	assert.True(t, ok)
+	assert.Equal(t, user{Name: "ada", Age: 36}, val)
+	val, _ = cache.Get("i")
+	assert.Equal(t, 42, val)
+	assert.Equal(t, 3, cache.Len())
+	assert.True(t, cache.Contains("s"))
+
+	cache.Remove("s")
+	_, ok = cache.Get("s")
+	assert.False(t, ok)
+
+	cache.Clear()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCache_Persistent(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, Options{})
+	require.NoError(t, err)
+	NewCache(store).Put("u", user{Name: "ada"})
+	require.NoError(t, store.Close())
+
+	val, ok := NewCache(openStore(t, dir, Options{})).Get("u")
+	assert.True(t, ok)
+	assert.Equal(t, user{Name: "ada"}, val)
+}
+
+func TestCache_Errors(t *testing.T) {
+	var reported []error
+	store := openStore(t, t.TempDir(), Options{})
+	cache := NewCache(store, WithErrorHandler(func(err error) {
+		reported = append(reported, err)
+	}))
+
+	// unregistered types cannot be encoded.
+	cache.Put("f", struct{ F func() }{})
+	require.Len(t, reported, 1)
+	assert.False(t, cache.Contains("f"))
+
+	require.NoError(t, store.Close())
+	cache.Put("a", 1)
+	require.Len(t, reported, 2)
+	assert.True(t, errors.Is(reported[1], ErrClosed))
+}
+
+func TestTier(t *testing.T) {
+	dir := t.TempDir()
+	l2 := NewCache(openStore(t, dir, Options{})).Tier()
+	tiered := gofast.NewTiered(gofast.NewCache(2, gofast.LRU), l2)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		tiered.Put(key, key)
+	}
+	val, ok := tiered.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "a", val)
+	assert.Equal(t, 4, tiered.Len())
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l2.Put(cancelled, "e", "e"), context.Canceled)
+}
@@ -0,0 +1,221 @@
+package disk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Record layout, all integers little endian:
+//
+//	crc32 (4) | flags (1) | key length (4) | value length (4) | key | value
+//
+// The checksum covers everything after itself, so a record torn by a crash
+// is detected on recovery.
+const (
+	headerSize    = 13
+	flagTombstone = 1 << 0
+	segmentExt    = ".seg"
+	// maxRecordPart bounds key and value lengths read back from disk,
+	// so a corrupt header cannot make recovery allocate gigabytes.
+	maxRecordPart = 1 << 30
+)
+
+var errCorruptRecord = errors.New("disk: corrupt record")
+
+// record is a decoded entry of a segment.
+type record struct {
+	key       string
+	value     []byte
+	tombstone bool
+}
+
+// size returns the encoded size of r.
+func (r record) size() int64 {
+	return int64(headerSize + len(r.key) + len(r.value))
+}
+
+// encode returns the on-disk bytes of r.
+func (r record) encode() []byte {
+	buf := make([]byte, r.size())
+	if r.tombstone {
+		buf[4] = flagTombstone
+	}
+	binary.LittleEndian.PutUint32(buf[5:9], uint32(len(r.key)))
+	binary.LittleEndian.PutUint32(buf[9:13], uint32(len(r.value)))
+	copy(buf[headerSize:], r.key)
+	copy(buf[headerSize+len(r.key):], r.value)
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[4:]))
+	return buf
+}
+
+// decodeRecord reads one record from r.
+// It returns io.EOF at a clean end of input and errCorruptRecord for a torn or damaged record.
+func decodeRecord(r io.Reader) (record, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			return record{}, io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return record{}, errCorruptRecord
+		}
+		return record{}, err
+	}
+
+	keyLen := binary.LittleEndian.Uint32(header[5:9])
+	valLen := binary.LittleEndian.Uint32(header[9:13])
+	if keyLen > maxRecordPart || valLen > maxRecordPart || header[4]&^flagTombstone != 0 {
+		return record{}, errCorruptRecord
+	}
+
+	body := make([]byte, keyLen+valLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return record{}, errCorruptRecord
+		}
+		return record{}, err
+	}
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(body)
+	if crc.Sum32() != binary.LittleEndian.Uint32(header[0:4]) {
+		return record{}, errCorruptRecord
+	}
+
+	return record{
+		key:       string(body[:keyLen]),
+		value:     body[keyLen:],
+		tombstone: header[4]&flagTombstone != 0,
+	}, nil
+}
+
+// segment is an append-only file of records.
+type segment struct {
+	id   uint32
+	path string
+	f    *os.File
+	// size is the number of bytes written to the segment.
+	size int64
+	// live is the number of bytes of records the index still points to.
+	live int64
+}
+
+// segmentPath returns the file path of segment id in dir.
+func segmentPath(dir string, id uint32) string {
+	return filepath.Join(dir, fmt.Sprintf("%010d%s", id, segmentExt))
+}
+
+// listSegments returns the ids of the segment files in dir, oldest first.
+func listSegments(dir string) ([]uint32, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint32
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint32(id))
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// createSegment creates a new, empty segment file.
+func createSegment(dir string, id uint32) (*segment, error) {
+	path := segmentPath(dir, id)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &segment{id: id, path: path, f: f}, nil
+}
+
+// openSegment opens an existing segment and replays its records through fn
+// in order, passing each record's offset. A torn or corrupt record and
+// everything after it is truncated away, as it can only come from a write
+// that was interrupted by a crash.
+func openSegment(dir string, id uint32, fn func(r record, offset int64)) (*segment, error) {
+	path := segmentPath(dir, id)
+	f, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		r, err := decodeRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err == errCorruptRecord {
+			if err := f.Truncate(offset); err != nil {
+				f.Close()
+				return nil, err
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		fn(r, offset)
+		offset += r.size()
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &segment{id: id, path: path, f: f, size: offset}, nil
+}
+
+// append writes r at the end of the segment and returns its offset.
+// A partial write is truncated away so that later records stay readable.
+func (s *segment) append(r record) (int64, error) {
+	offset := s.size
+	if _, err := s.f.Write(r.encode()); err != nil {
+		if truncErr := s.f.Truncate(offset); truncErr == nil {
+			s.f.Seek(offset, io.SeekStart)
+		}
+		return 0, err
+	}
+	s.size += r.size()
+	return offset, nil
+}
+
+// read returns the record of the given size at offset.
+func (s *segment) read(offset, size int64) (record, error) {
+	buf := make([]byte, size)
+	if _, err := s.f.ReadAt(buf, offset); err != nil {
+		return record{}, err
+	}
+	return decodeRecord(bytes.NewReader(buf))
+}
+
+// remove closes and deletes the segment file.
+func (s *segment) remove() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(s.path)
+}
@@ -0,0 +1,400 @@
+// Package disk provides an on-disk cache backend: values are appended to
+// segment files and an in-memory index maps each key to the offset of its
+// latest record. It can serve as the second tier of a gofast.Tiered cache or
+// as a standalone persistent cache.
+package disk
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// ErrClosed is returned by the operations of a closed Store.
+var ErrClosed = errors.New("disk: store is closed")
+
+const defaultSegmentSize = 64 << 20
+
+// Options configures a Store.
+type Options struct {
+	// SegmentSize is the size in bytes after which the active segment is sealed
+	// and a new one is started. Defaults to 64MiB.
+	SegmentSize int64
+	// MaxSegments caps the number of segment files. When a new segment would
+	// exceed it, the oldest segment is dropped along with the entries whose
+	// latest record it holds, which bounds the disk usage like a FIFO cache.
+	// Zero means unbounded.
+	MaxSegments int
+	// CompactRatio triggers a compaction after a write when the fraction of
+	// dead bytes in the sealed segments exceeds it.
+	// Zero disables automatic compaction, Compact can still be called.
+	CompactRatio float64
+	// SyncWrites calls fsync after every write. Without it, writes survive a
+	// process crash but the last ones may be lost on a power failure.
+	SyncWrites bool
+}
+
+// location is where the latest record of a key is stored.
+type location struct {
+	segment uint32
+	offset  int64
+	size    int64
+}
+
+// Store is an append-only, log-structured key-value store for []byte values.
+//
+// Every Put and Delete appends a checksummed record to the active segment file.
+// Open rebuilds the index by replaying the segments in order and truncates a
+// record torn by a crash, so the store recovers to the last complete write.
+// Overwritten and deleted records are reclaimed by Compact.
+//
+// Store is safe for concurrent use.
+type Store struct {
+	dir  string
+	opts Options
+
+	mu       sync.RWMutex
+	index    map[string]location
+	segments map[uint32]*segment
+	// order holds the segment ids, oldest first. The last one is active.
+	order  []uint32
+	closed bool
+}
+
+// Open opens the store in dir, creating dir if needed, and recovers the index
+// from the segment files found there.
+func Open(dir string, opts Options) (*Store, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = defaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		dir:      dir,
+		opts:     opts,
+		index:    make(map[string]location),
+		segments: make(map[uint32]*segment),
+	}
+
+	ids, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		id := id
+		seg, err := openSegment(dir, id, func(r record, offset int64) {
+			if r.tombstone {
+				delete(s.index, r.key)
+				return
+			}
+			s.index[r.key] = location{segment: id, offset: offset, size: r.size()}
+		})
+		if err != nil {
+			s.closeSegments()
+			return nil, err
+		}
+		s.segments[id] = seg
+		s.order = append(s.order, id)
+	}
+	for _, loc := range s.index {
+		s.segments[loc.segment].live += loc.size
+	}
+
+	if len(s.order) == 0 {
+		if err := s.createActive(1); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Get returns the value stored for key.
+func (s *Store) Get(key string) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, false, ErrClosed
+	}
+	loc, ok := s.index[key]
+	if !ok {
+		return nil, false, nil
+	}
+	r, err := s.segments[loc.segment].read(loc.offset, loc.size)
+	if err != nil {
+		return nil, false, err
+	}
+	return r.value, true, nil
+}
+
+// Put stores val for key. The returned error also covers the segment
+// dropping and compaction the write may trigger.
+func (s *Store) Put(key string, val []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	r := record{key: key, value: val}
+	loc, err := s.append(r)
+	if err != nil {
+		return err
+	}
+	s.unlink(key)
+	s.index[key] = loc
+	s.segments[loc.segment].live += loc.size
+	return s.maintain()
+}
+
+// Delete removes key from the store.
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if _, ok := s.index[key]; !ok {
+		return nil
+	}
+	if _, err := s.append(record{key: key, tombstone: true}); err != nil {
+		return err
+	}
+	s.unlink(key)
+	return s.maintain()
+}
+
+// Contains reports whether key is in the store.
+func (s *Store) Contains(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.index[key]
+	return ok
+}
+
+// Len returns the number of keys in the store.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.index)
+}
+
+// Clear deletes every segment file and starts over with an empty store.
+// A crash during Clear may leave some entries behind.
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	for _, id := range s.order {
+		if err := s.segments[id].remove(); err != nil {
+			return err
+		}
+		delete(s.segments, id)
+	}
+	last := s.order[len(s.order)-1]
+	s.order = nil
+	s.index = make(map[string]location)
+	return s.createActive(last + 1)
+}
+
+// Compact rewrites the live records of every sealed segment into the active
+// segment and deletes the sealed segments, reclaiming the space of overwritten
+// and deleted entries. The store is locked for the duration of the compaction.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	return s.compact()
+}
+
+// Size returns the total size in bytes of the segment files and the number of
+// those bytes that are still referenced by the index.
+func (s *Store) Size() (total, live int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, seg := range s.segments {
+		total += seg.size
+		live += seg.live
+	}
+	return total, live
+}
+
+// Close syncs and closes the segment files. The store cannot be used afterwards.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	err := s.active().f.Sync()
+	if closeErr := s.closeSegments(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// active returns the segment new records are appended to.
+func (s *Store) active() *segment {
+	return s.segments[s.order[len(s.order)-1]]
+}
+
+// append writes r to the active segment, sealing it first if it is full.
+func (s *Store) append(r record) (location, error) {
+	if s.active().size > 0 && s.active().size+r.size() > s.opts.SegmentSize {
+		if err := s.seal(); err != nil {
+			return location{}, err
+		}
+	}
+
+	seg := s.active()
+	offset, err := seg.append(r)
+	if err != nil {
+		return location{}, err
+	}
+	if s.opts.SyncWrites {
+		if err := seg.f.Sync(); err != nil {
+			return location{}, err
+		}
+	}
+	return location{segment: seg.id, offset: offset, size: r.size()}, nil
+}
+
+// unlink drops key from the index, accounting its record as dead.
+func (s *Store) unlink(key string) {
+	if loc, ok := s.index[key]; ok {
+		s.segments[loc.segment].live -= loc.size
+		delete(s.index, key)
+	}
+}
+
+// seal syncs the active segment and starts a new one.
+func (s *Store) seal() error {
+	if err := s.active().f.Sync(); err != nil {
+		return err
+	}
+	return s.createActive(s.active().id + 1)
+}
+
+// maintain enforces MaxSegments and CompactRatio after a write.
+func (s *Store) maintain() error {
+	for s.opts.MaxSegments > 0 && len(s.order) > s.opts.MaxSegments {
+		if err := s.dropOldest(); err != nil {
+			return err
+		}
+	}
+	if s.opts.CompactRatio > 0 && s.deadRatio() > s.opts.CompactRatio {
+		return s.compact()
+	}
+	return nil
+}
+
+// createActive creates segment id and makes it the active segment.
+func (s *Store) createActive(id uint32) error {
+	seg, err := createSegment(s.dir, id)
+	if err != nil {
+		return err
+	}
+	s.segments[id] = seg
+	s.order = append(s.order, id)
+	return nil
+}
+
+// dropOldest deletes the oldest segment and the entries it holds.
+// Dropping the oldest segment first is what keeps recovery correct: any
+// tombstone in it can only shadow records of segments that are already gone.
+func (s *Store) dropOldest() error {
+	oldest := s.order[0]
+	for key, loc := range s.index {
+		if loc.segment == oldest {
+			delete(s.index, key)
+		}
+	}
+	s.order = s.order[1:]
+	seg := s.segments[oldest]
+	delete(s.segments, oldest)
+	return seg.remove()
+}
+
+// deadRatio returns the fraction of bytes of the sealed segments that the index
+// no longer points to.
+func (s *Store) deadRatio() float64 {
+	var total, live int64
+	for _, id := range s.order[:len(s.order)-1] {
+		total += s.segments[id].size
+		live += s.segments[id].live
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(total-live) / float64(total)
+}
+
+// compact implements Compact.
+//
+// Live records are copied before any segment is deleted, and segments are
+// deleted oldest first, so a crash at any point leaves a set of segments that
+// replays to the same index: copies are newer than their originals, and no
+// tombstone is ever deleted before the records it shadows.
+func (s *Store) compact() error {
+	sealed := append([]uint32(nil), s.order[:len(s.order)-1]...)
+	if len(sealed) == 0 {
+		return nil
+	}
+	isSealed := make(map[uint32]bool, len(sealed))
+	for _, id := range sealed {
+		isSealed[id] = true
+	}
+
+	for key, loc := range s.index {
+		if !isSealed[loc.segment] {
+			continue
+		}
+		r, err := s.segments[loc.segment].read(loc.offset, loc.size)
+		if err != nil {
+			return err
+		}
+		// segments sealed while copying are new, so they are not in sealed.
+		newLoc, err := s.append(r)
+		if err != nil {
+			return err
+		}
+		s.segments[loc.segment].live -= loc.size
+		s.index[key] = newLoc
+		s.segments[newLoc.segment].live += newLoc.size
+	}
+	if err := s.active().f.Sync(); err != nil {
+		return err
+	}
+
+	for _, id := range sealed {
+		if err := s.segments[id].remove(); err != nil {
+			return err
+		}
+		delete(s.segments, id)
+	}
+	s.order = s.order[len(sealed):]
+	return nil
+}
+
+// closeSegments closes every segment file, returning the first error.
+func (s *Store) closeSegments() error {
+	var firstErr error
+	for _, seg := range s.segments {
+		if err := seg.f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,175 @@
+package disk
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openStore(t *testing.T, dir string, opts Options) *Store {
+	t.Helper()
+	s, err := Open(dir, opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func assertValue(t *testing.T, s *Store, key, expected string) {
+	t.Helper()
+	val, ok, err := s.Get(key)
+	require.NoError(t, err)
+	require.True(t, ok, "key %s not found", key)
+	assert.Equal(t, expected, string(val))
+}
+
+func TestStore(t *testing.T) {
+	s := openStore(t, t.TempDir(), Options{})
+
+	require.NoError(t, s.Put("a", []byte("1")))
+	require.NoError(t, s.Put("b", []byte("2")))
+	require.NoError(t, s.Put("a", []byte("3")))
+	assertValue(t, s, "a", "3")
+	assertValue(t, s, "b", "2")
+	assert.Equal(t, 2, s.Len())
+
+	require.NoError(t, s.Delete("b"))
+	_, ok, err := s.Get("b")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, s.Contains("b"))
+	require.NoError(t, s.Delete("missing"))
+
+	total, live := s.Size()
+	assert.Equal(t, record{key: "a", value: []byte("3")}.size(), live)
+	assert.Greater(t, total, live)
+
+	require.NoError(t, s.Clear())
+	assert.Equal(t, 0, s.Len())
+	require.NoError(t, s.Put("c", []byte("4")))
+	assertValue(t, s, "c", "4")
+}
+
+func TestStore_Recovery(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentSize: 64})
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		require.NoError(t, s.Put(strconv.Itoa(i), []byte("value-"+strconv.Itoa(i))))
+	}
+	require.NoError(t, s.Put("3", []byte("updated")))
+	require.NoError(t, s.Delete("5"))
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Put("x", nil), ErrClosed)
+
+	s = openStore(t, dir, Options{SegmentSize: 64})
+	assert.Equal(t, 19, s.Len())
+	assertValue(t, s, "3", "updated")
+	assertValue(t, s, "19", "value-19")
+	assert.False(t, s.Contains("5"))
+	assert.Greater(t, len(s.order), 1)
+}
+
+func TestStore_RecoveryTruncatesTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	require.NoError(t, err)
+	require.NoError(t, s.Put("a", []byte("1")))
+	require.NoError(t, s.Put("b", []byte("2")))
+	path := s.active().path
+	require.NoError(t, s.Close())
+
+	// simulate a crash in the middle of writing the record of "b".
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-1))
+
+	s = openStore(t, dir, Options{})
+	assertValue(t, s, "a", "1")
+	assert.False(t, s.Contains("b"))
+
+	// new writes land after the last complete record and survive a restart.
+	require.NoError(t, s.Put("c", []byte("3")))
+	require.NoError(t, s.Close())
+	s = openStore(t, dir, Options{})
+	assertValue(t, s, "a", "1")
+	assertValue(t, s, "c", "3")
+}
+
+func TestStore_RecoveryTruncatesCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	require.NoError(t, err)
+	require.NoError(t, s.Put("a", []byte("1")))
+	require.NoError(t, s.Put("b", []byte("2")))
+	path := s.active().path
+	require.NoError(t, s.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	s = openStore(t, dir, Options{})
+	assertValue(t, s, "a", "1")
+	assert.False(t, s.Contains("b"))
+}
+
+func TestStore_Compact(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentSize: 128})
+	require.NoError(t, err)
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 10; i++ {
+			require.NoError(t, s.Put(strconv.Itoa(i), []byte("round-"+strconv.Itoa(round))))
+		}
+	}
+	require.NoError(t, s.Delete("0"))
+
+	before, _ := s.Size()
+	require.NoError(t, s.Compact())
+	after, live := s.Size()
+	assert.Less(t, after, before)
+	assert.Less(t, after-live, before-live)
+	for i := 1; i < 10; i++ {
+		assertValue(t, s, strconv.Itoa(i), "round-4")
+	}
+
+	// the compacted store replays to the same content.
+	require.NoError(t, s.Close())
+	s = openStore(t, dir, Options{SegmentSize: 128})
+	assert.Equal(t, 9, s.Len())
+	assert.False(t, s.Contains("0"))
+	for i := 1; i < 10; i++ {
+		assertValue(t, s, strconv.Itoa(i), "round-4")
+	}
+}
+
+func TestStore_AutoCompact(t *testing.T) {
+	s := openStore(t, t.TempDir(), Options{SegmentSize: 128, CompactRatio: 0.5})
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, s.Put("key", []byte(strconv.Itoa(i))))
+	}
+
+	assertValue(t, s, "key", "999")
+	total, _ := s.Size()
+	assert.Less(t, total, int64(3*128))
+}
+
+func TestStore_MaxSegments(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, Options{SegmentSize: 100, MaxSegments: 3})
+	for i := 0; i < 100; i++ {
+		require.NoError(t, s.Put(strconv.Itoa(i), []byte("value")))
+	}
+
+	ids, err := listSegments(dir)
+	require.NoError(t, err)
+	assert.Len(t, ids, 3)
+	// the oldest entries were dropped with their segments, the newest survive.
+	assert.False(t, s.Contains("0"))
+	assert.True(t, s.Contains("99"))
+	assert.Less(t, s.Len(), 100)
+}
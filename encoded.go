@@ -0,0 +1,364 @@
+package gofast
+
+import (
+	"sync"
+
+	"github.com/raghavgh/gofast/codec"
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+)
+
+// EncodedOption configures an Encoded cache.
+type EncodedOption func(e *Encoded)
+
+// WithCompression compresses the encoded values at least threshold bytes
+// long with comp, such as codec.Flate(flate.BestSpeed). Values that do not
+// get smaller are stored uncompressed.
+func WithCompression(comp codec.Compressor, threshold int) EncodedOption {
+	return func(e *Encoded) {
+		e.comp = comp
+		e.threshold = threshold
+	}
+}
+
+// WithMaxBytes bounds the total size of the stored values, after compression,
+// to maxBytes: once a Put exceeds it, entries are evicted in the eviction
+// order of the wrapped cache until the values fit again. Values larger than
+// maxBytes are not stored. It requires the wrapped cache to implement
+// Inspector, as all caches returned by NewCache do, and is ignored otherwise.
+func WithMaxBytes(maxBytes int64) EncodedOption {
+	return func(e *Encoded) {
+		e.maxBytes = maxBytes
+	}
+}
+
+// WithEncodingErrorHandler sets fn to be called with the errors encoding and
+// decoding values, which the Cache methods have no error result for. Values
+// that fail to encode are not stored, and values that fail to decode are
+// reported as misses. By default those errors are dropped.
+func WithEncodingErrorHandler(fn func(err error)) EncodedOption {
+	return func(e *Encoded) {
+		e.onError = fn
+	}
+}
+
+// CodecStats reports the sizes of the values stored by an Encoded cache.
+type CodecStats struct {
+	// Entries is the number of values stored.
+	Entries int `json:"entries"`
+	// Compressed is the number of values stored compressed.
+	Compressed int `json:"compressed"`
+	// EncodedBytes is the total size of the values once encoded.
+	EncodedBytes int64 `json:"encoded_bytes"`
+	// StoredBytes is the total size of the values as stored, after compression.
+	StoredBytes int64 `json:"stored_bytes"`
+}
+
+// Ratio returns the compression ratio, the encoded size of the values over
+// their stored size, or 0 if no values are stored.
+func (s CodecStats) Ratio() float64 {
+	if s.StoredBytes == 0 {
+		return 0
+	}
+	return float64(s.EncodedBytes) / float64(s.StoredBytes)
+}
+
+// Encoded wraps a Cache and stores its values serialized with a codec.Codec,
+// optionally compressed, which shrinks large values and keeps them out of the
+// garbage collector's sight. Values read are decoded anew, so callers get
+// copies they may modify.
+//
+// Encoded tracks the size of every value to bound their total size and report
+// CodecStats. Entries the wrapped cache drops are accounted for when it
+// reports them through EvictionNotifier or ExpirationNotifier; the wrapped
+// cache must not be written to other than through Encoded.
+type Encoded struct {
+	cache     Cache
+	codec     codec.Codec
+	comp      codec.Compressor
+	threshold int
+	maxBytes  int64
+	onError   func(err error)
+	stats     *stats.Counter
+
+	// mu orders writes, so that evicting entries to bound their total size
+	// sees the sizes of the entries written before.
+	mu *sync.Mutex
+
+	// sizesMu guards the sizes and the eviction hooks. It is taken by the
+	// hooks of the wrapped cache, so it must not be held while calling it.
+	sizesMu *sync.Mutex
+	sizes   map[string]encodedSize
+	totals  CodecStats
+	onEvict hooks.Evict
+}
+
+// encodedSize is the size of a value stored by an Encoded cache.
+type encodedSize struct {
+	encoded, stored int
+	compressed      bool
+}
+
+// NewEncoded returns a cache storing the values encoded with cd in c.
+func NewEncoded(c Cache, cd codec.Codec, opts ...EncodedOption) *Encoded {
+	e := &Encoded{
+		cache:   c,
+		codec:   cd,
+		stats:   &stats.Counter{},
+		mu:      &sync.Mutex{},
+		sizesMu: &sync.Mutex{},
+		sizes:   make(map[string]encodedSize),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if notifier, ok := c.(EvictionNotifier); ok {
+		notifier.AddEvictionHook(func(key string, val any) {
+			e.sizesMu.Lock()
+			defer e.sizesMu.Unlock()
+			e.forget(key)
+			e.callEvictionHooks(key, val.([]byte))
+		})
+	}
+	if notifier, ok := c.(ExpirationNotifier); ok {
+		notifier.AddExpirationHook(func(key string, _ any) {
+			e.sizesMu.Lock()
+			defer e.sizesMu.Unlock()
+			e.forget(key)
+		})
+	}
+	return e
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (e *Encoded) Get(key string) (any, bool) {
+	val, ok, err := e.get(key)
+	e.report(err)
+	e.stats.Lookup(ok)
+	return val, ok
+}
+
+// Put adds a new key-value pair to the cache.
+func (e *Encoded) Put(key string, val any) {
+	e.report(e.put(key, val))
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (e *Encoded) Remove(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.remove(key)
+}
+
+// Len returns the number of items in the cache.
+func (e *Encoded) Len() int {
+	return e.cache.Len()
+}
+
+// Clear removes all items from the cache.
+func (e *Encoded) Clear() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cache.Clear()
+
+	e.sizesMu.Lock()
+	defer e.sizesMu.Unlock()
+	e.sizes = make(map[string]encodedSize)
+	e.totals = CodecStats{}
+}
+
+// Contains checks if a key is present in the cache.
+func (e *Encoded) Contains(key string) bool {
+	return e.cache.Contains(key)
+}
+
+// Stats returns the hits and misses of the encoded cache, counting values that
+// fail to decode as misses, the evictions of the wrapped cache (when it
+// reports them) plus those made to bound the size of the values, and the
+// expirations of the wrapped cache.
+func (e *Encoded) Stats() Stats {
+	s := e.stats.Snapshot()
+	if reporter, ok := e.cache.(StatsReporter); ok {
+		inner := reporter.Stats()
+		s.Evictions += inner.Evictions
+		s.Expirations = inner.Expirations
+	}
+	return s
+}
+
+// CodecStats returns the number and sizes of the values stored.
+func (e *Encoded) CodecStats() CodecStats {
+	e.sizesMu.Lock()
+	defer e.sizesMu.Unlock()
+	return e.totals
+}
+
+// AddEvictionHook registers fn to be called with every entry evicted, by the
+// wrapped cache to make room for a new one or to bound the size of the values.
+// Evicted values are decoded for fn; those that fail to decode are skipped.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (e *Encoded) AddEvictionHook(fn func(key string, val any)) {
+	e.sizesMu.Lock()
+	defer e.sizesMu.Unlock()
+	e.onEvict.Add(fn)
+}
+
+// Limit returns the limit of the wrapped cache, or 0 if it does not implement Inspector.
+func (e *Encoded) Limit() int {
+	if inspector, ok := e.cache.(Inspector); ok {
+		return inspector.Limit()
+	}
+	return 0
+}
+
+// Keys returns the keys of the wrapped cache in eviction order, or nil if it
+// does not implement Inspector.
+func (e *Encoded) Keys() []string {
+	if inspector, ok := e.cache.(Inspector); ok {
+		return inspector.Keys()
+	}
+	return nil
+}
+
+// Unwrap returns the wrapped cache.
+func (e *Encoded) Unwrap() Cache {
+	return e.cache
+}
+
+func (e *Encoded) get(key string) (any, bool, error) {
+	data, ok := e.cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	val, err := e.decode(data.([]byte))
+	if err != nil {
+		return nil, false, err
+	}
+	return val, true, nil
+}
+
+func (e *Encoded) put(key string, val any) error {
+	data, err := e.codec.Encode(val)
+	if err != nil {
+		return err
+	}
+	size := encodedSize{encoded: len(data), stored: len(data)}
+	if e.comp != nil {
+		if data, err = codec.Pack(e.comp, e.threshold, data); err != nil {
+			return err
+		}
+		size.stored, size.compressed = len(data), codec.IsCompressed(data)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	inspector, bounded := e.cache.(Inspector)
+	bounded = bounded && e.maxBytes > 0
+	if bounded && int64(size.stored) > e.maxBytes {
+		e.remove(key)
+		return nil
+	}
+	e.cache.Put(key, data)
+
+	e.sizesMu.Lock()
+	e.forget(key)
+	e.sizes[key] = size
+	e.totals.Entries++
+	e.totals.EncodedBytes += int64(size.encoded)
+	e.totals.StoredBytes += int64(size.stored)
+	if size.compressed {
+		e.totals.Compressed++
+	}
+	over := bounded && e.totals.StoredBytes > e.maxBytes
+	e.sizesMu.Unlock()
+
+	if over {
+		e.shrink(inspector, key)
+	}
+	return nil
+}
+
+// shrink evicts entries other than key, next to be evicted first, until the
+// values fit in maxBytes. e.mu must be held.
+func (e *Encoded) shrink(inspector Inspector, key string) {
+	for _, k := range inspector.Keys() {
+		e.sizesMu.Lock()
+		done := e.totals.StoredBytes <= e.maxBytes
+		e.sizesMu.Unlock()
+		if done {
+			return
+		}
+		if k == key {
+			continue
+		}
+
+		var data any
+		if len(e.onEvict) > 0 {
+			data, _ = e.cache.Get(k)
+		}
+		e.cache.Remove(k)
+		e.stats.Evict()
+
+		e.sizesMu.Lock()
+		e.forget(k)
+		if data != nil {
+			e.callEvictionHooks(k, data.([]byte))
+		}
+		e.sizesMu.Unlock()
+	}
+}
+
+// remove deletes key from the wrapped cache. e.mu must be held.
+func (e *Encoded) remove(key string) {
+	e.cache.Remove(key)
+	e.sizesMu.Lock()
+	defer e.sizesMu.Unlock()
+	e.forget(key)
+}
+
+// forget drops the size of key from the totals. e.sizesMu must be held.
+func (e *Encoded) forget(key string) {
+	size, ok := e.sizes[key]
+	if !ok {
+		return
+	}
+	delete(e.sizes, key)
+	e.totals.Entries--
+	e.totals.EncodedBytes -= int64(size.encoded)
+	e.totals.StoredBytes -= int64(size.stored)
+	if size.compressed {
+		e.totals.Compressed--
+	}
+}
+
+// callEvictionHooks calls the eviction hooks with the decoded value of an
+// evicted entry. e.sizesMu must be held.
+func (e *Encoded) callEvictionHooks(key string, data []byte) {
+	if len(e.onEvict) == 0 {
+		return
+	}
+	val, err := e.decode(data)
+	if err != nil {
+		e.report(err)
+		return
+	}
+	e.onEvict.Call(key, val)
+}
+
+// decode returns the value of the stored data.
+func (e *Encoded) decode(data []byte) (any, error) {
+	if e.comp != nil {
+		var err error
+		if data, err = codec.Unpack(e.comp, data); err != nil {
+			return nil, err
+		}
+	}
+	return e.codec.Decode(data)
+}
+
+// report passes a non-nil error to the error handler.
+func (e *Encoded) report(err error) {
+	if err != nil && e.onError != nil {
+		e.onError(err)
+	}
+}
@@ -0,0 +1,134 @@
+package gofast
+
+import (
+	"bytes"
+	"compress/flate"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raghavgh/gofast/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ StatsReporter    = (*Encoded)(nil)
+	_ EvictionNotifier = (*Encoded)(nil)
+	_ Inspector        = (*Encoded)(nil)
+	_ Wrapper          = (*Encoded)(nil)
+)
+
+func TestEncoded(t *testing.T) {
+	t.Run("values are copies", func(t *testing.T) {
+		cache := NewEncoded(NewCache(10, LRU), codec.Raw{})
+		val := []byte("value")
+		cache.Put("a", val)
+		val[0] = 'V'
+
+		got, ok := cache.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("value"), got)
+		got.([]byte)[0] = 'V'
+		got, _ = cache.Get("a")
+		assert.Equal(t, []byte("value"), got)
+
+		_, ok = cache.Get("b")
+		assert.False(t, ok)
+		assert.Equal(t, Stats{Hits: 2, Misses: 1}, cache.Stats())
+	})
+
+	t.Run("compression", func(t *testing.T) {
+		cache := NewEncoded(NewCache(10, LRU), codec.Raw{}, WithCompression(codec.Flate(flate.BestSpeed), 64))
+		large := bytes.Repeat([]byte("gofast "), 100)
+		cache.Put("large", large)
+		cache.Put("small", []byte("small"))
+
+		got, ok := cache.Get("large")
+		assert.True(t, ok)
+		assert.Equal(t, large, got)
+		got, _ = cache.Get("small")
+		assert.Equal(t, []byte("small"), got)
+
+		s := cache.CodecStats()
+		assert.Equal(t, 2, s.Entries)
+		assert.Equal(t, 1, s.Compressed)
+		assert.Equal(t, int64(len(large)+len("small")), s.EncodedBytes)
+		assert.Less(t, s.StoredBytes, s.EncodedBytes/4)
+		assert.Greater(t, s.Ratio(), 4.0)
+
+		cache.Remove("large")
+		assert.Equal(t, CodecStats{Entries: 1, EncodedBytes: 5, StoredBytes: 6}, cache.CodecStats())
+		cache.Clear()
+		assert.Equal(t, CodecStats{}, cache.CodecStats())
+		assert.Zero(t, cache.CodecStats().Ratio())
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		cache := NewEncoded(NewCache(100, LRU), codec.Raw{}, WithMaxBytes(10))
+		var evicted []string
+		cache.AddEvictionHook(func(key string, val any) {
+			evicted = append(evicted, key+"="+string(val.([]byte)))
+		})
+
+		cache.Put("a", []byte("1234"))
+		cache.Put("b", []byte("5678"))
+		cache.Get("a")
+		cache.Put("c", []byte("90"))
+		assert.Empty(t, evicted)
+
+		cache.Put("d", []byte("xy"))
+		assert.Equal(t, []string{"b=5678"}, evicted)
+		assert.Equal(t, []string{"a", "c", "d"}, cache.Keys())
+		assert.Equal(t, int64(8), cache.CodecStats().StoredBytes)
+		assert.Equal(t, uint64(1), cache.Stats().Evictions)
+
+		// Replacing a value counts its new size only.
+		cache.Put("a", []byte("123456"))
+		assert.Equal(t, int64(10), cache.CodecStats().StoredBytes)
+
+		// Values larger than the limit are not stored.
+		cache.Put("c", []byte(strings.Repeat("z", 11)))
+		assert.False(t, cache.Contains("c"))
+		assert.Equal(t, int64(8), cache.CodecStats().StoredBytes)
+	})
+
+	t.Run("entries dropped by the wrapped cache", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		expiring := NewExpiring(NewCache(2, LRU), WithExpiringClock(clock))
+		cache := NewEncoded(expiring, codec.Gob{})
+		var evicted []any
+		cache.AddEvictionHook(func(key string, val any) {
+			evicted = append(evicted, val)
+		})
+
+		cache.Put("1", 1)
+		cache.Put("2", 2)
+		cache.Put("3", 3)
+		assert.Equal(t, []any{1}, evicted)
+		assert.Equal(t, 2, cache.CodecStats().Entries)
+
+		expiring.Touch("2", time.Second)
+		clock.Advance(time.Minute)
+		_, ok := cache.Get("2")
+		assert.False(t, ok)
+		assert.Equal(t, 1, cache.CodecStats().Entries)
+		assert.Equal(t, Stats{Misses: 1, Evictions: 1, Expirations: 1}, cache.Stats())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var errs []error
+		inner := NewCache(10, LRU)
+		cache := NewEncoded(inner, codec.Gob{}, WithEncodingErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+
+		cache.Put("a", func() {})
+		assert.False(t, cache.Contains("a"))
+
+		inner.Put("b", []byte("not gob"))
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+		assert.Len(t, errs, 2)
+		assert.Equal(t, uint64(1), cache.Stats().Misses)
+	})
+}
@@ -0,0 +1,306 @@
+package gofast
+
+import (
+	"sync"
+	"time"
+
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+)
+
+// ExpiringOption configures an Expiring cache.
+type ExpiringOption func(e *Expiring)
+
+// WithExpiringClock sets the clock telling when entries expire. Defaults to RealClock.
+func WithExpiringClock(clock Clock) ExpiringOption {
+	return func(e *Expiring) {
+		e.clock = clock
+	}
+}
+
+// Expiring wraps a Cache and gives each entry an optional time to live.
+//
+// Expired entries are dropped lazily, when they are read; until then they keep
+// using capacity of the wrapped cache, and the wrapped cache's eviction policy
+// may evict them before they expire.
+type Expiring struct {
+	cache    Cache
+	clock    Clock
+	stats    *stats.Counter
+	onExpire hooks.Evict
+
+	// mu makes dropping an expired entry atomic with respect to writes:
+	// writes hold the read lock, so they still run concurrently with each other,
+	// while dropping an entry holds the write lock and checks it was not replaced.
+	mu *sync.RWMutex
+}
+
+// expiringEntry is the value stored in the wrapped cache.
+type expiringEntry struct {
+	value any
+	// expiresAt is the zero time for entries that never expire.
+	expiresAt time.Time
+	// tags are kept for Touch to put them back.
+	tags []string
+}
+
+// expired reports whether the entry's time to live ran out at now.
+func (e *expiringEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// remaining returns the time to live left to the entry at now, 0 if it never expires.
+func (e *expiringEntry) remaining(now time.Time) time.Duration {
+	if e.expiresAt.IsZero() {
+		return 0
+	}
+	return e.expiresAt.Sub(now)
+}
+
+// NewExpiring returns an expiring cache storing its entries in c.
+func NewExpiring(c Cache, opts ...ExpiringOption) *Expiring {
+	e := &Expiring{
+		cache: c,
+		clock: RealClock(),
+		stats: &stats.Counter{},
+		mu:    &sync.RWMutex{},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (e *Expiring) Get(key string) (any, bool) {
+	entry, ok := e.get(key, true, e.clock.Now())
+	e.stats.Lookup(ok)
+	if !ok {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Peek returns the value of key if it is not expired, without counting the
+// read as a use of key or in the stats.
+func (e *Expiring) Peek(key string) (any, bool) {
+	entry, ok := e.lookup(key)
+	if !ok || entry.expired(e.clock.Now()) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Put adds a new key-value pair to the cache that never expires.
+func (e *Expiring) Put(key string, val any) {
+	e.PutWithTTL(key, val, 0)
+}
+
+// PutWithTTL adds a new key-value pair to the cache that expires after ttl.
+// A ttl of 0 or less means the entry never expires.
+func (e *Expiring) PutWithTTL(key string, val any, ttl time.Duration) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	e.cache.Put(key, e.newEntry(val, ttl))
+}
+
+// PutWithTags adds a new key-value pair to the cache that never expires,
+// carrying tags. The tags are dropped if the wrapped cache does not implement
+// Tagger. Touch keeps the tags of an entry.
+func (e *Expiring) PutWithTags(key string, val any, tags ...string) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	entry := e.newEntry(val, 0)
+	entry.tags = tags
+	putWithTags(e.cache, key, entry, tags)
+}
+
+// InvalidateTag removes every entry carrying tag, expired or not, and returns
+// the number of entries removed. It returns 0 if the wrapped cache does not
+// implement Tagger.
+func (e *Expiring) InvalidateTag(tag string) int {
+	if tagger, ok := e.cache.(Tagger); ok {
+		return tagger.InvalidateTag(tag)
+	}
+	return 0
+}
+
+// TaggedKeys returns the keys carrying tag, expired or not, or nil if the
+// wrapped cache does not implement Tagger.
+func (e *Expiring) TaggedKeys(tag string) []string {
+	return taggedKeys(e.cache, tag)
+}
+
+// TTL returns the remaining time to live of key, and false if key is not in
+// the cache. The duration is 0 for entries that never expire.
+func (e *Expiring) TTL(key string) (time.Duration, bool) {
+	now := e.clock.Now()
+	entry, ok := e.get(key, false, now)
+	if !ok {
+		return 0, false
+	}
+	return entry.remaining(now), true
+}
+
+// GetWithTTL retrieves a value from the cache like Get, with its remaining
+// time to live as TTL returns it. Both come from the same entry, even if key
+// is replaced or expires meanwhile.
+func (e *Expiring) GetWithTTL(key string) (any, time.Duration, bool) {
+	now := e.clock.Now()
+	entry, ok := e.get(key, true, now)
+	e.stats.Lookup(ok)
+	if !ok {
+		return nil, 0, false
+	}
+	return entry.value, entry.remaining(now), true
+}
+
+// Touch sets the time to live of key to ttl, or makes it never expire if ttl
+// is 0 or less, and reports whether key was in the cache.
+func (e *Expiring) Touch(key string, ttl time.Duration) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.lookup(key)
+	if !ok || entry.expired(e.clock.Now()) {
+		return false
+	}
+	touched := e.newEntry(entry.value, ttl)
+	touched.tags = entry.tags
+	putWithTags(e.cache, key, touched, touched.tags)
+	return true
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (e *Expiring) Remove(key string) {
+	e.cache.Remove(key)
+}
+
+// Len returns the number of items in the cache, including expired items
+// that were not read since they expired.
+func (e *Expiring) Len() int {
+	return e.cache.Len()
+}
+
+// Clear removes all items from the cache.
+func (e *Expiring) Clear() {
+	e.cache.Clear()
+}
+
+// Contains checks if a key is present in the cache and not expired.
+func (e *Expiring) Contains(key string) bool {
+	_, ok := e.get(key, false, e.clock.Now())
+	return ok
+}
+
+// Stats returns the hits and misses of the expiring cache, the evictions of
+// the wrapped cache (when it reports them) and the number of expired entries dropped.
+func (e *Expiring) Stats() Stats {
+	s := e.stats.Snapshot()
+	if reporter, ok := e.cache.(StatsReporter); ok {
+		s.Evictions = reporter.Stats().Evictions
+	}
+	return s
+}
+
+// AddEvictionHook registers fn to be called with every entry the wrapped cache
+// evicts to make room for a new one. It does nothing if the wrapped cache does
+// not implement EvictionNotifier.
+func (e *Expiring) AddEvictionHook(fn func(key string, val any)) {
+	if notifier, ok := e.cache.(EvictionNotifier); ok {
+		notifier.AddEvictionHook(func(key string, val any) {
+			fn(key, val.(*expiringEntry).value)
+		})
+	}
+}
+
+// AddExpirationHook registers fn to be called with every expired entry dropped.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (e *Expiring) AddExpirationHook(fn func(key string, val any)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onExpire.Add(fn)
+}
+
+// Limit returns the limit of the wrapped cache, or 0 if it does not implement Inspector.
+func (e *Expiring) Limit() int {
+	if inspector, ok := e.cache.(Inspector); ok {
+		return inspector.Limit()
+	}
+	return 0
+}
+
+// Keys returns the keys of the wrapped cache in eviction order, including
+// expired keys that were not read since they expired, or nil if it does not
+// implement Inspector.
+func (e *Expiring) Keys() []string {
+	if inspector, ok := e.cache.(Inspector); ok {
+		return inspector.Keys()
+	}
+	return nil
+}
+
+// Unwrap returns the wrapped cache.
+func (e *Expiring) Unwrap() Cache {
+	return e.cache
+}
+
+// get returns the entry of key if it is not expired at now, dropping it if it
+// is. The read is a use of key for the wrapped cache only if use is true.
+func (e *Expiring) get(key string, use bool, now time.Time) (*expiringEntry, bool) {
+	var entry *expiringEntry
+	if use {
+		val, ok := e.cache.Get(key)
+		if !ok {
+			return nil, false
+		}
+		entry = val.(*expiringEntry)
+	} else {
+		var ok bool
+		if entry, ok = e.lookup(key); !ok {
+			return nil, false
+		}
+	}
+	if entry.expired(now) {
+		e.drop(key, entry)
+		return nil, false
+	}
+	return entry, true
+}
+
+// lookup returns the entry of key in the wrapped cache, expired or not,
+// peeking at it if the wrapped cache implements Peeker.
+func (e *Expiring) lookup(key string) (*expiringEntry, bool) {
+	var val any
+	var ok bool
+	if peeker, isPeeker := e.cache.(Peeker); isPeeker {
+		val, ok = peeker.Peek(key)
+	} else {
+		val, ok = e.cache.Get(key)
+	}
+	if !ok {
+		return nil, false
+	}
+	return val.(*expiringEntry), true
+}
+
+// drop removes the expired entry of key, unless it was replaced meanwhile.
+func (e *Expiring) drop(key string, expired *expiringEntry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if current, ok := e.lookup(key); ok && current == expired {
+		e.cache.Remove(key)
+		e.stats.Expire()
+		e.onExpire.Call(key, expired.value)
+	}
+}
+
+// newEntry returns the entry for val expiring after ttl.
+func (e *Expiring) newEntry(val any, ttl time.Duration) *expiringEntry {
+	entry := &expiringEntry{value: val}
+	if ttl > 0 {
+		entry.expiresAt = e.clock.Now().Add(ttl)
+	}
+	return entry
+}
@@ -0,0 +1,179 @@
+package gofast
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiring(t *testing.T) {
+	t.Run("entries without ttl never expire", func(t *testing.T) {
+		cache := NewExpiring(NewCache(10, LRU))
+		cache.Put("1", 1)
+
+		val, ok := cache.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+
+		ttl, ok := cache.TTL("1")
+		assert.True(t, ok)
+		assert.Zero(t, ttl)
+	})
+
+	t.Run("entries expire after their ttl", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		cache := NewExpiring(NewCache(10, LRU), WithExpiringClock(clock))
+		cache.PutWithTTL("1", 1, 20*time.Millisecond)
+
+		clock.Advance(5 * time.Millisecond)
+		ttl, ok := cache.TTL("1")
+		assert.True(t, ok)
+		assert.Equal(t, 15*time.Millisecond, ttl)
+		assert.True(t, cache.Contains("1"))
+
+		clock.Advance(15 * time.Millisecond)
+		_, ok = cache.Get("1")
+		assert.False(t, ok)
+		assert.False(t, cache.Contains("1"))
+		_, ok = cache.TTL("1")
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.Len())
+		assert.Equal(t, uint64(1), cache.Stats().Expirations)
+	})
+
+	t.Run("put replaces the ttl", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		cache := NewExpiring(NewCache(10, LRU), WithExpiringClock(clock))
+		cache.PutWithTTL("1", 1, 20*time.Millisecond)
+		cache.Put("1", 2)
+
+		clock.Advance(time.Hour)
+		val, ok := cache.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, 2, val)
+	})
+
+	t.Run("touch", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		cache := NewExpiring(NewCache(10, LRU), WithExpiringClock(clock))
+		cache.PutWithTTL("1", 1, 20*time.Millisecond)
+		cache.PutWithTTL("2", 2, time.Hour)
+
+		assert.True(t, cache.Touch("1", time.Hour))
+		assert.True(t, cache.Touch("2", 0))
+		assert.False(t, cache.Touch("3", time.Hour))
+
+		clock.Advance(30 * time.Millisecond)
+		assert.True(t, cache.Contains("1"))
+		ttl, _ := cache.TTL("2")
+		assert.Zero(t, ttl)
+	})
+
+	t.Run("get with ttl", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		cache := NewExpiring(NewCache(10, LRU), WithExpiringClock(clock))
+		cache.PutWithTTL("1", 1, time.Minute)
+		cache.Put("2", 2)
+
+		clock.Advance(20 * time.Second)
+		val, ttl, ok := cache.GetWithTTL("1")
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+		assert.Equal(t, 40*time.Second, ttl)
+		_, ttl, ok = cache.GetWithTTL("2")
+		assert.True(t, ok)
+		assert.Zero(t, ttl)
+
+		clock.Advance(time.Minute)
+		_, _, ok = cache.GetWithTTL("1")
+		assert.False(t, ok)
+		assert.Equal(t, Stats{Hits: 2, Misses: 1, Expirations: 1}, cache.Stats())
+	})
+
+	t.Run("inspecting entries does not use them", func(t *testing.T) {
+		inner := NewCache(2, LRU)
+		cache := NewExpiring(inner)
+		cache.PutWithTTL("1", 1, time.Hour)
+		cache.Put("2", 2)
+
+		assert.True(t, cache.Contains("1"))
+		_, ok := cache.TTL("1")
+		assert.True(t, ok)
+		val, ok := cache.Peek("1")
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+
+		// "1" is still the least recently used, and no read was counted.
+		assert.Equal(t, []string{"1", "2"}, cache.Keys())
+		assert.Equal(t, Stats{}, cache.Stats())
+		assert.Equal(t, uint64(0), inner.(StatsReporter).Stats().Hits)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		cache := NewExpiring(NewCache(10, LRU), WithExpiringClock(clock))
+		cache.PutWithTags("1", 1, "t")
+		cache.PutWithTags("2", 2, "t")
+		cache.PutWithTTL("3", 3, time.Hour)
+
+		// Touch keeps the tags, while a new put drops them.
+		assert.True(t, cache.Touch("1", time.Minute))
+		cache.Put("2", 2)
+		assert.Equal(t, []string{"1"}, cache.TaggedKeys("t"))
+		ttl, _ := cache.TTL("1")
+		assert.Equal(t, time.Minute, ttl)
+
+		assert.Equal(t, 1, cache.InvalidateTag("t"))
+		assert.False(t, cache.Contains("1"))
+		assert.Equal(t, 2, cache.Len())
+	})
+
+	t.Run("remove and clear", func(t *testing.T) {
+		cache := NewExpiring(NewCache(10, LRU))
+		cache.Put("1", 1)
+		cache.Put("2", 2)
+
+		cache.Remove("1")
+		assert.False(t, cache.Contains("1"))
+		cache.Clear()
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("stats and eviction hooks", func(t *testing.T) {
+		var evicted []any
+		cache := NewExpiring(NewCache(1, LRU))
+		cache.AddEvictionHook(func(key string, val any) {
+			evicted = append(evicted, val)
+		})
+
+		cache.Put("1", 1)
+		cache.Put("2", 2)
+		cache.Get("2")
+		cache.Get("1")
+
+		assert.Equal(t, []any{1}, evicted)
+		assert.Equal(t, Stats{Hits: 1, Misses: 1, Evictions: 1}, cache.Stats())
+	})
+}
+
+func TestExpiring_Concurrent(t *testing.T) {
+	cache := NewExpiring(NewCache(100, LRU))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := strconv.Itoa(j % 50)
+				cache.PutWithTTL(key, j, time.Duration(j%3)*time.Microsecond)
+				cache.Get(key)
+				cache.Touch(key, time.Millisecond)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, cache.Len(), 100)
+}
@@ -0,0 +1,284 @@
+// Package arena implements a cache of []byte values stored in large chunks
+// rather than in individual heap objects, so that the garbage collector has
+// almost nothing to scan however many entries the cache holds.
+//
+// The cache is split into buckets by key hash, each with its own lock. A
+// bucket is a ring of fixed-size chunks that entries are appended to; its
+// index maps the 64-bit hash of each key to the entry's offset in the ring,
+// and holds no pointers. When the ring wraps around, the oldest chunk is
+// reused and every entry still in it is evicted: eviction is FIFO at chunk
+// granularity, or LRU-ish with promotion, which copies the entries read from
+// the oldest chunks to the head of the ring.
+package arena
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/raghavgh/gofast/internal/cache/stats"
+)
+
+const (
+	// ChunkSize is the size of the chunks entries are stored in.
+	ChunkSize = 64 << 10
+	// headerSize is the size of the key and value lengths preceding each entry.
+	headerSize = 2 + 4
+	// MaxEntrySize is the largest key plus value length stored; larger entries are dropped.
+	MaxEntrySize = ChunkSize - headerSize
+	// maxKeyLen is the longest key stored.
+	maxKeyLen = 1<<16 - 1
+	// maxBuckets is the number of buckets of large caches.
+	maxBuckets = 256
+	// minChunksPerBucket is the smallest ring: one chunk being written and one being read.
+	minChunksPerBucket = 2
+	// maxChunksPerBucket keeps the offsets of a ring within a uint32.
+	maxChunksPerBucket = 1<<32/ChunkSize - 1
+)
+
+// Cache is a thread-safe cache of []byte values.
+type Cache struct {
+	buckets []bucket
+	// mask selects the bucket of a hash; len(buckets) is a power of two.
+	mask    uint64
+	promote bool
+	stats   *stats.Counter
+}
+
+// bucket is a ring of chunks and the index of the entries in it.
+type bucket struct {
+	mu *sync.RWMutex
+	// chunks are allocated on first use.
+	chunks [][]byte
+	// hashes lists the hashes of the entries written to each chunk, to evict
+	// them when the chunk is reused.
+	hashes [][]uint64
+	// index maps key hashes to ring offsets.
+	index map[uint64]uint32
+	// pos is the ring offset the next entry is written at.
+	pos uint32
+}
+
+// New returns a cache storing up to about maxBytes of entries, including
+// their keys and a few bytes of overhead each, and at least 128 KiB. With
+// promote, entries read from the oldest quarter of the ring are moved to its
+// head, so that entries in use survive the reuse of their chunk.
+func New(maxBytes int, promote bool) *Cache {
+	chunks := maxBytes / ChunkSize
+	buckets := 1
+	for buckets*2 <= maxBuckets && buckets*2*minChunksPerBucket*2 <= chunks {
+		buckets *= 2
+	}
+	perBucket := chunks / buckets
+	if perBucket < minChunksPerBucket {
+		perBucket = minChunksPerBucket
+	}
+	if perBucket > maxChunksPerBucket {
+		perBucket = maxChunksPerBucket
+	}
+
+	c := &Cache{
+		buckets: make([]bucket, buckets),
+		mask:    uint64(buckets - 1),
+		promote: promote,
+		stats:   &stats.Counter{},
+	}
+	for i := range c.buckets {
+		c.buckets[i] = bucket{
+			mu:     &sync.RWMutex{},
+			chunks: make([][]byte, perBucket),
+			hashes: make([][]uint64, perBucket),
+			index:  make(map[uint64]uint32),
+		}
+	}
+	return c
+}
+
+// Set stores val for key. Entries whose key and value are longer than
+// MaxEntrySize together, or whose key is longer than 65535 bytes, are not
+// stored, and any previous value of key is removed.
+func (c *Cache) Set(key string, val []byte) {
+	h := hash(key)
+	b := c.bucket(h)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(key) > maxKeyLen || len(key)+len(val) > MaxEntrySize {
+		if _, ok := b.lookup(h, key); ok {
+			delete(b.index, h)
+		}
+		return
+	}
+	c.write(b, h, key, val)
+}
+
+// Get appends the value of key to dst and returns it, and false if key is
+// not in the cache. Passing a dst with enough capacity avoids allocating.
+func (c *Cache) Get(dst []byte, key string) ([]byte, bool) {
+	h := hash(key)
+	b := c.bucket(h)
+	if c.promote {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+	} else {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+	}
+
+	v, ok := b.lookup(h, key)
+	c.stats.Lookup(ok)
+	if !ok {
+		return dst, false
+	}
+	dst = append(dst, v...)
+	if c.promote && b.old(b.index[h]) {
+		c.write(b, h, key, dst[len(dst)-len(v):])
+	}
+	return dst, true
+}
+
+// Has reports whether key is in the cache.
+func (c *Cache) Has(key string) bool {
+	h := hash(key)
+	b := c.bucket(h)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.lookup(h, key)
+	return ok
+}
+
+// Delete removes key from the cache. Its space is reclaimed when its chunk is reused.
+func (c *Cache) Delete(key string) {
+	h := hash(key)
+	b := c.bucket(h)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.lookup(h, key); ok {
+		delete(b.index, h)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache) Len() int {
+	n := 0
+	for i := range c.buckets {
+		b := &c.buckets[i]
+		b.mu.RLock()
+		n += len(b.index)
+		b.mu.RUnlock()
+	}
+	return n
+}
+
+// Clear removes all entries from the cache, keeping its chunks for reuse.
+func (c *Cache) Clear() {
+	for i := range c.buckets {
+		b := &c.buckets[i]
+		b.mu.Lock()
+		b.index = make(map[uint64]uint32)
+		for j := range b.hashes {
+			b.hashes[j] = b.hashes[j][:0]
+		}
+		b.pos = 0
+		b.mu.Unlock()
+	}
+}
+
+// MaxBytes returns the total size of the chunks of the cache.
+func (c *Cache) MaxBytes() int {
+	return len(c.buckets) * len(c.buckets[0].chunks) * ChunkSize
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (c *Cache) Stats() stats.Stats {
+	return c.stats.Snapshot()
+}
+
+// bucket returns the bucket of hash h.
+func (c *Cache) bucket(h uint64) *bucket {
+	return &c.buckets[h&c.mask]
+}
+
+// write appends an entry to the ring of b and indexes it, reusing the next
+// chunk if the current one is full. b must be locked for writing.
+func (c *Cache) write(b *bucket, h uint64, key string, val []byte) {
+	size := uint32(headerSize + len(key) + len(val))
+	chunk, off := b.pos/ChunkSize, b.pos%ChunkSize
+	if off+size > ChunkSize {
+		chunk = (chunk + 1) % uint32(len(b.chunks))
+		off = 0
+	}
+	if off == 0 {
+		c.reuse(b, chunk)
+	}
+
+	buf := b.chunks[chunk][off : off+size]
+	binary.LittleEndian.PutUint16(buf, uint16(len(key)))
+	binary.LittleEndian.PutUint32(buf[2:], uint32(len(val)))
+	copy(buf[headerSize:], key)
+	copy(buf[headerSize+len(key):], val)
+
+	b.index[h] = chunk*ChunkSize + off
+	b.hashes[chunk] = append(b.hashes[chunk], h)
+	b.pos = (chunk*ChunkSize + off + size) % (uint32(len(b.chunks)) * ChunkSize)
+}
+
+// reuse prepares chunk to be written from its start, allocating it or
+// evicting the entries still in it.
+func (c *Cache) reuse(b *bucket, chunk uint32) {
+	if b.chunks[chunk] == nil {
+		b.chunks[chunk] = make([]byte, ChunkSize)
+		return
+	}
+	for _, h := range b.hashes[chunk] {
+		if off, ok := b.index[h]; ok && off/ChunkSize == chunk {
+			delete(b.index, h)
+			c.stats.Evict()
+		}
+	}
+	b.hashes[chunk] = b.hashes[chunk][:0]
+}
+
+// lookup returns the value of key, which aliases the chunk.
+func (b *bucket) lookup(h uint64, key string) ([]byte, bool) {
+	off, ok := b.index[h]
+	if !ok {
+		return nil, false
+	}
+	chunk := b.chunks[off/ChunkSize]
+	off %= ChunkSize
+	keyLen := uint32(binary.LittleEndian.Uint16(chunk[off:]))
+	valLen := binary.LittleEndian.Uint32(chunk[off+2:])
+	// A different key with the same hash.
+	if string(chunk[off+headerSize:off+headerSize+keyLen]) != key {
+		return nil, false
+	}
+	start := off + headerSize + keyLen
+	return chunk[start : start+valLen], true
+}
+
+// old reports whether offset is in the oldest quarter of the ring, the next
+// chunks to be reused.
+func (b *bucket) old(offset uint32) bool {
+	n := uint32(len(b.chunks))
+	current, chunk := b.pos/ChunkSize, offset/ChunkSize
+	// distance is the number of chunks reused up to chunk, 0 for the current one.
+	distance := (chunk + n - current) % n
+	oldest := n / 4
+	if oldest == 0 {
+		oldest = 1
+	}
+	return distance != 0 && distance <= oldest
+}
+
+// hash returns the 64-bit FNV-1a hash of key, without allocating.
+func hash(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime64
+	}
+	return h
+}
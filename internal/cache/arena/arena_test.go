@@ -0,0 +1,107 @@
+package arena
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("set get delete", func(t *testing.T) {
+		c := New(1<<20, false)
+		c.Set("a", []byte("1"))
+		c.Set("b", []byte("22"))
+		c.Set("a", []byte("333"))
+
+		val, ok := c.Get(nil, "a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("333"), val)
+		val, ok = c.Get([]byte("b="), "b")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("b=22"), val)
+		_, ok = c.Get(nil, "c")
+		assert.False(t, ok)
+		assert.Equal(t, 2, c.Len())
+
+		c.Delete("a")
+		assert.False(t, c.Has("a"))
+		assert.True(t, c.Has("b"))
+		c.Clear()
+		assert.Equal(t, 0, c.Len())
+		assert.False(t, c.Has("b"))
+	})
+
+	t.Run("empty and large values", func(t *testing.T) {
+		c := New(1<<20, false)
+		c.Set("empty", nil)
+		val, ok := c.Get(nil, "empty")
+		assert.True(t, ok)
+		assert.Empty(t, val)
+
+		c.Set("big", []byte("small"))
+		c.Set("big", make([]byte, MaxEntrySize))
+		assert.False(t, c.Has("big"))
+		c.Set("big", make([]byte, MaxEntrySize-len("big")))
+		assert.True(t, c.Has("big"))
+	})
+
+	t.Run("chunks are evicted in order", func(t *testing.T) {
+		c := New(0, false)
+		assert.Len(t, c.buckets, 1)
+		assert.Equal(t, 2*ChunkSize, c.MaxBytes())
+
+		val := make([]byte, 1000)
+		for i := 0; i < 1000; i++ {
+			c.Set(strconv.Itoa(i), val)
+		}
+		assert.Less(t, c.Len(), 200)
+		assert.Equal(t, uint64(1000-c.Len()), c.Stats().Evictions)
+		assert.False(t, c.Has("0"))
+		assert.True(t, c.Has("999"))
+	})
+
+	t.Run("promotion keeps entries in use", func(t *testing.T) {
+		for _, promote := range []bool{false, true} {
+			c := New(0, promote)
+			c.Set("hot", []byte("x"))
+			val := make([]byte, 1000)
+			for i := 0; i < 1000; i++ {
+				c.Set(strconv.Itoa(i), val)
+				c.Get(nil, "hot")
+			}
+			assert.Equal(t, promote, c.Has("hot"))
+		}
+	})
+
+	t.Run("many buckets", func(t *testing.T) {
+		c := New(64<<20, false)
+		assert.Len(t, c.buckets, maxBuckets)
+		for i := 0; i < 10000; i++ {
+			c.Set(strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		}
+		for i := 0; i < 10000; i++ {
+			val, ok := c.Get(nil, strconv.Itoa(i))
+			assert.True(t, ok)
+			assert.Equal(t, strconv.Itoa(i), string(val))
+		}
+	})
+}
+
+func TestCache_Allocs(t *testing.T) {
+	c := New(1<<20, false)
+	val := bytes.Repeat([]byte("v"), 100)
+	// Warm up the index and the chunk lists.
+	for i := 0; i < 3; i++ {
+		c.Set("key", val)
+	}
+	buf := make([]byte, 0, 128)
+
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		c.Set("key", val)
+	}))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		buf, _ = c.Get(buf[:0], "key")
+	}))
+}
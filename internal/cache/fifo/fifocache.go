@@ -4,6 +4,9 @@ import (
 	"log"
 	"sync"
 
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+	"github.com/raghavgh/gofast/internal/cache/tags"
 	"github.com/raghavgh/gofast/internal/ds/queue"
 )
 
@@ -12,6 +15,9 @@ type Fifo struct {
 	queueEvictionList *queue.List
 	limit             int
 	mu                *sync.RWMutex
+	onEvict           hooks.Evict
+	tags              tags.Index
+	stats             *stats.Counter
 }
 
 // entry is used to hold a value in the eviction list.
@@ -31,6 +37,7 @@ func NewFifo(limit int) *Fifo {
 		queueEvictionList: queue.NewQueueList(true),
 		limit:             limit,
 		mu:                &sync.RWMutex{},
+		stats:             &stats.Counter{},
 	}
 }
 
@@ -40,25 +47,35 @@ func (f *Fifo) Get(key string) (any, bool) {
 	defer f.mu.RUnlock()
 
 	if element, ok := f.items[key]; ok {
+		f.stats.Lookup(true)
 		return element.value, true
 	}
 
+	f.stats.Lookup(false)
 	return nil, false
 }
 
-// Put adds a new key-value pair to the cache.
+// Put adds a new key-value pair to the cache, without tags.
 func (f *Fifo) Put(key string, val any) {
+	f.PutWithTags(key, val)
+}
+
+// PutWithTags adds a new key-value pair to the cache, replacing the tags of key with tags.
+func (f *Fifo) PutWithTags(key string, val any, tags ...string) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	f.put(key, val)
+	f.tags.Set(key, tags)
+}
 
+// put adds a new key-value pair to the cache. f.mu must be held.
+func (f *Fifo) put(key string, val any) {
 	if element, ok := f.items[key]; ok {
 		element.value = val
 		return
 	}
 	if len(f.items) >= f.limit {
-		element := f.queueEvictionList.Front()
-		f.queueEvictionList.Pop()
-		delete(f.items, element.(*entry).key)
+		f.evict()
 	}
 	entryVal := &entry{key: key, value: val}
 	f.queueEvictionList.Push(entryVal)
@@ -69,9 +86,33 @@ func (f *Fifo) Put(key string, val any) {
 func (f *Fifo) Remove(key string) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	f.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, and returns the number of entries removed.
+func (f *Fifo) InvalidateTag(tag string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
+	keys := f.tags.Keys(tag)
+	for _, key := range keys {
+		f.remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, in no particular order.
+func (f *Fifo) TaggedKeys(tag string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.tags.Keys(tag)
+}
+
+// remove deletes the entry of key and its tags. f.mu must be held.
+func (f *Fifo) remove(key string) {
 	if element, ok := f.items[key]; ok {
 		delete(f.items, key)
+		f.tags.Remove(key)
 		f.queueEvictionList.Remove(element)
 	}
 }
@@ -87,6 +128,7 @@ func (f *Fifo) Len() int {
 func (f *Fifo) Clear() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	f.tags.Clear()
 	f.items = make(map[string]*entry)
 	f.queueEvictionList = queue.NewQueueList(true)
 }
@@ -98,3 +140,73 @@ func (f *Fifo) Contains(key string) bool {
 	_, ok := f.items[key]
 	return ok
 }
+
+// Peek returns the value of key without counting the read as a use of key,
+// by the eviction policy or in the stats.
+func (f *Fifo) Peek(key string) (any, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if element, ok := f.items[key]; ok {
+		return element.value, true
+	}
+	return nil, false
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (f *Fifo) Stats() stats.Stats {
+	return f.stats.Snapshot()
+}
+
+// Limit returns the maximum number of items in the cache.
+func (f *Fifo) Limit() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.limit
+}
+
+// Resize sets the maximum number of items in the cache to limit, evicting
+// the oldest items until the cache fits. Growing does not reallocate.
+// It panics if limit is not greater than 0.
+func (f *Fifo) Resize(limit int) {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.limit = limit
+	for len(f.items) > limit {
+		f.evict()
+	}
+}
+
+// Keys returns the keys in the cache in eviction order, oldest first.
+func (f *Fifo) Keys() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	keys := make([]string, 0, len(f.items))
+	for node := f.queueEvictionList.Head; node != nil; node = node.Next {
+		keys = append(keys, node.Val.(*entry).key)
+	}
+	return keys
+}
+
+// AddEvictionHook registers fn to be called with every entry evicted to make room for a new one.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (f *Fifo) AddEvictionHook(fn func(key string, val any)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onEvict.Add(fn)
+}
+
+// evict removes the oldest item. f.mu must be held.
+func (f *Fifo) evict() {
+	element := f.queueEvictionList.Front().(*entry)
+	f.queueEvictionList.Pop()
+	delete(f.items, element.key)
+	f.tags.Remove(element.key)
+	f.stats.Evict()
+	f.onEvict.Call(element.key, element.value)
+}
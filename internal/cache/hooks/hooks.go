@@ -0,0 +1,18 @@
+package hooks
+
+// Evict is a list of functions called with every entry a cache evicts to make
+// room for a new one. It is not safe for concurrent use, caches guard it with
+// their own lock.
+type Evict []func(key string, val any)
+
+// Add registers fn to be called on eviction.
+func (h *Evict) Add(fn func(key string, val any)) {
+	*h = append(*h, fn)
+}
+
+// Call calls every registered function with the evicted entry.
+func (h Evict) Call(key string, val any) {
+	for _, fn := range h {
+		fn(key, val)
+	}
+}
@@ -272,3 +272,51 @@ func TestLFU(t *testing.T) {
 		})
 	}
 }
+
+func TestLFU_Resize(t *testing.T) {
+	lfu := NewLFU(4)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		lfu.Put(key, key)
+	}
+	lfu.Get("b")
+	lfu.Get("c")
+	lfu.Get("c")
+	lfu.Get("d")
+	lfu.Get("d")
+
+	// Evicting every item used once moves the minimum frequency up.
+	lfu.Resize(2)
+	assert.Equal(t, []string{"c", "d"}, lfu.Keys())
+	lfu.Resize(1)
+	assert.Equal(t, []string{"d"}, lfu.Keys())
+
+	lfu.Resize(3)
+	lfu.Put("e", "e")
+	lfu.Put("f", "f")
+	lfu.Put("g", "g")
+	assert.Equal(t, []string{"f", "g", "d"}, lfu.Keys())
+}
+
+func TestLFU_ResizeAfterRemove(t *testing.T) {
+	lfu := NewLFU(3)
+	lfu.Put("a", 1)
+	lfu.Put("b", 2)
+	lfu.Get("a")
+	lfu.Get("b")
+	lfu.Put("x", 3)
+	// Removing the only item used once empties the lowest frequency list.
+	lfu.Remove("x")
+
+	lfu.Resize(1)
+	assert.Equal(t, []string{"b"}, lfu.Keys())
+	lfu.Put("c", 3)
+	assert.Equal(t, []string{"c"}, lfu.Keys())
+
+	// Shrinking away every item used once leaves the cache full for put.
+	lfu.Resize(2)
+	lfu.Get("c")
+	lfu.Put("d", 4)
+	lfu.Resize(1)
+	lfu.Put("e", 5)
+	assert.Equal(t, []string{"e"}, lfu.Keys())
+}
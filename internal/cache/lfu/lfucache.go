@@ -1,75 +1,94 @@
 package lfu
 
 import (
+	"sort"
 	"sync"
 
-	"github.com/raghavgh/gofast/internal/ds/linkedlist"
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+	"github.com/raghavgh/gofast/internal/cache/tags"
+	"github.com/raghavgh/gofast/internal/ds/indexlist"
 )
 
 type LFU struct {
-	items         map[string]*entry
-	freqToListMap map[int]*linkedlist.LinkedList
-	minFreq       int
-	mu            *sync.RWMutex
-	limit         int
+	items         map[string]int32
+	freqToListMap map[int]*indexlist.List[entry]
+	// nodes stores the entries of every frequency list.
+	nodes   *indexlist.Arena[entry]
+	minFreq int
+	mu      *sync.RWMutex
+	limit   int
+	onEvict hooks.Evict
+	tags    tags.Index
+	stats   *stats.Counter
 }
 
 type entry struct {
 	key   string
 	value any
 	freq  int
-	node  *linkedlist.Node
 }
 
 func NewLFU(limit int) *LFU {
 	return &LFU{
-		items:         make(map[string]*entry),
-		freqToListMap: make(map[int]*linkedlist.LinkedList),
+		items:         make(map[string]int32, limit),
+		freqToListMap: make(map[int]*indexlist.List[entry]),
+		nodes:         indexlist.NewArena[entry](limit),
 		minFreq:       1,
 		mu:            &sync.RWMutex{},
+		stats:         &stats.Counter{},
 		limit:         limit,
 	}
 }
 
 func (l *LFU) Get(key string) (any, bool) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+	// Get moves the entry to the list of its new frequency, so it needs the write lock.
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	if element, ok := l.items[key]; ok {
-		l.updateFrequency(element)
-		return element.value, true
+	if node, ok := l.items[key]; ok {
+		node = l.updateFrequency(node)
+		l.items[key] = node
+		l.stats.Lookup(true)
+		return l.nodes.Value(node).value, true
 	}
+	l.stats.Lookup(false)
 	return nil, false
 }
 
-// Put adds a new key-value pair to the cache.
+// Put adds a new key-value pair to the cache, without tags.
 func (l *LFU) Put(key string, val any) {
+	l.PutWithTags(key, val)
+}
+
+// PutWithTags adds a new key-value pair to the cache, replacing the tags of key with tags.
+func (l *LFU) PutWithTags(key string, val any, tags ...string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	if l.limit == 0 {
 		return
 	}
+	l.put(key, val)
+	l.tags.Set(key, tags)
+}
 
-	if element, ok := l.items[key]; ok {
-		element.value = val
-		l.updateFrequency(element)
+// put adds a new key-value pair to the cache. l.mu must be held.
+func (l *LFU) put(key string, val any) {
+	if node, ok := l.items[key]; ok {
+		l.nodes.Value(node).value = val
+		l.items[key] = l.updateFrequency(node)
 		return
 	}
 	if len(l.items) >= l.limit {
-		list := l.freqToListMap[l.minFreq]
-		firstItemKey := list.Head.Val.(*entry).key
-		list.Remove(list.Head)
-		delete(l.items, firstItemKey)
+		l.evict()
 	}
 
-	dataEntry := &entry{
+	l.items[key] = l.addEntryInFreqList(entry{
 		key:   key,
 		value: val,
 		freq:  1,
-	}
-	l.addEntryInFreqList(dataEntry, dataEntry.freq)
-	l.items[key] = dataEntry
+	})
 	l.minFreq = 1
 }
 
@@ -77,13 +96,39 @@ func (l *LFU) Put(key string, val any) {
 func (l *LFU) Remove(key string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	if element, ok := l.items[key]; ok {
-		list := l.freqToListMap[element.freq]
-		list.Remove(element.node)
+	l.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, and returns the number of entries removed.
+func (l *LFU) InvalidateTag(tag string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := l.tags.Keys(tag)
+	for _, key := range keys {
+		l.remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, in no particular order.
+func (l *LFU) TaggedKeys(tag string) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tags.Keys(tag)
+}
+
+// remove deletes the entry of key and its tags. l.mu must be held.
+func (l *LFU) remove(key string) {
+	if node, ok := l.items[key]; ok {
+		freq := l.nodes.Value(node).freq
+		list := l.freqToListMap[freq]
+		list.Remove(node)
 		if list.Len() == 0 {
-			delete(l.freqToListMap, element.freq)
+			delete(l.freqToListMap, freq)
 		}
 		delete(l.items, key)
+		l.tags.Remove(key)
 	}
 }
 
@@ -98,8 +143,10 @@ func (l *LFU) Len() int {
 func (l *LFU) Clear() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.items = make(map[string]*entry)
-	l.freqToListMap = make(map[int]*linkedlist.LinkedList)
+	l.tags.Clear()
+	l.items = make(map[string]int32)
+	l.freqToListMap = make(map[int]*indexlist.List[entry])
+	l.nodes.Reset()
 	l.minFreq = 1
 }
 
@@ -111,25 +158,132 @@ func (l *LFU) Contains(key string) bool {
 	return ok
 }
 
-// updateFrequency updates the frequency of the given entry
-func (l *LFU) updateFrequency(val *entry) {
-	list := l.freqToListMap[val.freq]
-	list.Remove(val.node)
+// Peek returns the value of key without counting the read as a use of key,
+// by the eviction policy or in the stats.
+func (l *LFU) Peek(key string) (any, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if node, ok := l.items[key]; ok {
+		return l.nodes.Value(node).value, true
+	}
+	return nil, false
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (l *LFU) Stats() stats.Stats {
+	return l.stats.Snapshot()
+}
+
+// Limit returns the maximum number of items in the cache.
+func (l *LFU) Limit() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limit
+}
+
+// Resize sets the maximum number of items in the cache to limit, evicting
+// the least frequently used items until the cache fits. Growing does not
+// reallocate. It panics if limit is not greater than 0.
+func (l *LFU) Resize(limit int) {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = limit
+	for len(l.items) > limit {
+		l.evict()
+	}
+}
+
+// lowestFreq returns the lowest frequency of the items, or 1 if there are none.
+func (l *LFU) lowestFreq() int {
+	lowest := 0
+	for freq := range l.freqToListMap {
+		if lowest == 0 || freq < lowest {
+			lowest = freq
+		}
+	}
+	if lowest == 0 {
+		return 1
+	}
+	return lowest
+}
+
+// Keys returns the keys in the cache in eviction order, least frequently used
+// first and, among keys used as often, least recently used first.
+func (l *LFU) Keys() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	freqs := make([]int, 0, len(l.freqToListMap))
+	for freq := range l.freqToListMap {
+		freqs = append(freqs, freq)
+	}
+	sort.Ints(freqs)
+
+	keys := make([]string, 0, len(l.items))
+	for _, freq := range freqs {
+		list := l.freqToListMap[freq]
+		for node := list.Head(); node != indexlist.Nil; node = list.Next(node) {
+			keys = append(keys, list.Value(node).key)
+		}
+	}
+	return keys
+}
+
+// AddEvictionHook registers fn to be called with every entry evicted to make room for a new one.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (l *LFU) AddEvictionHook(fn func(key string, val any)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onEvict.Add(fn)
+}
+
+// updateFrequency updates the frequency of the entry of the given node,
+// and returns its new node.
+func (l *LFU) updateFrequency(node int32) int32 {
+	freq := l.nodes.Value(node).freq
+	list := l.freqToListMap[freq]
+	val := list.Remove(node)
 	if list.Len() == 0 {
-		delete(l.freqToListMap, val.freq)
-		if l.minFreq == val.freq {
+		delete(l.freqToListMap, freq)
+		if l.minFreq == freq {
 			l.minFreq++
 		}
 	}
 	val.freq++
-	l.addEntryInFreqList(val, val.freq)
+	return l.addEntryInFreqList(val)
 }
 
-// addEntryInFreqList updates the list for the given frequency
-func (l *LFU) addEntryInFreqList(val *entry, freq int) {
-	if _, ok := l.freqToListMap[freq]; !ok {
-		l.freqToListMap[freq] = linkedlist.New()
+// addEntryInFreqList appends val to the list of its frequency, and returns its node.
+func (l *LFU) addEntryInFreqList(val entry) int32 {
+	list, ok := l.freqToListMap[val.freq]
+	if !ok {
+		list = l.nodes.NewList()
+		l.freqToListMap[val.freq] = list
+	}
+	return list.PushBack(val)
+}
+
+// evict removes the least recently used of the least frequently used items.
+// l.mu must be held.
+func (l *LFU) evict() {
+	list, ok := l.freqToListMap[l.minFreq]
+	if !ok {
+		// minFreq is left stale when remove or an earlier evict emptied its
+		// list without putting an item used once.
+		l.minFreq = l.lowestFreq()
+		list = l.freqToListMap[l.minFreq]
+	}
+	evicted := list.Remove(list.Head())
+	if list.Len() == 0 {
+		delete(l.freqToListMap, l.minFreq)
 	}
-	node := l.freqToListMap[freq].PushBack(val)
-	val.node = node
+	delete(l.items, evicted.key)
+	l.tags.Remove(evicted.key)
+	l.stats.Evict()
+	l.onEvict.Call(evicted.key, evicted.value)
 }
@@ -4,15 +4,21 @@ import (
 	"log"
 	"sync"
 
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+	"github.com/raghavgh/gofast/internal/cache/tags"
 	"github.com/raghavgh/gofast/internal/ds/stack"
 )
 
 // Lifo represents a lifo cache.
 type Lifo struct {
-	items map[string]*entry
-	stack *stack.Stack
-	limit int
-	mu    *sync.RWMutex
+	items   map[string]*entry
+	stack   *stack.Stack
+	limit   int
+	mu      *sync.RWMutex
+	onEvict hooks.Evict
+	tags    tags.Index
+	stats   *stats.Counter
 }
 
 // entry is used to hold a value in the eviction list.
@@ -31,6 +37,7 @@ func NewLifo(limit int) *Lifo {
 		stack: stack.NewStack(true),
 		limit: limit,
 		mu:    &sync.RWMutex{},
+		stats: &stats.Counter{},
 	}
 }
 
@@ -40,24 +47,34 @@ func (l *Lifo) Get(key string) (any, bool) {
 	defer l.mu.RUnlock()
 
 	if element, ok := l.items[key]; ok {
+		l.stats.Lookup(true)
 		return element.value, true
 	}
+	l.stats.Lookup(false)
 	return nil, false
 }
 
-// Put adds a new key-value pair to the cache.
+// Put adds a new key-value pair to the cache, without tags.
 func (l *Lifo) Put(key string, val any) {
+	l.PutWithTags(key, val)
+}
+
+// PutWithTags adds a new key-value pair to the cache, replacing the tags of key with tags.
+func (l *Lifo) PutWithTags(key string, val any, tags ...string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.put(key, val)
+	l.tags.Set(key, tags)
+}
 
+// put adds a new key-value pair to the cache. l.mu must be held.
+func (l *Lifo) put(key string, val any) {
 	if element, ok := l.items[key]; ok {
 		element.value = val
 		return
 	}
 	if l.limit <= l.stack.Size() {
-		element := l.stack.Top()
-		l.stack.Pop()
-		delete(l.items, element.(*entry).key)
+		l.evict()
 	}
 	entryVal := &entry{
 		key:   key,
@@ -71,10 +88,34 @@ func (l *Lifo) Put(key string, val any) {
 func (l *Lifo) Remove(key string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, and returns the number of entries removed.
+func (l *Lifo) InvalidateTag(tag string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
+	keys := l.tags.Keys(tag)
+	for _, key := range keys {
+		l.remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, in no particular order.
+func (l *Lifo) TaggedKeys(tag string) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tags.Keys(tag)
+}
+
+// remove deletes the entry of key and its tags. l.mu must be held.
+func (l *Lifo) remove(key string) {
 	if element, ok := l.items[key]; ok {
 		l.stack.Remove(element)
 		delete(l.items, key)
+		l.tags.Remove(key)
 	}
 }
 
@@ -87,6 +128,7 @@ func (l *Lifo) Len() int {
 func (l *Lifo) Clear() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.tags.Clear()
 
 	l.stack.Clear()
 	l.items = make(map[string]*entry)
@@ -100,3 +142,73 @@ func (l *Lifo) Contains(key string) bool {
 	_, ok := l.items[key]
 	return ok
 }
+
+// Peek returns the value of key without counting the read as a use of key,
+// by the eviction policy or in the stats.
+func (l *Lifo) Peek(key string) (any, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if element, ok := l.items[key]; ok {
+		return element.value, true
+	}
+	return nil, false
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (l *Lifo) Stats() stats.Stats {
+	return l.stats.Snapshot()
+}
+
+// Limit returns the maximum number of items in the cache.
+func (l *Lifo) Limit() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limit
+}
+
+// Resize sets the maximum number of items in the cache to limit, evicting
+// the newest items until the cache fits. Growing does not reallocate.
+// It panics if limit is not greater than 0.
+func (l *Lifo) Resize(limit int) {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = limit
+	for l.stack.Size() > limit {
+		l.evict()
+	}
+}
+
+// Keys returns the keys in the cache in eviction order, newest first.
+func (l *Lifo) Keys() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	keys := make([]string, 0, len(l.items))
+	for node := l.stack.Head; node != nil; node = node.Next {
+		keys = append(keys, node.Val.(*entry).key)
+	}
+	return keys
+}
+
+// AddEvictionHook registers fn to be called with every entry evicted to make room for a new one.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (l *Lifo) AddEvictionHook(fn func(key string, val any)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onEvict.Add(fn)
+}
+
+// evict removes the newest item. l.mu must be held.
+func (l *Lifo) evict() {
+	element := l.stack.Top().(*entry)
+	l.stack.Pop()
+	delete(l.items, element.key)
+	l.tags.Remove(element.key)
+	l.stats.Evict()
+	l.onEvict.Call(element.key, element.value)
+}
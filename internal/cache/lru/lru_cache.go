@@ -3,15 +3,21 @@ package lru
 import (
 	"sync"
 
-	"github.com/raghavgh/gofast/internal/ds/linkedlist"
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+	"github.com/raghavgh/gofast/internal/cache/tags"
+	"github.com/raghavgh/gofast/internal/ds/indexlist"
 )
 
 // LRU represents a thread-safe, least recently used cache.
 type LRU struct {
-	items    map[string]*linkedlist.Node
-	eviction *linkedlist.LinkedList
+	items    map[string]int32
+	eviction *indexlist.List[entry]
 	limit    int
 	mu       *sync.RWMutex
+	onEvict  hooks.Evict
+	tags     tags.Index
+	stats    *stats.Counter
 }
 
 // entry is used to hold a value in the eviction list.
@@ -29,37 +35,72 @@ func (l *LRU) Get(key string) (any, bool) {
 
 	if node, ok := l.items[key]; ok {
 		l.eviction.MoveToFront(node)
-		return node.Val.(*entry).value, true
+		l.stats.Lookup(true)
+		return l.eviction.Value(node).value, true
 	}
+	l.stats.Lookup(false)
 	return nil, false
 }
 
-// Put adds a new key-value pair to the cache.
+// Put adds a new key-value pair to the cache, without tags.
 func (l *LRU) Put(key string, val any) {
+	l.PutWithTags(key, val)
+}
+
+// PutWithTags adds a new key-value pair to the cache, replacing the tags of key with tags.
+func (l *LRU) PutWithTags(key string, val any, tags ...string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.put(key, val)
+	l.tags.Set(key, tags)
+}
+
+// put adds a new key-value pair to the cache. l.mu must be held.
+func (l *LRU) put(key string, val any) {
 	// handling the case of existing key update
 	if element, ok := l.items[key]; ok {
 		l.eviction.MoveToFront(element)
-		element.Val.(*entry).value = val
+		l.eviction.Value(element).value = val
 		return
 	}
 
 	if l.eviction.Len() >= l.limit {
-		delete(l.items, l.eviction.Tail.Val.(*entry).key)
-		l.eviction.Remove(l.eviction.Tail)
+		l.evict()
 	}
-	l.eviction.PushFront(&entry{key: key, value: val})
-	l.items[key] = l.eviction.Head
+	l.items[key] = l.eviction.PushFront(entry{key: key, value: val})
 }
 
 // Remove deletes a specific key-value pair from the cache.
 func (l *LRU) Remove(key string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, and returns the number of entries removed.
+func (l *LRU) InvalidateTag(tag string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := l.tags.Keys(tag)
+	for _, key := range keys {
+		l.remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, in no particular order.
+func (l *LRU) TaggedKeys(tag string) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tags.Keys(tag)
+}
 
+// remove deletes the entry of key and its tags. l.mu must be held.
+func (l *LRU) remove(key string) {
 	if node, ok := l.items[key]; ok {
 		delete(l.items, key)
+		l.tags.Remove(key)
 		l.eviction.Remove(node)
 	}
 }
@@ -75,11 +116,12 @@ func (l *LRU) Len() int {
 func (l *LRU) Clear() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.tags.Clear()
 
-	// Reset the eviction list and the map.
+	// Reset the map, and the eviction list keeping its nodes for reuse.
 	// The old items will be garbage collected.
-	l.items = make(map[string]*linkedlist.Node)
-	l.eviction = linkedlist.New()
+	l.items = make(map[string]int32)
+	l.eviction.Reset()
 }
 
 // Contains checks if a key is present in the cache.
@@ -90,12 +132,82 @@ func (l *LRU) Contains(key string) bool {
 	return ok
 }
 
+// Peek returns the value of key without counting the read as a use of key,
+// by the eviction policy or in the stats.
+func (l *LRU) Peek(key string) (any, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if node, ok := l.items[key]; ok {
+		return l.eviction.Value(node).value, true
+	}
+	return nil, false
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (l *LRU) Stats() stats.Stats {
+	return l.stats.Snapshot()
+}
+
+// Limit returns the maximum number of items in the cache.
+func (l *LRU) Limit() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limit
+}
+
+// Resize sets the maximum number of items in the cache to limit, evicting
+// the least recently used items until the cache fits. Growing does not reallocate.
+// It panics if limit is not greater than 0.
+func (l *LRU) Resize(limit int) {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = limit
+	for l.eviction.Len() > limit {
+		l.evict()
+	}
+}
+
+// Keys returns the keys in the cache in eviction order, least recently used first.
+func (l *LRU) Keys() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	keys := make([]string, 0, l.eviction.Len())
+	for node := l.eviction.Tail(); node != indexlist.Nil; node = l.eviction.Prev(node) {
+		keys = append(keys, l.eviction.Value(node).key)
+	}
+	return keys
+}
+
+// AddEvictionHook registers fn to be called with every entry evicted to make room for a new one.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (l *LRU) AddEvictionHook(fn func(key string, val any)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onEvict.Add(fn)
+}
+
 // NewLRU creates a new LRU cache with the maximum size based on configuration.
 func NewLRU(limit int) *LRU {
 	return &LRU{
-		items:    make(map[string]*linkedlist.Node, limit),
-		eviction: linkedlist.New(),
+		items:    make(map[string]int32, limit),
+		eviction: indexlist.New[entry](limit),
 		limit:    limit,
 		mu:       &sync.RWMutex{},
+		stats:    &stats.Counter{},
 	}
 }
+
+// evict removes the least recently used item. l.mu must be held.
+func (l *LRU) evict() {
+	evicted := l.eviction.Remove(l.eviction.Tail())
+	delete(l.items, evicted.key)
+	l.tags.Remove(evicted.key)
+	l.stats.Evict()
+	l.onEvict.Call(evicted.key, evicted.value)
+}
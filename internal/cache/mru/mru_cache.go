@@ -3,7 +3,10 @@ package mru
 import (
 	"sync"
 
-	"github.com/raghavgh/gofast/internal/ds/linkedlist"
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+	"github.com/raghavgh/gofast/internal/cache/tags"
+	"github.com/raghavgh/gofast/internal/ds/indexlist"
 )
 
 /*
@@ -13,10 +16,13 @@ When a item get updated, it will move to the first place of the cache
 When cache reach it's limit, it will remove the first item
 */
 type MRU struct {
-	items    map[string]*linkedlist.Node
-	eviction *linkedlist.LinkedList
+	items    map[string]int32
+	eviction *indexlist.List[entry]
 	limit    int
 	mu       *sync.RWMutex
+	onEvict  hooks.Evict
+	tags     tags.Index
+	stats    *stats.Counter
 }
 
 // entry is used to hold a value in the eviction list.
@@ -35,40 +41,74 @@ func (m *MRU) Get(key string) (any, bool) {
 
 	if node, ok := m.items[key]; ok {
 		m.eviction.MoveToFront(node)
-		return node.Val.(*entry).value, true
+		m.stats.Lookup(true)
+		return m.eviction.Value(node).value, true
 	}
 
+	m.stats.Lookup(false)
 	return nil, false
 }
 
-// Put add or update the key-value pair to the cache.
-// And move the item to front
+// Put adds a new key-value pair to the cache, without tags.
 func (m *MRU) Put(key string, val any) {
+	m.PutWithTags(key, val)
+}
+
+// PutWithTags adds a new key-value pair to the cache, replacing the tags of key with tags.
+func (m *MRU) PutWithTags(key string, val any, tags ...string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.put(key, val)
+	m.tags.Set(key, tags)
+}
 
+// put add or update the key-value pair to the cache.
+// And move the item to front m.mu must be held.
+func (m *MRU) put(key string, val any) {
 	if element, ok := m.items[key]; ok {
 		m.eviction.MoveToFront(element)
-		element.Val.(*entry).value = val
+		m.eviction.Value(element).value = val
 		return
 	}
 
 	if m.eviction.Len() >= m.limit {
-		delete(m.items, m.eviction.Head.Val.(*entry).key)
-		m.eviction.Remove(m.eviction.Head)
+		m.evict()
 	}
 
-	m.eviction.PushFront(&entry{key: key, value: val})
-	m.items[key] = m.eviction.Head
+	m.items[key] = m.eviction.PushFront(entry{key: key, value: val})
 }
 
 // Remove deletes a specific key-value pair from the cache.
 func (m *MRU) Remove(key string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, and returns the number of entries removed.
+func (m *MRU) InvalidateTag(tag string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
+	keys := m.tags.Keys(tag)
+	for _, key := range keys {
+		m.remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, in no particular order.
+func (m *MRU) TaggedKeys(tag string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tags.Keys(tag)
+}
+
+// remove deletes the entry of key and its tags. m.mu must be held.
+func (m *MRU) remove(key string) {
 	if node, ok := m.items[key]; ok {
 		delete(m.items, key)
+		m.tags.Remove(key)
 		m.eviction.Remove(node)
 	}
 }
@@ -85,9 +125,10 @@ func (m *MRU) Len() int {
 func (m *MRU) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.tags.Clear()
 
-	m.items = make(map[string]*linkedlist.Node)
-	m.eviction = linkedlist.New()
+	m.items = make(map[string]int32)
+	m.eviction.Reset()
 }
 
 // Contains checks if a key is present in the cache.
@@ -99,6 +140,66 @@ func (m *MRU) Contains(key string) bool {
 	return ok
 }
 
+// Peek returns the value of key without counting the read as a use of key,
+// by the eviction policy or in the stats.
+func (m *MRU) Peek(key string) (any, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if node, ok := m.items[key]; ok {
+		return m.eviction.Value(node).value, true
+	}
+	return nil, false
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (m *MRU) Stats() stats.Stats {
+	return m.stats.Snapshot()
+}
+
+// Limit returns the maximum number of items in the cache.
+func (m *MRU) Limit() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.limit
+}
+
+// Resize sets the maximum number of items in the cache to limit, evicting
+// the most recently used items until the cache fits. Growing does not reallocate.
+// It panics if limit is not greater than 0.
+func (m *MRU) Resize(limit int) {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.limit = limit
+	for m.eviction.Len() > limit {
+		m.evict()
+	}
+}
+
+// Keys returns the keys in the cache in eviction order, most recently used first.
+func (m *MRU) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]string, 0, m.eviction.Len())
+	for node := m.eviction.Head(); node != indexlist.Nil; node = m.eviction.Next(node) {
+		keys = append(keys, m.eviction.Value(node).key)
+	}
+	return keys
+}
+
+// AddEvictionHook registers fn to be called with every entry evicted to make room for a new one.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (m *MRU) AddEvictionHook(fn func(key string, val any)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onEvict.Add(fn)
+}
+
 // NewMRU creates a new MRU cache with the maximum size based on configuration.
 func NewMRU(limit int) *MRU {
 	if limit <= 0 {
@@ -106,9 +207,19 @@ func NewMRU(limit int) *MRU {
 	}
 
 	return &MRU{
-		items:    make(map[string]*linkedlist.Node, limit),
-		eviction: linkedlist.New(),
+		items:    make(map[string]int32, limit),
+		eviction: indexlist.New[entry](limit),
 		limit:    limit,
 		mu:       &sync.RWMutex{},
+		stats:    &stats.Counter{},
 	}
 }
+
+// evict removes the most recently used item. m.mu must be held.
+func (m *MRU) evict() {
+	evicted := m.eviction.Remove(m.eviction.Head())
+	delete(m.items, evicted.key)
+	m.tags.Remove(evicted.key)
+	m.stats.Evict()
+	m.onEvict.Call(evicted.key, evicted.value)
+}
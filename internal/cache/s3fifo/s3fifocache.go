@@ -0,0 +1,345 @@
+package s3fifo
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+	"github.com/raghavgh/gofast/internal/cache/tags"
+	"github.com/raghavgh/gofast/internal/ds/queue"
+)
+
+// maxFreq is the saturation value of the 2-bit access counter kept per entry.
+const maxFreq = 3
+
+/*
+S3FIFO represents a thread-safe S3-FIFO cache.
+It keeps three FIFO queues:
+  - small: new entries land here and are filtered out if they are not reused quickly.
+  - main: entries that were accessed while in small (or that come back from ghost).
+  - ghost: keys (without values) recently evicted from small, used to detect reuse.
+
+Hits only bump a 2-bit counter on the entry, so Get never reorders a queue and
+can run under a read lock.
+*/
+type S3FIFO struct {
+	items      map[string]*entry
+	small      *queue.List
+	main       *queue.List
+	ghost      *queue.List
+	ghostKeys  map[string]struct{}
+	limit      int
+	smallLimit int
+	ghostLimit int
+	mu         *sync.RWMutex
+	onEvict    hooks.Evict
+	tags       tags.Index
+	stats      *stats.Counter
+}
+
+// entry is used to hold a value in the small and main queues.
+type entry struct {
+	key    string
+	value  any
+	freq   int32
+	inMain bool
+}
+
+// NewS3FIFO returns a new S3-FIFO cache with the given limit.
+// The small queue gets 10% of the limit, the main queue the rest
+// and the ghost queue remembers as many keys as the main queue can hold.
+func NewS3FIFO(limit int) *S3FIFO {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+
+	smallLimit, ghostLimit := queueLimits(limit)
+	return &S3FIFO{
+		items:      make(map[string]*entry, limit),
+		small:      queue.NewQueueList(true),
+		main:       queue.NewQueueList(true),
+		ghost:      queue.NewQueueList(true),
+		ghostKeys:  make(map[string]struct{}),
+		limit:      limit,
+		smallLimit: smallLimit,
+		ghostLimit: ghostLimit,
+		mu:         &sync.RWMutex{},
+		stats:      &stats.Counter{},
+	}
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (s *S3FIFO) Get(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if element, ok := s.items[key]; ok {
+		element.touch()
+		s.stats.Lookup(true)
+		return element.value, true
+	}
+	s.stats.Lookup(false)
+	return nil, false
+}
+
+// Put adds a new key-value pair to the cache, without tags.
+func (s *S3FIFO) Put(key string, val any) {
+	s.PutWithTags(key, val)
+}
+
+// PutWithTags adds a new key-value pair to the cache, replacing the tags of key with tags.
+func (s *S3FIFO) PutWithTags(key string, val any, tags ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(key, val)
+	s.tags.Set(key, tags)
+}
+
+// put adds a new key-value pair to the cache. s.mu must be held.
+// Keys found in the ghost queue are admitted straight into the main queue.
+func (s *S3FIFO) put(key string, val any) {
+	if element, ok := s.items[key]; ok {
+		element.value = val
+		element.touch()
+		return
+	}
+
+	for len(s.items) >= s.limit {
+		s.evict()
+	}
+
+	entryVal := &entry{key: key, value: val}
+	if _, ok := s.ghostKeys[key]; ok {
+		s.ghost.Remove(key)
+		delete(s.ghostKeys, key)
+		entryVal.inMain = true
+		s.main.Push(entryVal)
+	} else {
+		s.small.Push(entryVal)
+	}
+	s.items[key] = entryVal
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (s *S3FIFO) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, and returns the number of entries removed.
+func (s *S3FIFO) InvalidateTag(tag string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := s.tags.Keys(tag)
+	for _, key := range keys {
+		s.remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, in no particular order.
+func (s *S3FIFO) TaggedKeys(tag string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tags.Keys(tag)
+}
+
+// remove deletes the entry of key and its tags. s.mu must be held.
+func (s *S3FIFO) remove(key string) {
+	if element, ok := s.items[key]; ok {
+		delete(s.items, key)
+		s.tags.Remove(key)
+		if element.inMain {
+			s.main.Remove(element)
+		} else {
+			s.small.Remove(element)
+		}
+	}
+}
+
+// Len returns the number of items in the cache.
+func (s *S3FIFO) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items)
+}
+
+// Clear removes all items from the cache, including the ghost history.
+func (s *S3FIFO) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags.Clear()
+
+	s.items = make(map[string]*entry, s.limit)
+	s.small = queue.NewQueueList(true)
+	s.main = queue.NewQueueList(true)
+	s.ghost = queue.NewQueueList(true)
+	s.ghostKeys = make(map[string]struct{})
+}
+
+// Contains returns true if the cache contains the given key.
+func (s *S3FIFO) Contains(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.items[key]
+	return ok
+}
+
+// Peek returns the value of key without counting the read as a use of key,
+// by the eviction policy or in the stats.
+func (s *S3FIFO) Peek(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if element, ok := s.items[key]; ok {
+		return element.value, true
+	}
+	return nil, false
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (s *S3FIFO) Stats() stats.Stats {
+	return s.stats.Snapshot()
+}
+
+// Limit returns the maximum number of items in the cache.
+func (s *S3FIFO) Limit() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.limit
+}
+
+// Resize sets the maximum number of items in the cache to limit, and the
+// limits of its queues in proportion, evicting items as the queues are
+// scanned until the cache fits. Growing does not reallocate. It panics if
+// limit is not greater than 0.
+func (s *S3FIFO) Resize(limit int) {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limit = limit
+	s.smallLimit, s.ghostLimit = queueLimits(limit)
+	for len(s.items) > limit {
+		s.evict()
+	}
+	for s.ghost.Len() > s.ghostLimit {
+		delete(s.ghostKeys, s.ghost.Front().(string))
+		s.ghost.Pop()
+	}
+}
+
+// Keys returns the keys in the cache in the order the queues are scanned for
+// eviction: the small queue, then the main queue, oldest first. Entries that
+// were accessed get a second chance when they are reached, so the actual
+// eviction order may differ.
+func (s *S3FIFO) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.items))
+	for _, q := range []*queue.List{s.small, s.main} {
+		for node := q.Head; node != nil; node = node.Next {
+			keys = append(keys, node.Val.(*entry).key)
+		}
+	}
+	return keys
+}
+
+// AddEvictionHook registers fn to be called with every entry evicted to make room for a new one.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (s *S3FIFO) AddEvictionHook(fn func(key string, val any)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onEvict.Add(fn)
+}
+
+// evict makes one eviction step. It evicts from the small queue while the
+// small queue is over its share, and from the main queue otherwise.
+// A step may only move an entry from small to main, so callers loop until
+// there is room.
+func (s *S3FIFO) evict() {
+	if s.small.Len() >= s.smallLimit || s.main.Empty() {
+		s.evictSmall()
+		return
+	}
+	s.evictMain()
+}
+
+// evictSmall pops the oldest entry of the small queue. Entries that were hit
+// while in small are promoted to main, the rest are evicted and their key is
+// remembered in the ghost queue.
+func (s *S3FIFO) evictSmall() {
+	element := s.small.Front().(*entry)
+	s.small.Pop()
+
+	if atomic.LoadInt32(&element.freq) > 0 {
+		atomic.StoreInt32(&element.freq, 0)
+		element.inMain = true
+		s.main.Push(element)
+		return
+	}
+
+	delete(s.items, element.key)
+	s.tags.Remove(element.key)
+	s.stats.Evict()
+	s.onEvict.Call(element.key, element.value)
+	if s.ghost.Len() >= s.ghostLimit {
+		delete(s.ghostKeys, s.ghost.Front().(string))
+		s.ghost.Pop()
+	}
+	s.ghost.Push(element.key)
+	s.ghostKeys[element.key] = struct{}{}
+}
+
+// evictMain pops the oldest entry of the main queue. Entries with a non-zero
+// counter get reinserted with the counter decremented, the first one found
+// with a zero counter is evicted.
+func (s *S3FIFO) evictMain() {
+	for {
+		element := s.main.Front().(*entry)
+		s.main.Pop()
+
+		if freq := atomic.LoadInt32(&element.freq); freq > 0 {
+			atomic.StoreInt32(&element.freq, freq-1)
+			s.main.Push(element)
+			continue
+		}
+
+		delete(s.items, element.key)
+		s.tags.Remove(element.key)
+		s.stats.Evict()
+		s.onEvict.Call(element.key, element.value)
+		return
+	}
+}
+
+// queueLimits returns the limits of the small and ghost queues of a cache
+// holding limit items.
+func queueLimits(limit int) (small, ghost int) {
+	small = limit / 10
+	if small == 0 {
+		small = 1
+	}
+	ghost = limit - small
+	if ghost == 0 {
+		ghost = 1
+	}
+	return small, ghost
+}
+
+// touch increments the access counter of the entry, saturating at maxFreq.
+// It is safe to call concurrently under the cache's read lock.
+func (e *entry) touch() {
+	for {
+		freq := atomic.LoadInt32(&e.freq)
+		if freq >= maxFreq || atomic.CompareAndSwapInt32(&e.freq, freq, freq+1) {
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package s3fifo
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS3FIFO(t *testing.T) {
+	t.Run("put and get", func(t *testing.T) {
+		s := NewS3FIFO(10)
+		s.Put("1", 1)
+
+		val, ok := s.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+		assert.Equal(t, 1, s.Len())
+		assert.True(t, s.Contains("1"))
+	})
+
+	t.Run("update existing key", func(t *testing.T) {
+		s := NewS3FIFO(10)
+		s.Put("1", 1)
+		s.Put("1", 2)
+
+		val, ok := s.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, 2, val)
+		assert.Equal(t, 1, s.Len())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		s := NewS3FIFO(10)
+		s.Put("1", 1)
+		s.Remove("1")
+
+		_, ok := s.Get("1")
+		assert.False(t, ok)
+		assert.Equal(t, 0, s.Len())
+		s.Remove("1")
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		s := NewS3FIFO(10)
+		for i := 0; i < 20; i++ {
+			s.Put(strconv.Itoa(i), i)
+		}
+		s.Clear()
+
+		assert.Equal(t, 0, s.Len())
+		assert.Equal(t, 0, s.ghost.Len())
+		assert.False(t, s.Contains("19"))
+	})
+
+	t.Run("limit is respected", func(t *testing.T) {
+		s := NewS3FIFO(10)
+		for i := 0; i < 100; i++ {
+			s.Put(strconv.Itoa(i), i)
+			assert.LessOrEqual(t, s.Len(), 10)
+		}
+		assert.Equal(t, 10, s.Len())
+		assert.Equal(t, s.Len(), s.small.Len()+s.main.Len())
+	})
+
+	t.Run("one-hit wonders are evicted from small first", func(t *testing.T) {
+		s := NewS3FIFO(10)
+		for i := 0; i < 10; i++ {
+			s.Put(strconv.Itoa(i), i)
+		}
+		// "0" was hit, so it is promoted to main instead of being evicted.
+		s.Get("0")
+		s.Put("10", 10)
+
+		assert.True(t, s.Contains("0"))
+		assert.False(t, s.Contains("1"))
+		assert.True(t, s.items["0"].inMain)
+	})
+
+	t.Run("ghost hit is admitted to main", func(t *testing.T) {
+		s := NewS3FIFO(10)
+		for i := 0; i < 11; i++ {
+			s.Put(strconv.Itoa(i), i)
+		}
+		assert.False(t, s.Contains("0"))
+		_, inGhost := s.ghostKeys["0"]
+		assert.True(t, inGhost)
+
+		s.Put("0", 0)
+		assert.True(t, s.items["0"].inMain)
+		_, inGhost = s.ghostKeys["0"]
+		assert.False(t, inGhost)
+	})
+
+	t.Run("frequency saturates", func(t *testing.T) {
+		s := NewS3FIFO(10)
+		s.Put("1", 1)
+		for i := 0; i < 10; i++ {
+			s.Get("1")
+		}
+		assert.Equal(t, int32(maxFreq), s.items["1"].freq)
+	})
+
+	t.Run("limit 1", func(t *testing.T) {
+		s := NewS3FIFO(1)
+		s.Put("1", 1)
+		s.Get("1")
+		s.Put("2", 2)
+		s.Put("3", 3)
+
+		assert.Equal(t, 1, s.Len())
+		assert.True(t, s.Contains("3"))
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		assert.Panics(t, func() { NewS3FIFO(0) })
+	})
+}
+
+func TestS3FIFO_Concurrent(t *testing.T) {
+	s := NewS3FIFO(100)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := strconv.Itoa((i * j) % 300)
+				s.Put(key, j)
+				s.Get(key)
+				if j%7 == 0 {
+					s.Remove(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, s.Len(), 100)
+}
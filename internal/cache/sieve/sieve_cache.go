@@ -0,0 +1,258 @@
+package sieve
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/raghavgh/gofast/internal/cache/hooks"
+	"github.com/raghavgh/gofast/internal/cache/stats"
+	"github.com/raghavgh/gofast/internal/cache/tags"
+	"github.com/raghavgh/gofast/internal/ds/linkedlist"
+)
+
+/*
+Sieve represents a thread-safe SIEVE cache.
+New items are pushed at the head of a single FIFO queue and a hit only sets
+the visited bit of the item, so Get never reorders the queue.
+On eviction a hand walks from the tail toward the head, clearing visited bits
+until it finds an unvisited item, which is evicted. The hand remembers where
+it stopped for the next eviction.
+*/
+type Sieve struct {
+	items    map[string]*linkedlist.Node
+	eviction *linkedlist.LinkedList
+	hand     *linkedlist.Node
+	limit    int
+	mu       *sync.RWMutex
+	onEvict  hooks.Evict
+	tags     tags.Index
+	stats    *stats.Counter
+}
+
+// entry is used to hold a value in the eviction list.
+// we are keeping entry as value to make sure that we can access key in O(1) time
+// when we want to remove an entry from the map.
+type entry struct {
+	key     string
+	value   any
+	visited int32
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (s *Sieve) Get(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if node, ok := s.items[key]; ok {
+		element := node.Val.(*entry)
+		atomic.StoreInt32(&element.visited, 1)
+		s.stats.Lookup(true)
+		return element.value, true
+	}
+	s.stats.Lookup(false)
+	return nil, false
+}
+
+// Put adds a new key-value pair to the cache, without tags.
+func (s *Sieve) Put(key string, val any) {
+	s.PutWithTags(key, val)
+}
+
+// PutWithTags adds a new key-value pair to the cache, replacing the tags of key with tags.
+func (s *Sieve) PutWithTags(key string, val any, tags ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(key, val)
+	s.tags.Set(key, tags)
+}
+
+// put adds a new key-value pair to the cache. s.mu must be held.
+func (s *Sieve) put(key string, val any) {
+	if node, ok := s.items[key]; ok {
+		element := node.Val.(*entry)
+		element.value = val
+		atomic.StoreInt32(&element.visited, 1)
+		return
+	}
+
+	if s.eviction.Len() >= s.limit {
+		s.evict()
+	}
+	s.items[key] = s.eviction.PushFront(&entry{key: key, value: val})
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (s *Sieve) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, and returns the number of entries removed.
+func (s *Sieve) InvalidateTag(tag string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := s.tags.Keys(tag)
+	for _, key := range keys {
+		s.remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, in no particular order.
+func (s *Sieve) TaggedKeys(tag string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tags.Keys(tag)
+}
+
+// remove deletes the entry of key and its tags. s.mu must be held.
+func (s *Sieve) remove(key string) {
+	if node, ok := s.items[key]; ok {
+		s.removeNode(node)
+	}
+}
+
+// Len returns the number of items in the cache.
+func (s *Sieve) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.eviction.Len()
+}
+
+// Clear removes all items from the cache.
+func (s *Sieve) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags.Clear()
+
+	s.items = make(map[string]*linkedlist.Node)
+	s.eviction = linkedlist.New()
+	s.hand = nil
+}
+
+// Contains checks if a key is present in the cache.
+func (s *Sieve) Contains(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.items[key]
+	return ok
+}
+
+// Peek returns the value of key without counting the read as a use of key,
+// by the eviction policy or in the stats.
+func (s *Sieve) Peek(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if node, ok := s.items[key]; ok {
+		return node.Val.(*entry).value, true
+	}
+	return nil, false
+}
+
+// Stats returns the hit, miss and eviction counters of the cache.
+func (s *Sieve) Stats() stats.Stats {
+	return s.stats.Snapshot()
+}
+
+// Limit returns the maximum number of items in the cache.
+func (s *Sieve) Limit() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.limit
+}
+
+// Resize sets the maximum number of items in the cache to limit, evicting
+// items as the hand finds them until the cache fits. Growing does not
+// reallocate. It panics if limit is not greater than 0.
+func (s *Sieve) Resize(limit int) {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limit = limit
+	for s.eviction.Len() > limit {
+		s.evict()
+	}
+}
+
+// Keys returns the keys in the cache in the order the hand visits them for
+// eviction, starting at the hand. Visited items get a second chance when the
+// hand reaches them, so the actual eviction order may differ.
+func (s *Sieve) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.items))
+	start := s.hand
+	if start == nil {
+		start = s.eviction.Tail
+	}
+	for node := start; node != nil; node = node.Prev {
+		keys = append(keys, node.Val.(*entry).key)
+	}
+	// wrap around to the tail once the hand passes the head.
+	for node := s.eviction.Tail; node != start && node != nil; node = node.Prev {
+		keys = append(keys, node.Val.(*entry).key)
+	}
+	return keys
+}
+
+// AddEvictionHook registers fn to be called with every entry evicted to make room for a new one.
+// fn is called with the cache's lock held, so it must not call back into the cache.
+func (s *Sieve) AddEvictionHook(fn func(key string, val any)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onEvict.Add(fn)
+}
+
+// evict moves the hand toward the head, giving visited items a second chance,
+// and evicts the first unvisited item it finds.
+func (s *Sieve) evict() {
+	node := s.hand
+	if node == nil {
+		node = s.eviction.Tail
+	}
+	for atomic.LoadInt32(&node.Val.(*entry).visited) == 1 {
+		atomic.StoreInt32(&node.Val.(*entry).visited, 0)
+		node = node.Prev
+		// wrap around to the tail once the hand passes the head.
+		if node == nil {
+			node = s.eviction.Tail
+		}
+	}
+	s.hand = node
+	evicted := node.Val.(*entry)
+	s.removeNode(node)
+	s.stats.Evict()
+	s.onEvict.Call(evicted.key, evicted.value)
+}
+
+// removeNode unlinks node from the cache, moving the hand off it if needed.
+func (s *Sieve) removeNode(node *linkedlist.Node) {
+	if node == s.hand {
+		s.hand = node.Prev
+	}
+	delete(s.items, node.Val.(*entry).key)
+	s.tags.Remove(node.Val.(*entry).key)
+	s.eviction.Remove(node)
+}
+
+// NewSieve creates a new SIEVE cache with the maximum size based on configuration.
+func NewSieve(limit int) *Sieve {
+	if limit <= 0 {
+		panic("cache limit must be greater than 0")
+	}
+
+	return &Sieve{
+		items:    make(map[string]*linkedlist.Node, limit),
+		eviction: linkedlist.New(),
+		limit:    limit,
+		mu:       &sync.RWMutex{},
+		stats:    &stats.Counter{},
+	}
+}
@@ -0,0 +1,185 @@
+package sieve
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/raghavgh/gofast/internal/cache/fifo"
+	"github.com/raghavgh/gofast/internal/cache/lru"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSieve(t *testing.T) {
+	t.Run("put and get", func(t *testing.T) {
+		s := NewSieve(10)
+		s.Put("1", 1)
+
+		val, ok := s.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+		assert.Equal(t, 1, s.Len())
+		assert.True(t, s.Contains("1"))
+	})
+
+	t.Run("update existing key", func(t *testing.T) {
+		s := NewSieve(10)
+		s.Put("1", 1)
+		s.Put("1", 2)
+
+		val, ok := s.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, 2, val)
+		assert.Equal(t, 1, s.Len())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		s := NewSieve(10)
+		s.Put("1", 1)
+		s.Remove("1")
+
+		_, ok := s.Get("1")
+		assert.False(t, ok)
+		assert.Equal(t, 0, s.Len())
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		s := NewSieve(3)
+		for i := 0; i < 5; i++ {
+			s.Put(strconv.Itoa(i), i)
+		}
+		s.Clear()
+
+		assert.Equal(t, 0, s.Len())
+		assert.Nil(t, s.hand)
+	})
+
+	t.Run("evicts oldest unvisited item", func(t *testing.T) {
+		s := NewSieve(3)
+		s.Put("1", 1)
+		s.Put("2", 2)
+		s.Put("3", 3)
+		s.Get("1")
+
+		// "1" was visited, so the hand skips it and evicts "2".
+		s.Put("4", 4)
+		assert.True(t, s.Contains("1"))
+		assert.False(t, s.Contains("2"))
+		assert.Equal(t, 3, s.Len())
+
+		// the hand continues from where it stopped instead of restarting at the tail.
+		s.Put("5", 5)
+		assert.False(t, s.Contains("3"))
+		assert.True(t, s.Contains("1"))
+	})
+
+	t.Run("all items visited", func(t *testing.T) {
+		s := NewSieve(3)
+		for i := 0; i < 3; i++ {
+			s.Put(strconv.Itoa(i), i)
+			s.Get(strconv.Itoa(i))
+		}
+
+		// the hand wraps around once, then evicts the oldest item.
+		s.Put("3", 3)
+		assert.False(t, s.Contains("0"))
+		assert.Equal(t, 3, s.Len())
+	})
+
+	t.Run("remove item under the hand", func(t *testing.T) {
+		s := NewSieve(3)
+		s.Put("1", 1)
+		s.Put("2", 2)
+		s.Put("3", 3)
+		s.Get("1")
+		s.Put("4", 4)
+		s.Remove(s.hand.Val.(*entry).key)
+
+		s.Put("5", 5)
+		s.Put("6", 6)
+		assert.Equal(t, 3, s.Len())
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		assert.Panics(t, func() { NewSieve(0) })
+	})
+}
+
+func TestSieve_Concurrent(t *testing.T) {
+	s := NewSieve(100)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := strconv.Itoa((i * j) % 300)
+				s.Put(key, j)
+				s.Get(key)
+				if j%7 == 0 {
+					s.Remove(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, s.Len(), 100)
+}
+
+// cache is the subset of operations needed to replay a trace.
+type cache interface {
+	Get(key string) (any, bool)
+	Put(key string, val any)
+}
+
+// hitRatio replays trace against c, inserting every miss.
+func hitRatio(c cache, trace []string) float64 {
+	hits := 0
+	for _, key := range trace {
+		if _, ok := c.Get(key); ok {
+			hits++
+			continue
+		}
+		c.Put(key, key)
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+// zipfTrace returns n keys drawn from a zipfian distribution over keySpace keys.
+func zipfTrace(seed int64, skew float64, keySpace uint64, n int) []string {
+	zipf := rand.NewZipf(rand.New(rand.NewSource(seed)), skew, 1, keySpace-1)
+	trace := make([]string, n)
+	for i := range trace {
+		trace[i] = strconv.FormatUint(zipf.Uint64(), 10)
+	}
+	return trace
+}
+
+func TestSieve_HitRatio(t *testing.T) {
+	cases := []struct {
+		name     string
+		skew     float64
+		keySpace uint64
+		limit    int
+	}{
+		{name: "skew 1.01, 1% cache", skew: 1.01, keySpace: 100000, limit: 1000},
+		{name: "skew 1.01, 10% cache", skew: 1.01, keySpace: 100000, limit: 10000},
+		{name: "skew 1.2, 1% cache", skew: 1.2, keySpace: 100000, limit: 1000},
+		{name: "skew 1.2, 10% cache", skew: 1.2, keySpace: 100000, limit: 10000},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			trace := zipfTrace(42, tc.skew, tc.keySpace, 200000)
+
+			sieveRatio := hitRatio(NewSieve(tc.limit), trace)
+			lruRatio := hitRatio(lru.NewLRU(tc.limit), trace)
+			fifoRatio := hitRatio(fifo.NewFifo(tc.limit), trace)
+			t.Logf("sieve=%.4f lru=%.4f fifo=%.4f", sieveRatio, lruRatio, fifoRatio)
+
+			assert.Greater(t, sieveRatio, fifoRatio)
+			assert.GreaterOrEqual(t, sieveRatio, lruRatio)
+		})
+	}
+}
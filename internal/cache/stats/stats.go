@@ -0,0 +1,61 @@
+package stats
+
+import "sync/atomic"
+
+// Stats is a snapshot of the counters of a cache.
+type Stats struct {
+	// Hits is the number of lookups that found their key.
+	Hits uint64 `json:"hits"`
+	// Misses is the number of lookups that did not find their key.
+	Misses uint64 `json:"misses"`
+	// Evictions is the number of entries dropped to make room for new ones.
+	Evictions uint64 `json:"evictions"`
+	// Expirations is the number of entries dropped because their time to live ran out.
+	Expirations uint64 `json:"expirations"`
+}
+
+// HitRatio returns the fraction of lookups that were hits.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Counter records the Stats of a cache. The zero value is ready to use and
+// it is safe for concurrent use, so caches can record hits under a read lock.
+type Counter struct {
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+	expirations uint64
+}
+
+// Lookup records a hit when found is true and a miss otherwise.
+func (c *Counter) Lookup(found bool) {
+	if found {
+		atomic.AddUint64(&c.hits, 1)
+		return
+	}
+	atomic.AddUint64(&c.misses, 1)
+}
+
+// Evict records an eviction.
+func (c *Counter) Evict() {
+	atomic.AddUint64(&c.evictions, 1)
+}
+
+// Expire records an expiration.
+func (c *Counter) Expire() {
+	atomic.AddUint64(&c.expirations, 1)
+}
+
+// Snapshot returns the current counters.
+func (c *Counter) Snapshot() Stats {
+	return Stats{
+		Hits:        atomic.LoadUint64(&c.hits),
+		Misses:      atomic.LoadUint64(&c.misses),
+		Evictions:   atomic.LoadUint64(&c.evictions),
+		Expirations: atomic.LoadUint64(&c.expirations),
+	}
+}
@@ -0,0 +1,83 @@
+package tags
+
+// Index maps the keys of a cache to their tags and back, so that a cache can
+// find the entries carrying a tag and forget the tags of the entries it drops.
+// The zero value is ready to use. It is not safe for concurrent use, caches
+// guard it with their own lock.
+type Index struct {
+	// tags lists the tags of each tagged key.
+	tags map[string][]string
+	// keys is the set of keys carrying each tag.
+	keys map[string]map[string]struct{}
+}
+
+// Set replaces the tags of key with tags, or removes them if tags is empty.
+func (x *Index) Set(key string, tags []string) {
+	if len(x.tags) == 0 && len(tags) == 0 {
+		return
+	}
+	x.Remove(key)
+	if len(tags) == 0 {
+		return
+	}
+	if x.tags == nil {
+		x.tags = make(map[string][]string)
+		x.keys = make(map[string]map[string]struct{})
+	}
+
+	own := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys, ok := x.keys[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			x.keys[tag] = keys
+		}
+		if _, dup := keys[key]; dup {
+			continue
+		}
+		keys[key] = struct{}{}
+		own = append(own, tag)
+	}
+	x.tags[key] = own
+}
+
+// Remove removes the tags of key.
+func (x *Index) Remove(key string) {
+	tags, ok := x.tags[key]
+	if !ok {
+		return
+	}
+	delete(x.tags, key)
+	for _, tag := range tags {
+		keys := x.keys[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(x.keys, tag)
+		}
+	}
+}
+
+// Keys returns the keys carrying tag, in no particular order.
+func (x *Index) Keys(tag string) []string {
+	keys := make([]string, 0, len(x.keys[tag]))
+	for key := range x.keys[tag] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Tags returns the tags of key.
+func (x *Index) Tags(key string) []string {
+	return x.tags[key]
+}
+
+// Len returns the number of distinct tags in the index.
+func (x *Index) Len() int {
+	return len(x.keys)
+}
+
+// Clear removes every key and tag from the index.
+func (x *Index) Clear() {
+	x.tags = nil
+	x.keys = nil
+}
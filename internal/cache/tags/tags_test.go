@@ -0,0 +1,40 @@
+package tags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+	var x Index
+	x.Set("a", nil)
+	assert.Equal(t, 0, x.Len())
+
+	x.Set("a", []string{"user:7", "org:3", "user:7"})
+	x.Set("b", []string{"org:3"})
+	assert.Equal(t, []string{"user:7", "org:3"}, x.Tags("a"))
+	assert.ElementsMatch(t, []string{"a", "b"}, x.Keys("org:3"))
+	assert.Equal(t, []string{"a"}, x.Keys("user:7"))
+	assert.Empty(t, x.Keys("missing"))
+
+	// Set replaces the tags of a key.
+	x.Set("a", []string{"org:4"})
+	assert.Empty(t, x.Keys("user:7"))
+	assert.Equal(t, []string{"b"}, x.Keys("org:3"))
+	assert.Equal(t, 2, x.Len())
+
+	x.Remove("b")
+	x.Remove("missing")
+	assert.Empty(t, x.Keys("org:3"))
+	assert.Equal(t, 1, x.Len())
+
+	x.Set("a", nil)
+	assert.Equal(t, 0, x.Len())
+	assert.Empty(t, x.tags)
+
+	x.Set("c", []string{"t"})
+	x.Clear()
+	assert.Equal(t, 0, x.Len())
+	assert.Nil(t, x.Tags("c"))
+}
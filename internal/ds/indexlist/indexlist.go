@@ -0,0 +1,228 @@
+// Package indexlist implements doubly linked lists whose nodes are stored in
+// a slice and linked by int32 indices rather than pointers.
+//
+// Compared with package linkedlist, pushing a value does not allocate once
+// the slice has grown, since removed nodes are reused through a free list,
+// and the values are stored inline in the nodes rather than boxed in an
+// interface. The garbage collector scans one slice instead of one object per
+// node, and the links, being plain integers, hold nothing for it to follow.
+package indexlist
+
+// Nil is the index of no node: the next node of the tail, the previous node
+// of the head, and the head and tail of an empty list.
+const Nil int32 = -1
+
+// node is an element of an Arena.
+type node[T any] struct {
+	val        T
+	prev, next int32
+}
+
+// Arena stores the nodes of one or more lists. Indices are only meaningful
+// within their arena.
+type Arena[T any] struct {
+	nodes []node[T]
+	// free is the first node of the free list, linked through next.
+	free int32
+}
+
+// NewArena returns an arena with room for capacity nodes before it grows.
+func NewArena[T any](capacity int) *Arena[T] {
+	return &Arena[T]{nodes: make([]node[T], 0, capacity), free: Nil}
+}
+
+// NewList returns an empty list storing its nodes in a.
+func (a *Arena[T]) NewList() *List[T] {
+	return &List[T]{arena: a, head: Nil, tail: Nil}
+}
+
+// Value returns a pointer to the value of node i. It is invalidated by the
+// next push to any list of the arena, which may grow the arena.
+func (a *Arena[T]) Value(i int32) *T {
+	return &a.nodes[i].val
+}
+
+// Next returns the node after i, or Nil if i is the tail of its list.
+func (a *Arena[T]) Next(i int32) int32 {
+	return a.nodes[i].next
+}
+
+// Prev returns the node before i, or Nil if i is the head of its list.
+func (a *Arena[T]) Prev(i int32) int32 {
+	return a.nodes[i].prev
+}
+
+// Reset frees every node of the arena, keeping its capacity. The lists using
+// the arena must not be used afterwards.
+func (a *Arena[T]) Reset() {
+	var zero node[T]
+	for i := range a.nodes {
+		a.nodes[i] = zero
+	}
+	a.nodes = a.nodes[:0]
+	a.free = Nil
+}
+
+// alloc returns a node holding val, reusing a free node if there is one.
+func (a *Arena[T]) alloc(val T) int32 {
+	if a.free != Nil {
+		i := a.free
+		a.free = a.nodes[i].next
+		a.nodes[i] = node[T]{val: val, prev: Nil, next: Nil}
+		return i
+	}
+	a.nodes = append(a.nodes, node[T]{val: val, prev: Nil, next: Nil})
+	return int32(len(a.nodes) - 1)
+}
+
+// release adds node i to the free list, dropping its value.
+func (a *Arena[T]) release(i int32) {
+	a.nodes[i] = node[T]{prev: Nil, next: a.free}
+	a.free = i
+}
+
+// List is a doubly linked list of values of type T.
+type List[T any] struct {
+	arena      *Arena[T]
+	head, tail int32
+	len        int
+}
+
+// New returns an empty list with its own arena, with room for capacity
+// values before it grows.
+func New[T any](capacity int) *List[T] {
+	return NewArena[T](capacity).NewList()
+}
+
+// Len returns the number of values in the list.
+func (l *List[T]) Len() int {
+	return l.len
+}
+
+// Head returns the first node of the list, or Nil if it is empty.
+func (l *List[T]) Head() int32 {
+	return l.head
+}
+
+// Tail returns the last node of the list, or Nil if it is empty.
+func (l *List[T]) Tail() int32 {
+	return l.tail
+}
+
+// Next returns the node after i, or Nil if i is the tail.
+func (l *List[T]) Next(i int32) int32 {
+	return l.arena.nodes[i].next
+}
+
+// Prev returns the node before i, or Nil if i is the head.
+func (l *List[T]) Prev(i int32) int32 {
+	return l.arena.nodes[i].prev
+}
+
+// Value returns a pointer to the value of node i. It is invalidated by the
+// next push, which may grow the arena.
+func (l *List[T]) Value(i int32) *T {
+	return &l.arena.nodes[i].val
+}
+
+// PushFront inserts val at the front of the list and returns its node.
+func (l *List[T]) PushFront(val T) int32 {
+	i := l.arena.alloc(val)
+	l.linkFront(i)
+	return i
+}
+
+// PushBack inserts val at the back of the list and returns its node.
+func (l *List[T]) PushBack(val T) int32 {
+	i := l.arena.alloc(val)
+	l.linkBack(i)
+	return i
+}
+
+// Remove removes node i from the list and returns its value. The node is
+// reused by later pushes.
+func (l *List[T]) Remove(i int32) T {
+	val := l.arena.nodes[i].val
+	l.unlink(i)
+	l.arena.release(i)
+	return val
+}
+
+// MoveToFront moves node i to the front of the list.
+func (l *List[T]) MoveToFront(i int32) {
+	if l.head == i {
+		return
+	}
+	l.unlink(i)
+	l.linkFront(i)
+}
+
+// MoveToBack moves node i to the back of the list.
+func (l *List[T]) MoveToBack(i int32) {
+	if l.tail == i {
+		return
+	}
+	l.unlink(i)
+	l.linkBack(i)
+}
+
+// Clear removes every value from the list. If the list has its own arena,
+// use its Reset instead: it is faster, and keeps no references to the values.
+func (l *List[T]) Clear() {
+	for i := l.head; i != Nil; {
+		next := l.arena.nodes[i].next
+		l.arena.release(i)
+		i = next
+	}
+	l.head, l.tail, l.len = Nil, Nil, 0
+}
+
+// Reset removes every value from the list and frees every node of its arena.
+// It must only be used on lists having their own arena, as returned by New.
+func (l *List[T]) Reset() {
+	l.arena.Reset()
+	l.head, l.tail, l.len = Nil, Nil, 0
+}
+
+// linkFront links the unlinked node i at the front of the list.
+func (l *List[T]) linkFront(i int32) {
+	n := &l.arena.nodes[i]
+	n.prev, n.next = Nil, l.head
+	if l.head != Nil {
+		l.arena.nodes[l.head].prev = i
+	} else {
+		l.tail = i
+	}
+	l.head = i
+	l.len++
+}
+
+// linkBack links the unlinked node i at the back of the list.
+func (l *List[T]) linkBack(i int32) {
+	n := &l.arena.nodes[i]
+	n.prev, n.next = l.tail, Nil
+	if l.tail != Nil {
+		l.arena.nodes[l.tail].next = i
+	} else {
+		l.head = i
+	}
+	l.tail = i
+	l.len++
+}
+
+// unlink removes node i from the links of the list, leaving it in the arena.
+func (l *List[T]) unlink(i int32) {
+	n := &l.arena.nodes[i]
+	if n.prev != Nil {
+		l.arena.nodes[n.prev].next = n.next
+	} else {
+		l.head = n.next
+	}
+	if n.next != Nil {
+		l.arena.nodes[n.next].prev = n.prev
+	} else {
+		l.tail = n.prev
+	}
+	n.prev, n.next = Nil, Nil
+	l.len--
+}
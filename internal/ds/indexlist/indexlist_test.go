@@ -0,0 +1,148 @@
+package indexlist
+
+import (
+	"testing"
+
+	"github.com/raghavgh/gofast/internal/ds/linkedlist"
+	"github.com/stretchr/testify/assert"
+)
+
+// values returns the values of l from head to tail.
+func values(l *List[int]) []int {
+	vals := []int{}
+	for i := l.Head(); i != Nil; i = l.Next(i) {
+		vals = append(vals, *l.Value(i))
+	}
+	return vals
+}
+
+// reversed returns the values of l from tail to head.
+func reversed(l *List[int]) []int {
+	vals := []int{}
+	for i := l.Tail(); i != Nil; i = l.Prev(i) {
+		vals = append(vals, *l.Value(i))
+	}
+	return vals
+}
+
+func TestList(t *testing.T) {
+	t.Run("push and iterate", func(t *testing.T) {
+		l := New[int](0)
+		assert.Equal(t, Nil, l.Head())
+		assert.Equal(t, Nil, l.Tail())
+
+		l.PushBack(2)
+		l.PushFront(1)
+		l.PushBack(3)
+		assert.Equal(t, 3, l.Len())
+		assert.Equal(t, []int{1, 2, 3}, values(l))
+		assert.Equal(t, []int{3, 2, 1}, reversed(l))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		l := New[int](0)
+		a, b, c := l.PushBack(1), l.PushBack(2), l.PushBack(3)
+
+		l.MoveToFront(c)
+		assert.Equal(t, []int{3, 1, 2}, values(l))
+		l.MoveToFront(c)
+		assert.Equal(t, []int{3, 1, 2}, values(l))
+		l.MoveToBack(a)
+		assert.Equal(t, []int{3, 2, 1}, values(l))
+		l.MoveToFront(b)
+		assert.Equal(t, []int{2, 3, 1}, values(l))
+		assert.Equal(t, []int{1, 3, 2}, reversed(l))
+	})
+
+	t.Run("remove reuses nodes", func(t *testing.T) {
+		l := New[int](0)
+		a, b, c := l.PushBack(1), l.PushBack(2), l.PushBack(3)
+
+		assert.Equal(t, 2, l.Remove(b))
+		assert.Equal(t, []int{1, 3}, values(l))
+		assert.Equal(t, []int{3, 1}, reversed(l))
+		assert.Equal(t, b, l.PushFront(4))
+		assert.Equal(t, []int{4, 1, 3}, values(l))
+
+		l.Remove(a)
+		l.Remove(c)
+		l.Remove(b)
+		assert.Equal(t, 0, l.Len())
+		assert.Equal(t, Nil, l.Head())
+		assert.Equal(t, Nil, l.Tail())
+		assert.Len(t, l.arena.nodes, 3)
+	})
+
+	t.Run("clear and reset", func(t *testing.T) {
+		l := New[int](0)
+		l.PushBack(1)
+		l.PushBack(2)
+		l.Clear()
+		assert.Equal(t, 0, l.Len())
+		assert.Empty(t, values(l))
+		l.PushBack(3)
+		assert.Len(t, l.arena.nodes, 2)
+
+		l.Reset()
+		assert.Equal(t, 0, l.Len())
+		assert.Empty(t, l.arena.nodes)
+		l.PushBack(4)
+		assert.Equal(t, []int{4}, values(l))
+	})
+
+	t.Run("lists sharing an arena", func(t *testing.T) {
+		a := NewArena[int](0)
+		l1, l2 := a.NewList(), a.NewList()
+		x := l1.PushBack(1)
+		l2.PushBack(2)
+		l1.PushBack(3)
+
+		l1.Remove(x)
+		assert.Equal(t, x, l2.PushBack(4))
+		assert.Equal(t, []int{3}, values(l1))
+		assert.Equal(t, []int{2, 4}, values(l2))
+		assert.Equal(t, 4, *a.Value(a.Next(a.Prev(x))))
+	})
+}
+
+func TestList_Allocs(t *testing.T) {
+	l := New[int](1)
+	l.Remove(l.PushFront(0))
+
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		l.Remove(l.PushFront(1))
+	}))
+}
+
+// benchLen is the length of the lists benchmarked.
+const benchLen = 1000
+
+// BenchmarkPushRemove pushes to the front and removes from the back of a full
+// list, as a cache evicting an entry to make room for a new one does.
+func BenchmarkPushRemove(b *testing.B) {
+	b.Run("indexlist", func(b *testing.B) {
+		l := New[int](benchLen)
+		for i := 0; i < benchLen; i++ {
+			l.PushFront(i)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Remove(l.Tail())
+			l.PushFront(i)
+		}
+	})
+
+	b.Run("linkedlist", func(b *testing.B) {
+		l := linkedlist.New()
+		for i := 0; i < benchLen; i++ {
+			l.PushFront(i)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Remove(l.Tail)
+			l.PushFront(i)
+		}
+	})
+}
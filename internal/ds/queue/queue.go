@@ -49,7 +49,10 @@ func (l *List) Pop() {
 	if l.Empty() {
 		panic("queue: Pop() called on empty queue")
 	}
-	l.Remove(l.Head)
+	if l.allowArbitraryDeletion {
+		delete(l.elementToNode, l.Head.Val)
+	}
+	l.LinkedList.Remove(l.Head)
 }
 
 // Front returns the first element of the queue
@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	for _, removable := range []bool{true, false} {
+		q := NewQueueList(removable)
+		q.Push(1)
+		q.Push(2)
+		q.Push(3)
+		assert.Equal(t, 3, q.Size())
+		assert.Equal(t, 1, q.Front())
+		assert.Equal(t, 3, q.Back())
+
+		q.Pop()
+		assert.Equal(t, 2, q.Size())
+		assert.Equal(t, 2, q.Front())
+
+		q.Pop()
+		q.Pop()
+		assert.True(t, q.Empty())
+		assert.Panics(t, q.Pop)
+	}
+}
+
+func TestQueue_Remove(t *testing.T) {
+	q := NewQueueList(true)
+	a, b, c := new(int), new(int), new(int)
+	q.Push(a)
+	q.Push(b)
+	q.Push(c)
+
+	q.Remove(b)
+	assert.Equal(t, 2, q.Size())
+	q.Pop()
+	assert.Equal(t, c, q.Front())
+
+	// a was popped, so removing it again must be a no-op.
+	q.Remove(a)
+	assert.Equal(t, 1, q.Size())
+}
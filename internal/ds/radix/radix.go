@@ -0,0 +1,208 @@
+// Package radix implements a set of strings as a radix tree, answering how
+// many keys and which keys start with a given prefix in time proportional to
+// the length of the prefix, plus the number of keys listed.
+package radix
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tree is a set of strings. The zero value is an empty set ready to use.
+type Tree struct {
+	root node
+}
+
+// node is a node of a Tree. The keys of its subtree all start with the
+// labels of the edges from the root, each edge labelled by its child's prefix.
+type node struct {
+	prefix string
+	// leaf is true if the labels from the root down to the node are a key.
+	leaf bool
+	// size is the number of keys in the subtree of the node.
+	size int
+	// children are sorted by the first byte of their prefix, which is unique.
+	children []*node
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree) Len() int {
+	return t.root.size
+}
+
+// Insert adds key to the tree, and reports whether it was not in it already.
+func (t *Tree) Insert(key string) bool {
+	n := &t.root
+	path := []*node{n}
+	for key != "" {
+		i, child := n.child(key[0])
+		if child == nil {
+			n.insertChild(i, &node{prefix: key})
+			n = n.children[i]
+			path = append(path, n)
+			break
+		}
+		common := commonPrefix(key, child.prefix)
+		if common < len(child.prefix) {
+			// Split the edge to child where key leaves it.
+			mid := &node{prefix: child.prefix[:common], size: child.size, children: []*node{child}}
+			child.prefix = child.prefix[common:]
+			n.children[i] = mid
+			child = mid
+		}
+		key = key[common:]
+		n = child
+		path = append(path, n)
+	}
+	if n.leaf {
+		return false
+	}
+	n.leaf = true
+	for _, p := range path {
+		p.size++
+	}
+	return true
+}
+
+// Delete removes key from the tree, and reports whether it was in it.
+func (t *Tree) Delete(key string) bool {
+	n := &t.root
+	path := []*node{n}
+	for key != "" {
+		_, child := n.child(key[0])
+		if child == nil || !strings.HasPrefix(key, child.prefix) {
+			return false
+		}
+		key = key[len(child.prefix):]
+		n = child
+		path = append(path, n)
+	}
+	if !n.leaf {
+		return false
+	}
+	n.leaf = false
+	for _, p := range path {
+		p.size--
+	}
+
+	// Drop the nodes left without keys, then merge the first node left with
+	// a single child into it, keeping the tree compressed.
+	for i := len(path) - 1; i > 0; i-- {
+		n, parent := path[i], path[i-1]
+		if n.size == 0 {
+			parent.removeChild(n.prefix[0])
+			continue
+		}
+		if !n.leaf && len(n.children) == 1 {
+			child := n.children[0]
+			n.prefix += child.prefix
+			n.leaf = child.leaf
+			n.children = child.children
+		}
+		break
+	}
+	return true
+}
+
+// Contains reports whether key is in the tree.
+func (t *Tree) Contains(key string) bool {
+	n, rest := t.find(key)
+	return n != nil && rest == "" && n.leaf
+}
+
+// Count returns the number of keys starting with prefix.
+func (t *Tree) Count(prefix string) int {
+	n, _ := t.find(prefix)
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// WalkPrefix calls fn with the keys starting with prefix, in lexicographic
+// order, until fn returns false. The tree must not be modified during the walk.
+func (t *Tree) WalkPrefix(prefix string, fn func(key string) bool) {
+	n, rest := t.find(prefix)
+	if n == nil {
+		return
+	}
+	// find stops at the node whose edge covers the end of prefix.
+	n.walk(prefix+rest, fn)
+}
+
+// Clear removes every key from the tree.
+func (t *Tree) Clear() {
+	t.root = node{}
+}
+
+// find returns the node of the shallowest subtree holding every key starting
+// with prefix, and the rest of its edge label beyond prefix, or nil if no key
+// starts with prefix.
+func (t *Tree) find(prefix string) (*node, string) {
+	n := &t.root
+	for prefix != "" {
+		_, child := n.child(prefix[0])
+		if child == nil {
+			return nil, ""
+		}
+		if strings.HasPrefix(child.prefix, prefix) {
+			return child, child.prefix[len(prefix):]
+		}
+		if !strings.HasPrefix(prefix, child.prefix) {
+			return nil, ""
+		}
+		prefix = prefix[len(child.prefix):]
+		n = child
+	}
+	return n, ""
+}
+
+// walk calls fn with the keys of the subtree of n, whose labels from the root
+// are key, and reports whether fn asked to go on.
+func (n *node) walk(key string, fn func(key string) bool) bool {
+	if n.leaf && !fn(key) {
+		return false
+	}
+	for _, child := range n.children {
+		if !child.walk(key+child.prefix, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// child returns the child whose prefix starts with b, or nil and the index
+// to insert it at.
+func (n *node) child(b byte) (int, *node) {
+	i := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= b
+	})
+	if i < len(n.children) && n.children[i].prefix[0] == b {
+		return i, n.children[i]
+	}
+	return i, nil
+}
+
+// insertChild inserts child at index i.
+func (n *node) insertChild(i int, child *node) {
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+}
+
+// removeChild removes the child whose prefix starts with b.
+func (n *node) removeChild(b byte) {
+	i, _ := n.child(b)
+	copy(n.children[i:], n.children[i+1:])
+	n.children[len(n.children)-1] = nil
+	n.children = n.children[:len(n.children)-1]
+}
+
+// commonPrefix returns the length of the common prefix of a and b.
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,112 @@
+package radix
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// keys returns the keys of t starting with prefix.
+func keys(t *Tree, prefix string) []string {
+	keys := []string{}
+	t.WalkPrefix(prefix, func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func TestTree(t *testing.T) {
+	t.Run("insert and walk", func(t *testing.T) {
+		tree := &Tree{}
+		for _, key := range []string{"tenant:1:a", "tenant:1:b", "tenant:2:a", "tenant:10", "t", ""} {
+			assert.True(t, tree.Insert(key))
+		}
+		assert.False(t, tree.Insert("tenant:1:a"))
+		assert.Equal(t, 6, tree.Len())
+
+		assert.Equal(t, []string{"tenant:10", "tenant:1:a", "tenant:1:b"}, keys(tree, "tenant:1"))
+		assert.Equal(t, 3, tree.Count("tenant:1"))
+		assert.Equal(t, 4, tree.Count("ten"))
+		assert.Equal(t, 5, tree.Count("t"))
+		assert.Equal(t, 6, tree.Count(""))
+		assert.Equal(t, 0, tree.Count("tenant:3"))
+		assert.Empty(t, keys(tree, "tenant:1:c"))
+		assert.True(t, tree.Contains(""))
+		assert.True(t, tree.Contains("t"))
+		assert.False(t, tree.Contains("tenant:"))
+	})
+
+	t.Run("walk stops", func(t *testing.T) {
+		tree := &Tree{}
+		tree.Insert("a")
+		tree.Insert("b")
+		var walked []string
+		tree.WalkPrefix("", func(key string) bool {
+			walked = append(walked, key)
+			return false
+		})
+		assert.Equal(t, []string{"a"}, walked)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		tree := &Tree{}
+		tree.Insert("abc")
+		tree.Insert("abd")
+		tree.Insert("ab")
+		assert.False(t, tree.Delete("a"))
+		assert.False(t, tree.Delete("abx"))
+
+		assert.True(t, tree.Delete("ab"))
+		assert.False(t, tree.Delete("ab"))
+		assert.Equal(t, []string{"abc", "abd"}, keys(tree, "a"))
+		assert.True(t, tree.Delete("abc"))
+		// The tree stays compressed.
+		assert.Len(t, tree.root.children, 1)
+		assert.Equal(t, "abd", tree.root.children[0].prefix)
+		assert.True(t, tree.Delete("abd"))
+		assert.Equal(t, 0, tree.Len())
+		assert.Empty(t, tree.root.children)
+	})
+
+	t.Run("random operations", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		tree := &Tree{}
+		set := map[string]bool{}
+		alphabet := "ab:"
+		randomKey := func() string {
+			b := make([]byte, rng.Intn(6))
+			for i := range b {
+				b[i] = alphabet[rng.Intn(len(alphabet))]
+			}
+			return string(b)
+		}
+
+		for i := 0; i < 5000; i++ {
+			key := randomKey()
+			if rng.Intn(3) == 0 {
+				assert.Equal(t, set[key], tree.Delete(key))
+				delete(set, key)
+			} else {
+				assert.Equal(t, !set[key], tree.Insert(key))
+				set[key] = true
+			}
+
+			prefix := randomKey()
+			prefix = prefix[:len(prefix)/2]
+			want := []string{}
+			for k := range set {
+				if strings.HasPrefix(k, prefix) {
+					want = append(want, k)
+				}
+			}
+			sort.Strings(want)
+			assert.Equal(t, want, keys(tree, prefix))
+			assert.Equal(t, len(want), tree.Count(prefix))
+		}
+		assert.Equal(t, len(set), tree.Len())
+	})
+}
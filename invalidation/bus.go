@@ -0,0 +1,272 @@
+// Package invalidation keeps the local caches of several replicas consistent
+// by broadcasting their removals. When a replica changes the source of truth
+// and removes its cached entry, the other replicas remove theirs too.
+//
+// A Bus sends and receives invalidation messages over a Transport, such as a
+// message broker; NewMemory returns a transport connecting the buses of one
+// process, for tests.
+package invalidation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/raghavgh/gofast"
+)
+
+// Op is the operation of a message.
+type Op int
+
+const (
+	// OpRemove removes a key.
+	OpRemove Op = iota + 1
+	// OpClear removes every key.
+	OpClear
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case OpRemove:
+		return "remove"
+	case OpClear:
+		return "clear"
+	default:
+		return "unknown"
+	}
+}
+
+// Message is an invalidation sent over a Transport.
+type Message struct {
+	// Origin identifies the bus that sent the message.
+	Origin string `json:"origin"`
+	Op     Op     `json:"op"`
+	// Key is empty for OpClear.
+	Key string `json:"key,omitempty"`
+}
+
+// Transport broadcasts messages between buses.
+type Transport interface {
+	// Publish sends msg to every subscriber, including those of the sender.
+	Publish(ctx context.Context, msg Message) error
+	// Subscribe calls handler with every message published until cancel is
+	// called. handler must not block for long.
+	Subscribe(handler func(Message)) (cancel func(), err error)
+}
+
+// BusOption configures a Bus.
+type BusOption func(b *Bus)
+
+// WithOrigin sets the identifier of the bus, which must be unique among the
+// buses of the transport. Defaults to a random identifier.
+func WithOrigin(origin string) BusOption {
+	return func(b *Bus) {
+		b.origin = origin
+	}
+}
+
+// WithErrorHandler sets fn to be called with the errors of publishing from
+// the Cache wrappers. By default those errors are dropped.
+func WithErrorHandler(fn func(err error)) BusOption {
+	return func(b *Bus) {
+		b.onError = fn
+	}
+}
+
+// Bus applies the invalidations of the other buses of a transport to the
+// attached caches, and sends theirs.
+type Bus struct {
+	transport Transport
+	origin    string
+	onError   func(err error)
+	cancel    func()
+
+	mu      *sync.RWMutex
+	targets map[*target]struct{}
+}
+
+// target is an attached cache.
+type target struct {
+	cache    gofast.Cache
+	prefixes []string
+}
+
+// NewBus returns a bus subscribed to transport.
+func NewBus(transport Transport, opts ...BusOption) (*Bus, error) {
+	b := &Bus{
+		transport: transport,
+		mu:        &sync.RWMutex{},
+		targets:   make(map[*target]struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.origin == "" {
+		b.origin = newOrigin()
+	}
+	cancel, err := transport.Subscribe(b.receive)
+	if err != nil {
+		return nil, err
+	}
+	b.cancel = cancel
+	return b, nil
+}
+
+// Origin returns the identifier of the bus.
+func (b *Bus) Origin() string {
+	return b.origin
+}
+
+// Attach applies the invalidations received from the other buses to c, until
+// detach is called. If prefixes are given, only the removals of keys starting
+// with one of them are applied; clears always are.
+func (b *Bus) Attach(c gofast.Cache, prefixes ...string) (detach func()) {
+	t := &target{cache: c, prefixes: prefixes}
+	b.mu.Lock()
+	b.targets[t] = struct{}{}
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		delete(b.targets, t)
+		b.mu.Unlock()
+	}
+}
+
+// Remove tells the other buses to remove key from their caches. The caches
+// attached to b are left alone: remove key from them directly, or use Wrap.
+func (b *Bus) Remove(ctx context.Context, key string) error {
+	return b.transport.Publish(ctx, Message{Origin: b.origin, Op: OpRemove, Key: key})
+}
+
+// Clear tells the other buses to clear their caches.
+func (b *Bus) Clear(ctx context.Context) error {
+	return b.transport.Publish(ctx, Message{Origin: b.origin, Op: OpClear})
+}
+
+// Close unsubscribes the bus from its transport.
+func (b *Bus) Close() {
+	b.cancel()
+}
+
+// receive applies a message from the transport to the attached caches.
+func (b *Bus) receive(msg Message) {
+	// The sender has already applied its own invalidations.
+	if msg.Origin == b.origin {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for t := range b.targets {
+		switch msg.Op {
+		case OpRemove:
+			if t.matches(msg.Key) {
+				t.cache.Remove(msg.Key)
+			}
+		case OpClear:
+			t.cache.Clear()
+		}
+	}
+}
+
+// matches reports whether the removals of key apply to the target.
+func (t *target) matches(key string) bool {
+	if len(t.prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range t.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// report passes a non-nil error to the error handler.
+func (b *Bus) report(err error) {
+	if err != nil && b.onError != nil {
+		b.onError(err)
+	}
+}
+
+// newOrigin returns a random identifier.
+func newOrigin() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic("invalidation: " + err.Error())
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// Cache is a cache whose removals are broadcast to the other buses. Returned
+// by Bus.Wrap.
+type Cache struct {
+	gofast.Cache
+	bus    *Bus
+	detach func()
+}
+
+// Wrap attaches c to b, as Attach does, and returns c with Remove and Clear
+// also sent to the other buses. Publishing errors go to the error handler of
+// the bus. Call Detach once the cache is no longer used, so that the bus
+// releases it.
+func (b *Bus) Wrap(c gofast.Cache, prefixes ...string) *Cache {
+	detach := b.Attach(c, prefixes...)
+	return &Cache{Cache: c, bus: b, detach: detach}
+}
+
+// Detach stops applying the invalidations of the other buses to the cache.
+// Its own removals are still sent.
+func (c *Cache) Detach() {
+	c.detach()
+}
+
+// Remove deletes a specific key-value pair from the cache and from the caches
+// of the other buses.
+func (c *Cache) Remove(key string) {
+	c.Cache.Remove(key)
+	c.bus.report(c.bus.Remove(context.Background(), key))
+}
+
+// PutWithTags adds a new key-value pair to the cache carrying tags. The tags
+// are dropped if the wrapped cache does not implement gofast.Tagger.
+func (c *Cache) PutWithTags(key string, val any, tags ...string) {
+	if tagger, ok := c.Cache.(gofast.Tagger); ok {
+		tagger.PutWithTags(key, val, tags...)
+		return
+	}
+	c.Cache.Put(key, val)
+}
+
+// InvalidateTag removes every entry carrying tag, from the cache and from the
+// caches of the other buses, and returns the number of entries removed. Tags
+// are not sent: the other caches remove the keys carrying tag in this one.
+func (c *Cache) InvalidateTag(tag string) int {
+	keys := c.TaggedKeys(tag)
+	for _, key := range keys {
+		c.Remove(key)
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, or nil if the wrapped cache does
+// not implement gofast.Tagger.
+func (c *Cache) TaggedKeys(tag string) []string {
+	if tagger, ok := c.Cache.(gofast.Tagger); ok {
+		return tagger.TaggedKeys(tag)
+	}
+	return nil
+}
+
+// Clear removes all items from the cache and from the caches of the other buses.
+func (c *Cache) Clear() {
+	c.Cache.Clear()
+	c.bus.report(c.bus.Clear(context.Background()))
+}
+
+// Unwrap returns the wrapped cache.
+func (c *Cache) Unwrap() gofast.Cache {
+	return c.Cache
+}
@@ -0,0 +1,142 @@
+package invalidation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raghavgh/gofast"
+)
+
+var _ gofast.Wrapper = (*Cache)(nil)
+
+// failing is a transport whose publishes fail.
+type failing struct {
+	*Memory
+}
+
+func (failing) Publish(ctx context.Context, msg Message) error {
+	return errors.New("broker down")
+}
+
+// newReplicas returns n caches holding "user:1", "user:2" and "order:1",
+// wrapped by buses of the same transport.
+func newReplicas(t *testing.T, n int, prefixes ...string) []*Cache {
+	t.Helper()
+	transport := NewMemory()
+	var caches []*Cache
+	for i := 0; i < n; i++ {
+		bus, err := NewBus(transport)
+		require.NoError(t, err)
+		t.Cleanup(bus.Close)
+		c := bus.Wrap(gofast.NewCache(10, gofast.LRU), prefixes...)
+		c.Put("user:1", 1)
+		c.Put("user:2", 2)
+		c.Put("order:1", 3)
+		caches = append(caches, c)
+	}
+	return caches
+}
+
+func TestBus(t *testing.T) {
+	t.Run("remove and clear", func(t *testing.T) {
+		caches := newReplicas(t, 3)
+
+		caches[0].Remove("user:1")
+		for _, c := range caches {
+			assert.False(t, c.Contains("user:1"))
+			assert.True(t, c.Contains("user:2"))
+		}
+
+		caches[1].Clear()
+		for _, c := range caches {
+			assert.Equal(t, 0, c.Len())
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		caches := newReplicas(t, 2)
+		caches[0].PutWithTags("user:1", 1, "users")
+		caches[0].PutWithTags("user:2", 2, "users")
+
+		assert.Equal(t, 2, caches[0].InvalidateTag("users"))
+		for _, c := range caches {
+			assert.False(t, c.Contains("user:1"))
+			assert.False(t, c.Contains("user:2"))
+			assert.True(t, c.Contains("order:1"))
+		}
+	})
+
+	t.Run("prefixes", func(t *testing.T) {
+		caches := newReplicas(t, 2, "user:")
+
+		caches[0].Remove("order:1")
+		assert.False(t, caches[0].Contains("order:1"))
+		assert.True(t, caches[1].Contains("order:1"))
+
+		caches[0].Remove("user:1")
+		assert.False(t, caches[1].Contains("user:1"))
+	})
+
+	t.Run("own messages are ignored", func(t *testing.T) {
+		transport := NewMemory()
+		bus, err := NewBus(transport, WithOrigin("a"))
+		require.NoError(t, err)
+		defer bus.Close()
+		c := gofast.NewCache(10, gofast.LRU)
+		bus.Attach(c)
+		c.Put("k", 1)
+
+		require.NoError(t, bus.Remove(context.Background(), "k"))
+		assert.True(t, c.Contains("k"))
+
+		require.NoError(t, transport.Publish(context.Background(), Message{Origin: "b", Op: OpRemove, Key: "k"}))
+		assert.False(t, c.Contains("k"))
+	})
+
+	t.Run("detach and close", func(t *testing.T) {
+		transport := NewMemory()
+		a, err := NewBus(transport)
+		require.NoError(t, err)
+		b, err := NewBus(transport)
+		require.NoError(t, err)
+
+		c := gofast.NewCache(10, gofast.LRU)
+		detach := b.Attach(c)
+		c.Put("k", 1)
+		detach()
+		require.NoError(t, a.Clear(context.Background()))
+		assert.True(t, c.Contains("k"))
+
+		b.Attach(c)
+		b.Close()
+		require.NoError(t, a.Clear(context.Background()))
+		assert.True(t, c.Contains("k"))
+	})
+
+	t.Run("detach wrapped caches", func(t *testing.T) {
+		caches := newReplicas(t, 2)
+		caches[1].Detach()
+		assert.Empty(t, caches[1].bus.targets)
+
+		caches[0].Remove("user:1")
+		assert.True(t, caches[1].Contains("user:1"))
+		caches[1].Remove("user:2")
+		assert.False(t, caches[0].Contains("user:2"))
+	})
+
+	t.Run("publish errors", func(t *testing.T) {
+		var errs []error
+		bus, err := NewBus(failing{NewMemory()}, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+		require.NoError(t, err)
+		c := bus.Wrap(gofast.NewCache(10, gofast.LRU))
+		c.Put("k", 1)
+
+		c.Remove("k")
+		assert.False(t, c.Contains("k"))
+		assert.Len(t, errs, 1)
+	})
+}
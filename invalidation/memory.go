@@ -0,0 +1,48 @@
+package invalidation
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory is a Transport delivering messages to the subscribers of the same
+// process, synchronously. Use it in tests or between caches of one process.
+type Memory struct {
+	mu       *sync.RWMutex
+	handlers map[*func(Message)]struct{}
+}
+
+// NewMemory returns a transport with no subscribers.
+func NewMemory() *Memory {
+	return &Memory{mu: &sync.RWMutex{}, handlers: make(map[*func(Message)]struct{})}
+}
+
+// Publish calls every subscribed handler with msg before returning.
+func (m *Memory) Publish(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	handlers := make([]func(Message), 0, len(m.handlers))
+	for h := range m.handlers {
+		handlers = append(handlers, *h)
+	}
+	m.mu.RUnlock()
+	for _, h := range handlers {
+		h(msg)
+	}
+	return nil
+}
+
+// Subscribe calls handler with every message published until cancel is called.
+func (m *Memory) Subscribe(handler func(Message)) (cancel func(), err error) {
+	h := &handler
+	m.mu.Lock()
+	m.handlers[h] = struct{}{}
+	m.mu.Unlock()
+	return func() {
+		m.mu.Lock()
+		delete(m.handlers, h)
+		m.mu.Unlock()
+	}, nil
+}
@@ -0,0 +1,337 @@
+package gofast
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/raghavgh/gofast/internal/ds/radix"
+)
+
+// Namespaced wraps a Cache, adding RemovePrefix and namespaces: views of the
+// cache whose keys are kept apart from the others and that can be invalidated
+// at once, such as the entries of one tenant.
+//
+// Namespaced indexes the keys of the wrapped cache to remove them by prefix.
+// Entries the wrapped cache drops are removed from the index when it reports
+// them through EvictionNotifier or ExpirationNotifier; the wrapped cache must
+// not be written to other than through Namespaced.
+type Namespaced struct {
+	cache Cache
+
+	// mu orders writes, so that the index is updated in the order the
+	// wrapped cache is.
+	mu *sync.Mutex
+
+	// indexMu guards the index and the namespaces. It is taken by the hooks
+	// of the wrapped cache, so it must not be held while calling it.
+	indexMu    *sync.RWMutex
+	index      *radix.Tree
+	namespaces map[string]*Namespace
+}
+
+// NewNamespaced returns a namespaced cache storing its entries in c.
+func NewNamespaced(c Cache) *Namespaced {
+	n := &Namespaced{
+		cache:      c,
+		mu:         &sync.Mutex{},
+		indexMu:    &sync.RWMutex{},
+		index:      &radix.Tree{},
+		namespaces: make(map[string]*Namespace),
+	}
+	unindex := func(key string, _ any) {
+		n.indexMu.Lock()
+		defer n.indexMu.Unlock()
+		n.index.Delete(key)
+	}
+	if notifier, ok := c.(EvictionNotifier); ok {
+		notifier.AddEvictionHook(unindex)
+	}
+	if notifier, ok := c.(ExpirationNotifier); ok {
+		notifier.AddExpirationHook(unindex)
+	}
+	return n
+}
+
+// Namespace returns the namespace called name, creating it on first use.
+// Its entries share the capacity of the cache. Names must not contain NUL
+// bytes, which separate them from the keys, and the keys put in the cache
+// directly must not start with one.
+func (n *Namespaced) Namespace(name string) *Namespace {
+	n.indexMu.Lock()
+	defer n.indexMu.Unlock()
+	return namespace(n, nil, n.namespaces, name)
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (n *Namespaced) Get(key string) (any, bool) {
+	return n.cache.Get(key)
+}
+
+// Put adds a new key-value pair to the cache.
+func (n *Namespaced) Put(key string, val any) {
+	n.put(key, val, nil)
+}
+
+// PutWithTags adds a new key-value pair to the cache carrying tags. The tags
+// are dropped if the wrapped cache does not implement Tagger.
+func (n *Namespaced) PutWithTags(key string, val any, tags ...string) {
+	n.put(key, val, tags)
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (n *Namespaced) Remove(key string) {
+	n.remove(key)
+}
+
+// RemovePrefix removes every key starting with prefix, including the keys of
+// the namespaces if prefix is empty, and returns the number of keys removed.
+func (n *Namespaced) RemovePrefix(prefix string) int {
+	return n.removePrefix(prefix)
+}
+
+// InvalidateTag removes every entry carrying tag and returns the number of
+// entries removed. It returns 0 if the wrapped cache does not implement Tagger.
+func (n *Namespaced) InvalidateTag(tag string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	keys := taggedKeys(n.cache, tag)
+	n.removeKeys(keys)
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag as stored, or nil if the wrapped
+// cache does not implement Tagger.
+func (n *Namespaced) TaggedKeys(tag string) []string {
+	return taggedKeys(n.cache, tag)
+}
+
+// Len returns the number of items in the cache, including those of the
+// namespaces and the invalidated entries of namespaces not evicted yet.
+func (n *Namespaced) Len() int {
+	return n.cache.Len()
+}
+
+// Clear removes all items from the cache, including those of the namespaces.
+func (n *Namespaced) Clear() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cache.Clear()
+
+	n.indexMu.Lock()
+	defer n.indexMu.Unlock()
+	n.index.Clear()
+}
+
+// Contains checks if a key is present in the cache.
+func (n *Namespaced) Contains(key string) bool {
+	return n.cache.Contains(key)
+}
+
+// Stats returns the stats of the wrapped cache, or zero Stats if it does not
+// implement StatsReporter.
+func (n *Namespaced) Stats() Stats {
+	if reporter, ok := n.cache.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+// Limit returns the limit of the wrapped cache, or 0 if it does not implement Inspector.
+func (n *Namespaced) Limit() int {
+	if inspector, ok := n.cache.(Inspector); ok {
+		return inspector.Limit()
+	}
+	return 0
+}
+
+// Keys returns the keys of the wrapped cache in eviction order, including the
+// keys of the namespaces as stored, or nil if it does not implement Inspector.
+func (n *Namespaced) Keys() []string {
+	if inspector, ok := n.cache.(Inspector); ok {
+		return inspector.Keys()
+	}
+	return nil
+}
+
+// Unwrap returns the wrapped cache.
+func (n *Namespaced) Unwrap() Cache {
+	return n.cache
+}
+
+// put stores val under the stored key carrying tags, and indexes it.
+func (n *Namespaced) put(key string, val any, tags []string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	putWithTags(n.cache, key, val, tags)
+
+	n.indexMu.Lock()
+	defer n.indexMu.Unlock()
+	n.index.Insert(key)
+}
+
+// remove deletes the stored key.
+func (n *Namespaced) remove(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cache.Remove(key)
+
+	n.indexMu.Lock()
+	defer n.indexMu.Unlock()
+	n.index.Delete(key)
+}
+
+// removePrefix deletes the stored keys starting with prefix.
+func (n *Namespaced) removePrefix(prefix string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.indexMu.RLock()
+	keys := make([]string, 0, n.index.Count(prefix))
+	n.index.WalkPrefix(prefix, func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	n.indexMu.RUnlock()
+
+	n.removeKeys(keys)
+	return len(keys)
+}
+
+// removeKeys deletes the stored keys. n.mu must be held.
+func (n *Namespaced) removeKeys(keys []string) {
+	for _, key := range keys {
+		n.cache.Remove(key)
+	}
+	n.indexMu.Lock()
+	defer n.indexMu.Unlock()
+	for _, key := range keys {
+		n.index.Delete(key)
+	}
+}
+
+// Namespace is a view of a Namespaced cache holding the keys put through it
+// apart from the other keys of the cache. Namespaces nest: the entries of a
+// namespace include those of its children.
+//
+// Invalidate drops every entry of a namespace in constant time, by bumping
+// the generation part of the keys it stores: the entries of older
+// generations can no longer be read, and are left for the wrapped cache to
+// evict.
+type Namespace struct {
+	root     *Namespaced
+	parent   *Namespace
+	name     string
+	children map[string]*Namespace
+
+	// generation and prefix are guarded by root.indexMu.
+	generation uint64
+	// prefix starts the stored keys of the namespace.
+	prefix string
+}
+
+// namespace returns the child of parent called name in namespaces, creating
+// it if needed. root.indexMu must be held for writing.
+func namespace(root *Namespaced, parent *Namespace, namespaces map[string]*Namespace, name string) *Namespace {
+	if ns, ok := namespaces[name]; ok {
+		return ns
+	}
+	ns := &Namespace{
+		root:     root,
+		parent:   parent,
+		name:     name,
+		children: make(map[string]*Namespace),
+	}
+	ns.updatePrefix()
+	namespaces[name] = ns
+	return ns
+}
+
+// Name returns the name of the namespace.
+func (ns *Namespace) Name() string {
+	return ns.name
+}
+
+// Namespace returns the child namespace called name, creating it on first use.
+func (ns *Namespace) Namespace(name string) *Namespace {
+	ns.root.indexMu.Lock()
+	defer ns.root.indexMu.Unlock()
+	return namespace(ns.root, ns, ns.children, name)
+}
+
+// Get retrieves a value from the namespace for a specific key.
+func (ns *Namespace) Get(key string) (any, bool) {
+	return ns.root.cache.Get(ns.key(key))
+}
+
+// Put adds a new key-value pair to the namespace.
+func (ns *Namespace) Put(key string, val any) {
+	ns.root.put(ns.key(key), val, nil)
+}
+
+// PutWithTags adds a new key-value pair to the namespace carrying tags, which
+// are shared with the other namespaces: Namespaced.InvalidateTag removes the
+// entries of every namespace carrying a tag.
+func (ns *Namespace) PutWithTags(key string, val any, tags ...string) {
+	ns.root.put(ns.key(key), val, tags)
+}
+
+// Remove deletes a specific key-value pair from the namespace.
+func (ns *Namespace) Remove(key string) {
+	ns.root.remove(ns.key(key))
+}
+
+// RemovePrefix removes every key of the namespace starting with prefix, and
+// returns the number of keys removed. Unlike Invalidate, it takes time
+// proportional to the number of keys removed, and frees their capacity.
+func (ns *Namespace) RemovePrefix(prefix string) int {
+	return ns.root.removePrefix(ns.key(prefix))
+}
+
+// Len returns the number of items in the namespace, including those of its children.
+func (ns *Namespace) Len() int {
+	ns.root.indexMu.RLock()
+	defer ns.root.indexMu.RUnlock()
+	return ns.root.index.Count(ns.prefix)
+}
+
+// Clear removes all items from the namespace, as Invalidate does.
+func (ns *Namespace) Clear() {
+	ns.Invalidate()
+}
+
+// Contains checks if a key is present in the namespace.
+func (ns *Namespace) Contains(key string) bool {
+	return ns.root.cache.Contains(ns.key(key))
+}
+
+// Invalidate drops every entry of the namespace and of its children, in
+// constant time with respect to their number.
+func (ns *Namespace) Invalidate() {
+	ns.root.indexMu.Lock()
+	defer ns.root.indexMu.Unlock()
+	ns.generation++
+	ns.updatePrefix()
+}
+
+// key returns the stored key of key.
+func (ns *Namespace) key(key string) string {
+	ns.root.indexMu.RLock()
+	defer ns.root.indexMu.RUnlock()
+	return ns.prefix + key
+}
+
+// updatePrefix sets the prefix of the namespace and of its children from
+// their generations. root.indexMu must be held for writing.
+func (ns *Namespace) updatePrefix() {
+	// Stored keys of namespaces start with a NUL byte, to keep them apart
+	// from the keys put in the cache directly, and the name and generation
+	// of each namespace are followed by one.
+	parent := "\x00"
+	if ns.parent != nil {
+		parent = ns.parent.prefix
+	}
+	ns.prefix = parent + ns.name + "\x00" + strconv.FormatUint(ns.generation, 36) + "\x00"
+	for _, child := range ns.children {
+		child.updatePrefix()
+	}
+}
@@ -0,0 +1,135 @@
+package gofast
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ Cache         = (*Namespace)(nil)
+	_ StatsReporter = (*Namespaced)(nil)
+	_ Inspector     = (*Namespaced)(nil)
+	_ Wrapper       = (*Namespaced)(nil)
+	_ Tagger        = (*Namespaced)(nil)
+)
+
+func TestNamespaced(t *testing.T) {
+	t.Run("namespaces are kept apart", func(t *testing.T) {
+		cache := NewNamespaced(NewCache(100, LRU))
+		t1, t2 := cache.Namespace("tenant:1"), cache.Namespace("tenant:2")
+		assert.Same(t, t1, cache.Namespace("tenant:1"))
+		assert.Equal(t, "tenant:1", t1.Name())
+
+		cache.Put("user", "root")
+		t1.Put("user", "one")
+		t2.Put("user", "two")
+
+		val, ok := t1.Get("user")
+		assert.True(t, ok)
+		assert.Equal(t, "one", val)
+		val, _ = t2.Get("user")
+		assert.Equal(t, "two", val)
+		val, _ = cache.Get("user")
+		assert.Equal(t, "root", val)
+		assert.Equal(t, 1, t1.Len())
+		assert.Equal(t, 3, cache.Len())
+
+		t1.Remove("user")
+		assert.False(t, t1.Contains("user"))
+		assert.True(t, t2.Contains("user"))
+		assert.Equal(t, 0, t1.Len())
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		cache := NewNamespaced(NewCache(100, LRU))
+		tenant := cache.Namespace("tenant:42")
+		users := tenant.Namespace("users")
+		other := cache.Namespace("tenant:7")
+
+		tenant.Put("plan", "pro")
+		users.Put("1", "ada")
+		other.Put("plan", "free")
+		assert.Equal(t, 2, tenant.Len())
+
+		tenant.Invalidate()
+		assert.False(t, tenant.Contains("plan"))
+		assert.False(t, users.Contains("1"))
+		assert.True(t, other.Contains("plan"))
+		assert.Equal(t, 0, tenant.Len())
+		assert.Equal(t, 0, users.Len())
+
+		users.Put("1", "grace")
+		val, ok := users.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, "grace", val)
+
+		users.Clear()
+		assert.False(t, users.Contains("1"))
+		// Invalidated entries are left for the wrapped cache to evict.
+		assert.Equal(t, 4, cache.Len())
+	})
+
+	t.Run("remove prefix", func(t *testing.T) {
+		cache := NewNamespaced(NewCache(100, LRU))
+		for i := 0; i < 5; i++ {
+			cache.Put("session:"+strconv.Itoa(i), i)
+		}
+		cache.Put("user:1", 1)
+		tenant := cache.Namespace("tenant")
+		tenant.Put("session:1", 1)
+		tenant.Put("user:1", 1)
+
+		assert.Equal(t, 5, cache.RemovePrefix("session:"))
+		assert.Equal(t, 0, cache.RemovePrefix("session:"))
+		assert.False(t, cache.Contains("session:3"))
+		assert.True(t, cache.Contains("user:1"))
+		assert.True(t, tenant.Contains("session:1"))
+
+		assert.Equal(t, 1, tenant.RemovePrefix("session:"))
+		assert.False(t, tenant.Contains("session:1"))
+		assert.True(t, tenant.Contains("user:1"))
+		assert.Equal(t, 2, cache.Len())
+
+		assert.Equal(t, 2, cache.RemovePrefix(""))
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("the index follows evictions and expirations", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		expiring := NewExpiring(NewCache(2, LRU), WithExpiringClock(clock))
+		cache := NewNamespaced(expiring)
+		tenant := cache.Namespace("tenant")
+
+		tenant.Put("1", 1)
+		tenant.Put("2", 2)
+		tenant.Put("3", 3)
+		assert.Equal(t, 2, tenant.Len())
+
+		expiring.Touch(tenant.key("3"), time.Second)
+		clock.Advance(time.Minute)
+		assert.False(t, tenant.Contains("3"))
+		assert.Equal(t, 1, tenant.Len())
+
+		cache.Clear()
+		assert.Equal(t, 0, tenant.Len())
+	})
+}
+
+func TestNamespaced_Tagger(t *testing.T) {
+	cache := NewNamespaced(NewCache(10, LRU))
+	tenant := cache.Namespace("tenant")
+	cache.PutWithTags("a", 1, "t")
+	tenant.PutWithTags("a", 2, "t")
+	tenant.Put("b", 3)
+
+	assert.Len(t, cache.TaggedKeys("t"), 2)
+	assert.Equal(t, 2, cache.InvalidateTag("t"))
+	assert.False(t, cache.Contains("a"))
+	assert.False(t, tenant.Contains("a"))
+	// The index follows the removals.
+	assert.Equal(t, 1, tenant.Len())
+	assert.Equal(t, 1, cache.RemovePrefix(""))
+}
@@ -0,0 +1,193 @@
+package gofast
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by a LoaderFunc for keys that do not exist in the
+// source of truth. Negative caches remember such keys for a while.
+var ErrNotFound = errors.New("gofast: not found")
+
+// Presence tells whether a Negative cache knows about a key.
+type Presence int
+
+const (
+	// NotCached means the cache knows nothing about the key.
+	NotCached Presence = iota
+	// Cached means the cache holds a value for the key.
+	Cached
+	// CachedAbsent means the cache holds a tombstone for the key: it was
+	// recently found not to exist.
+	CachedAbsent
+)
+
+// String returns the name of the presence.
+func (p Presence) String() string {
+	switch p {
+	case Cached:
+		return "cached"
+	case CachedAbsent:
+		return "cached absent"
+	default:
+		return "not cached"
+	}
+}
+
+// NegativeOption configures a Negative cache.
+type NegativeOption func(n *Negative)
+
+// WithNegativeClock sets the clock telling when tombstones expire. Defaults to RealClock.
+func WithNegativeClock(clock Clock) NegativeOption {
+	return func(n *Negative) {
+		n.clock = clock
+	}
+}
+
+// Negative wraps a Cache and also caches the keys that do not exist, so they
+// are not looked up again on every request.
+//
+// When a loader returns ErrNotFound, a tombstone is stored for the key, which
+// expires after its own time to live, usually shorter than that of the values.
+// Tombstones are kept in a separate cache: they use their own capacity rather
+// than that of the values, and have their own stats.
+type Negative struct {
+	cache      Cache
+	tombstones *Expiring
+	ttl        time.Duration
+	clock      Clock
+}
+
+// NewNegative returns a cache storing its values in c and its tombstones in
+// tombstones, where they expire after ttl, or never if ttl is 0.
+func NewNegative(c, tombstones Cache, ttl time.Duration, opts ...NegativeOption) *Negative {
+	n := &Negative{
+		cache: c,
+		ttl:   ttl,
+		clock: RealClock(),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	n.tombstones = NewExpiring(tombstones, WithExpiringClock(n.clock))
+	return n
+}
+
+// Lookup returns the value of key if it is cached, and whether the cache holds
+// a value, a tombstone or nothing for key.
+func (n *Negative) Lookup(key string) (any, Presence) {
+	if val, ok := n.cache.Get(key); ok {
+		return val, Cached
+	}
+	if _, ok := n.tombstones.Get(key); ok {
+		return nil, CachedAbsent
+	}
+	return nil, NotCached
+}
+
+// Get retrieves a value from the cache for a specific key. It returns false
+// for tombstones; use Lookup to tell them apart from missing keys.
+func (n *Negative) Get(key string) (any, bool) {
+	val, presence := n.Lookup(key)
+	return val, presence == Cached
+}
+
+// GetCtx retrieves a value from the cache unless ctx is already done. It
+// returns ErrNotFound for tombstones.
+func (n *Negative) GetCtx(ctx context.Context, key string) (any, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	val, presence := n.Lookup(key)
+	if presence == CachedAbsent {
+		return nil, false, ErrNotFound
+	}
+	return val, presence == Cached, nil
+}
+
+// Put adds a new key-value pair to the cache, replacing any tombstone of key.
+func (n *Negative) Put(key string, val any) {
+	n.tombstones.Remove(key)
+	n.cache.Put(key, val)
+}
+
+// PutCtx adds a new key-value pair to the cache unless ctx is already done.
+func (n *Negative) PutCtx(ctx context.Context, key string, val any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	n.Put(key, val)
+	return nil
+}
+
+// PutAbsent stores a tombstone for key, replacing any value of key.
+func (n *Negative) PutAbsent(key string) {
+	n.cache.Remove(key)
+	n.tombstones.PutWithTTL(key, struct{}{}, n.ttl)
+}
+
+// GetOrLoadCtx returns the cached value of key or loads it. It returns
+// ErrNotFound without calling load if key has a tombstone, and stores a
+// tombstone if load returns an error wrapping ErrNotFound.
+func (n *Negative) GetOrLoadCtx(ctx context.Context, key string, load LoaderFunc) (any, error) {
+	val, ok, err := n.GetCtx(ctx, key)
+	if err != nil || ok {
+		return val, err
+	}
+	return loadCtx(ctx, n, key, func(ctx context.Context, key string) (any, error) {
+		val, err := load(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			n.PutAbsent(key)
+		}
+		return val, err
+	})
+}
+
+// Remove deletes the value or the tombstone of key.
+func (n *Negative) Remove(key string) {
+	n.cache.Remove(key)
+	n.tombstones.Remove(key)
+}
+
+// Len returns the number of values in the cache, not counting tombstones.
+func (n *Negative) Len() int {
+	return n.cache.Len()
+}
+
+// Tombstones returns the number of tombstones in the cache, including expired
+// tombstones that were not read since they expired.
+func (n *Negative) Tombstones() int {
+	return n.tombstones.Len()
+}
+
+// Clear removes all values and tombstones from the cache.
+func (n *Negative) Clear() {
+	n.cache.Clear()
+	n.tombstones.Clear()
+}
+
+// Contains checks if a value is present in the cache for key.
+func (n *Negative) Contains(key string) bool {
+	return n.cache.Contains(key)
+}
+
+// Stats returns the stats of the values, or zero stats if the wrapped cache
+// does not implement StatsReporter.
+func (n *Negative) Stats() Stats {
+	if reporter, ok := n.cache.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+// NegativeStats returns the stats of the tombstones: hits are the lookups that
+// found a tombstone, and expirations the tombstones dropped after their ttl.
+func (n *Negative) NegativeStats() Stats {
+	return n.tombstones.Stats()
+}
+
+// Unwrap returns the cache of the values.
+func (n *Negative) Unwrap() Cache {
+	return n.cache
+}
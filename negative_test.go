@@ -0,0 +1,104 @@
+package gofast
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ContextCache = (*Negative)(nil)
+
+func TestNegative(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	load := func(ctx context.Context, key string) (any, error) {
+		calls++
+		if key == "missing" {
+			return nil, fmt.Errorf("user %s: %w", key, ErrNotFound)
+		}
+		return "value of " + key, nil
+	}
+
+	t.Run("tombstones", func(t *testing.T) {
+		calls = 0
+		cache := NewNegative(NewCache(10, LRU), NewCache(10, LRU), time.Hour)
+
+		_, presence := cache.Lookup("missing")
+		assert.Equal(t, NotCached, presence)
+		for i := 0; i < 3; i++ {
+			_, err := cache.GetOrLoadCtx(ctx, "missing", load)
+			assert.ErrorIs(t, err, ErrNotFound)
+		}
+		assert.Equal(t, 1, calls)
+
+		_, presence = cache.Lookup("missing")
+		assert.Equal(t, CachedAbsent, presence)
+		_, ok := cache.Get("missing")
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.Len())
+		assert.Equal(t, 1, cache.Tombstones())
+
+		val, err := cache.GetOrLoadCtx(ctx, "present", load)
+		require.NoError(t, err)
+		assert.Equal(t, "value of present", val)
+		val, presence = cache.Lookup("present")
+		assert.Equal(t, Cached, presence)
+		assert.Equal(t, "value of present", val)
+	})
+
+	t.Run("tombstones expire", func(t *testing.T) {
+		calls = 0
+		clock := NewFakeClock(time.Now())
+		cache := NewNegative(NewCache(10, LRU), NewCache(10, LRU), 10*time.Millisecond, WithNegativeClock(clock))
+		_, err := cache.GetOrLoadCtx(ctx, "missing", load)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		clock.Advance(20 * time.Millisecond)
+		_, presence := cache.Lookup("missing")
+		assert.Equal(t, NotCached, presence)
+		_, err = cache.GetOrLoadCtx(ctx, "missing", load)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, uint64(1), cache.NegativeStats().Expirations)
+	})
+
+	t.Run("values and tombstones replace each other", func(t *testing.T) {
+		cache := NewNegative(NewCache(10, LRU), NewCache(10, LRU), time.Hour)
+		cache.PutAbsent("a")
+		cache.Put("a", 1)
+		_, presence := cache.Lookup("a")
+		assert.Equal(t, Cached, presence)
+
+		cache.PutAbsent("a")
+		_, presence = cache.Lookup("a")
+		assert.Equal(t, CachedAbsent, presence)
+		assert.False(t, cache.Contains("a"))
+
+		cache.Remove("a")
+		_, presence = cache.Lookup("a")
+		assert.Equal(t, NotCached, presence)
+	})
+
+	t.Run("separate capacity and stats", func(t *testing.T) {
+		cache := NewNegative(NewCache(2, LRU), NewCache(1, LRU), time.Hour)
+		cache.Put("a", 1)
+		cache.Put("b", 2)
+		cache.PutAbsent("x")
+		cache.PutAbsent("y")
+		assert.Equal(t, 2, cache.Len())
+		assert.Equal(t, 1, cache.Tombstones())
+
+		cache.Lookup("a")
+		cache.Lookup("y")
+		assert.Equal(t, uint64(1), cache.Stats().Hits)
+		assert.Equal(t, uint64(1), cache.NegativeStats().Hits)
+		assert.Equal(t, uint64(1), cache.NegativeStats().Evictions)
+
+		cache.Clear()
+		assert.Equal(t, 0, cache.Len()+cache.Tombstones())
+	})
+}
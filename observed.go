@@ -0,0 +1,281 @@
+package gofast
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the kind of change an Event describes.
+type EventType int
+
+const (
+	// EventPut is a new key put in the cache.
+	EventPut EventType = iota + 1
+	// EventUpdate is a new value put for a key already in the cache.
+	EventUpdate
+	// EventRemove is a key removed from the cache.
+	EventRemove
+	// EventEvict is an entry evicted to make room for a new one.
+	EventEvict
+	// EventExpire is an entry dropped because its time to live ran out.
+	EventExpire
+	// EventClear is the removal of every key.
+	EventClear
+)
+
+// eventTypeNames maps each event type to its display name.
+var eventTypeNames = map[EventType]string{
+	EventPut:    "put",
+	EventUpdate: "update",
+	EventRemove: "remove",
+	EventEvict:  "evict",
+	EventExpire: "expire",
+	EventClear:  "clear",
+}
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return "EventType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Event is a change to an Observed cache.
+type Event struct {
+	Type EventType
+	// Key is empty for EventClear.
+	Key string
+	// Value is the new value for EventPut and EventUpdate, the dropped value
+	// for EventEvict and EventExpire, and nil otherwise.
+	Value any
+}
+
+// Observed wraps a Cache and sends its changes to subscribers.
+//
+// Events are sent without blocking: each subscriber has a buffered channel,
+// and an event that does not fit in it is dropped for that subscriber, which
+// keeps receiving the later events. Dropped counts those events; a subscriber
+// that must not miss any should use a buffer large enough for its bursts and
+// resynchronize from the cache when Dropped grows.
+//
+// The changes made directly to the wrapped cache are not seen, except its
+// evictions and expirations when it implements EvictionNotifier and
+// ExpirationNotifier.
+type Observed struct {
+	// dropped comes first to be 64-bit aligned for the atomic package.
+	dropped uint64
+
+	cache Cache
+	ttl   ttlCache
+
+	// mu makes telling puts from updates atomic with respect to other writes.
+	mu *sync.Mutex
+
+	subMu       *sync.RWMutex
+	subscribers map[chan Event]struct{}
+}
+
+// ttlCache is implemented by caches supporting per-entry time to live, such as Expiring.
+type ttlCache interface {
+	PutWithTTL(key string, val any, ttl time.Duration)
+}
+
+// NewObserved returns an observed cache storing its entries in c.
+func NewObserved(c Cache) *Observed {
+	o := &Observed{
+		cache:       c,
+		mu:          &sync.Mutex{},
+		subMu:       &sync.RWMutex{},
+		subscribers: make(map[chan Event]struct{}),
+	}
+	o.ttl, _ = c.(ttlCache)
+	if notifier, ok := c.(EvictionNotifier); ok {
+		notifier.AddEvictionHook(func(key string, val any) {
+			o.publish(Event{Type: EventEvict, Key: key, Value: val})
+		})
+	}
+	if notifier, ok := c.(ExpirationNotifier); ok {
+		notifier.AddExpirationHook(func(key string, val any) {
+			o.publish(Event{Type: EventExpire, Key: key, Value: val})
+		})
+	}
+	return o
+}
+
+// Subscribe returns a channel receiving the changes to the cache, buffering up
+// to buffer events, and a function to cancel the subscription, which closes
+// the channel. See Observed for what happens when the buffer is full.
+func (o *Observed) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	o.subMu.Lock()
+	o.subscribers[ch] = struct{}{}
+	o.subMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			o.subMu.Lock()
+			delete(o.subscribers, ch)
+			o.subMu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Dropped returns the number of events dropped because a subscriber's buffer was full.
+func (o *Observed) Dropped() uint64 {
+	return atomic.LoadUint64(&o.dropped)
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (o *Observed) Get(key string) (any, bool) {
+	return o.cache.Get(key)
+}
+
+// Put adds a new key-value pair to the cache.
+func (o *Observed) Put(key string, val any) {
+	o.put(key, val, func() { o.cache.Put(key, val) })
+}
+
+// PutWithTTL adds a new key-value pair to the cache that expires after ttl, or
+// never if ttl is 0 or less. ttl is ignored if the wrapped cache does not
+// support per-entry time to live, as Expiring does.
+func (o *Observed) PutWithTTL(key string, val any, ttl time.Duration) {
+	if o.ttl == nil {
+		o.Put(key, val)
+		return
+	}
+	o.put(key, val, func() { o.ttl.PutWithTTL(key, val, ttl) })
+}
+
+// PutWithTags adds a new key-value pair to the cache carrying tags. The tags
+// are dropped if the wrapped cache does not implement Tagger.
+func (o *Observed) PutWithTags(key string, val any, tags ...string) {
+	o.put(key, val, func() { putWithTags(o.cache, key, val, tags) })
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (o *Observed) Remove(key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.remove(key)
+}
+
+// InvalidateTag removes every entry carrying tag, publishing a removal for
+// each, and returns the number of entries removed. It returns 0 if the
+// wrapped cache does not implement Tagger.
+func (o *Observed) InvalidateTag(tag string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	removed := 0
+	for _, key := range taggedKeys(o.cache, tag) {
+		if o.remove(key) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// TaggedKeys returns the keys carrying tag, or nil if the wrapped cache does
+// not implement Tagger.
+func (o *Observed) TaggedKeys(tag string) []string {
+	return taggedKeys(o.cache, tag)
+}
+
+// Len returns the number of items in the cache.
+func (o *Observed) Len() int {
+	return o.cache.Len()
+}
+
+// Clear removes all items from the cache.
+func (o *Observed) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.cache.Clear()
+	o.publish(Event{Type: EventClear})
+}
+
+// Contains checks if a key is present in the cache.
+func (o *Observed) Contains(key string) bool {
+	return o.cache.Contains(key)
+}
+
+// Stats returns the stats of the wrapped cache, or zero stats if it does not
+// implement StatsReporter.
+func (o *Observed) Stats() Stats {
+	if reporter, ok := o.cache.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+// AddEvictionHook registers fn to be called with every entry the wrapped cache
+// evicts to make room for a new one. It does nothing if the wrapped cache does
+// not implement EvictionNotifier.
+func (o *Observed) AddEvictionHook(fn func(key string, val any)) {
+	if notifier, ok := o.cache.(EvictionNotifier); ok {
+		notifier.AddEvictionHook(fn)
+	}
+}
+
+// Limit returns the limit of the wrapped cache, or 0 if it does not implement Inspector.
+func (o *Observed) Limit() int {
+	if inspector, ok := o.cache.(Inspector); ok {
+		return inspector.Limit()
+	}
+	return 0
+}
+
+// Keys returns the keys of the wrapped cache in eviction order, or nil if it
+// does not implement Inspector.
+func (o *Observed) Keys() []string {
+	if inspector, ok := o.cache.(Inspector); ok {
+		return inspector.Keys()
+	}
+	return nil
+}
+
+// Unwrap returns the wrapped cache.
+func (o *Observed) Unwrap() Cache {
+	return o.cache
+}
+
+// put calls store and publishes a put or update of key.
+func (o *Observed) put(key string, val any, store func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	typ := EventPut
+	if o.cache.Contains(key) {
+		typ = EventUpdate
+	}
+	store()
+	o.publish(Event{Type: typ, Key: key, Value: val})
+}
+
+// remove deletes key and publishes its removal, and reports whether it was
+// in the cache. o.mu must be held.
+func (o *Observed) remove(key string) bool {
+	if !o.cache.Contains(key) {
+		return false
+	}
+	o.cache.Remove(key)
+	o.publish(Event{Type: EventRemove, Key: key})
+	return true
+}
+
+// publish sends e to every subscriber that has room for it. It may be called
+// with the wrapped cache's lock held, from a hook.
+func (o *Observed) publish(e Event) {
+	o.subMu.RLock()
+	defer o.subMu.RUnlock()
+	for ch := range o.subscribers {
+		select {
+		case ch <- e:
+		default:
+			atomic.AddUint64(&o.dropped, 1)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package gofast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// drain returns the events buffered in ch.
+func drain(ch <-chan Event) []Event {
+	var events []Event
+	for {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestObserved(t *testing.T) {
+	t.Run("events", func(t *testing.T) {
+		cache := NewObserved(NewCache(2, LRU))
+		events, cancel := cache.Subscribe(10)
+		defer cancel()
+
+		cache.Put("1", 1)
+		cache.Put("1", 2)
+		cache.Put("2", 2)
+		cache.Put("3", 3)
+		cache.Remove("2")
+		cache.Remove("missing")
+		cache.Clear()
+
+		assert.Equal(t, []Event{
+			{Type: EventPut, Key: "1", Value: 1},
+			{Type: EventUpdate, Key: "1", Value: 2},
+			{Type: EventPut, Key: "2", Value: 2},
+			{Type: EventEvict, Key: "1", Value: 2},
+			{Type: EventPut, Key: "3", Value: 3},
+			{Type: EventRemove, Key: "2"},
+			{Type: EventClear},
+		}, drain(events))
+	})
+
+	t.Run("expirations", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		cache := NewObserved(NewExpiring(NewCache(10, LRU), WithExpiringClock(clock)))
+		events, cancel := cache.Subscribe(10)
+		defer cancel()
+
+		cache.PutWithTTL("1", 1, 10*time.Millisecond)
+		clock.Advance(20 * time.Millisecond)
+		_, ok := cache.Get("1")
+		assert.False(t, ok)
+
+		assert.Equal(t, []Event{
+			{Type: EventPut, Key: "1", Value: 1},
+			{Type: EventExpire, Key: "1", Value: 1},
+		}, drain(events))
+	})
+
+	t.Run("slow subscribers miss events", func(t *testing.T) {
+		cache := NewObserved(NewCache(10, LRU))
+		slow, cancelSlow := cache.Subscribe(1)
+		defer cancelSlow()
+		fast, cancelFast := cache.Subscribe(10)
+		defer cancelFast()
+
+		cache.Put("1", 1)
+		cache.Put("2", 2)
+		cache.Put("3", 3)
+
+		assert.Len(t, drain(slow), 1)
+		assert.Len(t, drain(fast), 3)
+		assert.Equal(t, uint64(2), cache.Dropped())
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		cache := NewObserved(NewCache(10, LRU))
+		events, cancel := cache.Subscribe(10)
+		cancel()
+		cancel()
+
+		cache.Put("1", 1)
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+}
+
+func TestObserved_Tagger(t *testing.T) {
+	cache := NewObserved(NewExpiring(NewCache(10, LRU)))
+	events, cancel := cache.Subscribe(10)
+	defer cancel()
+
+	cache.PutWithTags("1", 1, "t")
+	cache.PutWithTags("2", 2, "t")
+	cache.Put("3", 3)
+	assert.Equal(t, 2, cache.InvalidateTag("t"))
+	assert.Equal(t, 0, cache.InvalidateTag("t"))
+
+	removed := []Event{{Type: EventRemove, Key: "1"}, {Type: EventRemove, Key: "2"}}
+	assert.ElementsMatch(t, removed, drain(events)[3:])
+	assert.Equal(t, 1, cache.Len())
+}
@@ -0,0 +1,261 @@
+// Package pressure shrinks caches when the process runs short of memory, to
+// keep it from being killed during a spike, and lets them grow back once the
+// pressure drops.
+//
+// A Controller samples the memory usage of the process from a Source, such as
+// the Go heap or the cgroup of a container, and compares it with a soft
+// target. Above the target it shrinks the registered caches in proportion,
+// by resizing them with gofast.Resize, at most once per garbage collection;
+// below it they regrow step by step up to the limits they were registered with.
+package pressure
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/raghavgh/gofast"
+)
+
+const (
+	// DefaultInterval is the default time between two samples of memory usage.
+	DefaultInterval = time.Second
+	// DefaultTargetRatio is the default target, as a fraction of the memory
+	// limit reported by the Source.
+	DefaultTargetRatio = 0.8
+	// DefaultMinScale is the default fraction of their limits the caches
+	// keep under pressure.
+	DefaultMinScale = 0.1
+)
+
+// growStep is the fraction of their limits the caches regrow by per sample
+// while memory usage stays below the target.
+const growStep = 0.1
+
+var (
+	// ErrNoTarget is returned by Check when no target was set with WithTarget
+	// and the Source reports no memory limit to derive one from.
+	ErrNoTarget = errors.New("pressure: no memory target")
+	// ErrNotResizable is returned by Register when the cache cannot be resized.
+	ErrNotResizable = errors.New("pressure: cache is not resizable")
+)
+
+// Option configures a Controller.
+type Option func(c *Controller)
+
+// WithTarget sets the soft target of memory usage in bytes. By default the
+// target is DefaultTargetRatio of the limit reported by the Source.
+func WithTarget(bytes uint64) Option {
+	return func(c *Controller) {
+		c.target = bytes
+	}
+}
+
+// WithTargetRatio sets the target, as a fraction of the memory limit reported
+// by the Source, used when no target is set with WithTarget. Defaults to
+// DefaultTargetRatio.
+func WithTargetRatio(ratio float64) Option {
+	return func(c *Controller) {
+		c.targetRatio = ratio
+	}
+}
+
+// WithInterval sets the time between two samples of memory usage. Defaults to
+// DefaultInterval.
+func WithInterval(d time.Duration) Option {
+	return func(c *Controller) {
+		c.interval = d
+	}
+}
+
+// WithMinScale sets the fraction of their limits the caches keep however
+// high the pressure. Defaults to DefaultMinScale.
+func WithMinScale(scale float64) Option {
+	return func(c *Controller) {
+		c.minScale = scale
+	}
+}
+
+// WithClock sets the clock timing the samples. Defaults to gofast.RealClock.
+func WithClock(clock gofast.Clock) Option {
+	return func(c *Controller) {
+		c.clock = clock
+	}
+}
+
+// WithErrorHandler sets fn to be called with the errors of the periodic
+// samples. By default those errors are dropped.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(c *Controller) {
+		c.onError = fn
+	}
+}
+
+// Controller resizes the registered caches according to the memory usage of
+// the process. All the caches are scaled by the same fraction of the limits
+// they were registered with, so they shrink and regrow in proportion.
+type Controller struct {
+	source      Source
+	target      uint64
+	targetRatio float64
+	interval    time.Duration
+	minScale    float64
+	clock       gofast.Clock
+	onError     func(err error)
+
+	mu     *sync.Mutex
+	scale  float64
+	caches map[*registered]struct{}
+	timer  gofast.Timer
+	closed bool
+	// shrunk reports whether the caches were shrunk in shrunkEpoch.
+	shrunk      bool
+	shrunkEpoch uint64
+}
+
+// registered is a cache resized by a Controller.
+type registered struct {
+	cache gofast.Cache
+	// limit is the limit of the cache when it was registered, and current
+	// the limit the controller last resized it to.
+	limit, current int
+}
+
+// NewController returns a controller sampling source every interval until
+// Close is called. It panics if the interval is not positive.
+func NewController(source Source, opts ...Option) *Controller {
+	c := &Controller{
+		source:      source,
+		targetRatio: DefaultTargetRatio,
+		interval:    DefaultInterval,
+		minScale:    DefaultMinScale,
+		clock:       gofast.RealClock(),
+		mu:          &sync.Mutex{},
+		scale:       1,
+		caches:      make(map[*registered]struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.interval <= 0 {
+		panic("pressure: interval must be greater than 0")
+	}
+	c.timer = c.clock.AfterFunc(c.interval, c.tick)
+	return c
+}
+
+// Register resizes cache along with the other registered caches until
+// unregister is called, which restores its limit. cache, or a cache it wraps,
+// must implement gofast.Resizer, and it must report its limit through
+// gofast.Inspector; the limit it has when registered is the one it regrows
+// to. Its limit must not be changed other than through the controller meanwhile.
+func (c *Controller) Register(cache gofast.Cache) (unregister func(), err error) {
+	inspector, ok := cache.(gofast.Inspector)
+	if !ok || inspector.Limit() <= 0 {
+		return nil, ErrNotResizable
+	}
+	r := &registered{cache: cache, limit: inspector.Limit()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r.current = r.scaled(c.scale)
+	if !gofast.Resize(cache, r.current) {
+		return nil, ErrNotResizable
+	}
+	c.caches[r] = struct{}{}
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, ok := c.caches[r]; !ok {
+			return
+		}
+		delete(c.caches, r)
+		gofast.Resize(r.cache, r.limit)
+	}, nil
+}
+
+// Scale returns the fraction of their limits the registered caches are
+// currently resized to, 1 when there is no pressure.
+func (c *Controller) Scale() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.scale
+}
+
+// Check samples the memory usage and resizes the caches accordingly, as the
+// controller does every interval. Above the target, the caches shrink by the
+// ratio of the target to the usage, once per epoch of the samples; below it,
+// with a margin, they regrow by a tenth of their limits.
+func (c *Controller) Check() error {
+	usage, err := c.source.Usage()
+	if err != nil {
+		return err
+	}
+	target := c.target
+	if target == 0 {
+		if usage.Limit == 0 {
+			return ErrNoTarget
+		}
+		target = uint64(float64(usage.Limit) * c.targetRatio)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	scale := c.scale
+	switch {
+	case usage.Used > target:
+		// Until the next garbage collection, the usage still counts the
+		// entries the last shrink evicted.
+		if c.shrunk && usage.Epoch == c.shrunkEpoch {
+			return nil
+		}
+		c.shrunk, c.shrunkEpoch = true, usage.Epoch
+		scale *= float64(target) / float64(usage.Used)
+	case float64(usage.Used) < float64(target)*(1-growStep):
+		scale += growStep
+	}
+	if scale < c.minScale {
+		scale = c.minScale
+	}
+	if scale > 1 {
+		scale = 1
+	}
+	c.scale = scale
+
+	for r := range c.caches {
+		if limit := r.scaled(scale); limit != r.current {
+			gofast.Resize(r.cache, limit)
+			r.current = limit
+		}
+	}
+	return nil
+}
+
+// Close stops sampling. The caches keep their current limits.
+func (c *Controller) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.timer.Stop()
+}
+
+// tick runs a periodic check and schedules the next one.
+func (c *Controller) tick() {
+	if err := c.Check(); err != nil && c.onError != nil {
+		c.onError(err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.timer.Reset(c.interval)
+	}
+}
+
+// scaled returns the limit of the cache scaled by scale, at least 1.
+func (r *registered) scaled(scale float64) int {
+	limit := int(float64(r.limit) * scale)
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
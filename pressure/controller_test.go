@@ -0,0 +1,179 @@
+package pressure
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raghavgh/gofast"
+)
+
+// fakeSource is a Source reporting the usage it is set to.
+type fakeSource struct {
+	mu    sync.Mutex
+	usage Usage
+	err   error
+}
+
+func (s *fakeSource) set(used, limit uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage.Used, s.usage.Limit = used, limit
+}
+
+// collect starts a new epoch, as a garbage collection does.
+func (s *fakeSource) collect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage.Epoch++
+}
+
+func (s *fakeSource) Usage() (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage, s.err
+}
+
+// fill puts n items in c.
+func fill(c gofast.Cache, n int) {
+	for i := 0; i < n; i++ {
+		c.Put(strconv.Itoa(i), i)
+	}
+}
+
+func TestController(t *testing.T) {
+	t.Run("shrinks and regrows", func(t *testing.T) {
+		source := &fakeSource{}
+		clock := gofast.NewFakeClock(time.Now())
+		controller := NewController(source, WithClock(clock), WithInterval(time.Second))
+		t.Cleanup(controller.Close)
+
+		lru := gofast.NewCache(1000, gofast.LRU)
+		wrapped := gofast.NewExpiring(gofast.NewCache(100, gofast.LFU))
+		_, err := controller.Register(lru)
+		require.NoError(t, err)
+		_, err = controller.Register(wrapped)
+		require.NoError(t, err)
+		fill(lru, 1000)
+		fill(wrapped, 100)
+
+		// The target is 80% of the limit: 800 bytes.
+		source.set(500, 1000)
+		clock.Advance(time.Second)
+		assert.Equal(t, 1.0, controller.Scale())
+		assert.Equal(t, 1000, lru.Len())
+
+		source.set(1600, 1000)
+		clock.Advance(time.Second)
+		assert.Equal(t, 0.5, controller.Scale())
+		assert.Equal(t, 500, lru.Len())
+		assert.Equal(t, 500, lru.(gofast.Inspector).Limit())
+		assert.Equal(t, 50, wrapped.Len())
+
+		// Close to the target, the caches keep their size.
+		source.set(750, 1000)
+		clock.Advance(time.Second)
+		assert.Equal(t, 0.5, controller.Scale())
+
+		source.set(100, 1000)
+		for i := 0; i < 10; i++ {
+			clock.Advance(time.Second)
+		}
+		assert.Equal(t, 1.0, controller.Scale())
+		assert.Equal(t, 1000, lru.(gofast.Inspector).Limit())
+		assert.Equal(t, 100, wrapped.Limit())
+	})
+
+	t.Run("shrinks once per epoch", func(t *testing.T) {
+		source := &fakeSource{}
+		clock := gofast.NewFakeClock(time.Now())
+		controller := NewController(source, WithClock(clock), WithTarget(100))
+		t.Cleanup(controller.Close)
+		cache := gofast.NewCache(100, gofast.LRU)
+		_, err := controller.Register(cache)
+		require.NoError(t, err)
+
+		// The usage stays over the target until a collection frees the
+		// evicted entries.
+		source.set(200, 0)
+		for i := 0; i < 5; i++ {
+			clock.Advance(DefaultInterval)
+		}
+		assert.Equal(t, 0.5, controller.Scale())
+		assert.Equal(t, 50, cache.(gofast.Inspector).Limit())
+
+		source.collect()
+		source.set(125, 0)
+		for i := 0; i < 5; i++ {
+			clock.Advance(DefaultInterval)
+		}
+		assert.Equal(t, 0.4, controller.Scale())
+		assert.Equal(t, 40, cache.(gofast.Inspector).Limit())
+	})
+
+	t.Run("min scale", func(t *testing.T) {
+		source := &fakeSource{}
+		controller := NewController(source, WithTarget(100), WithMinScale(0.25))
+		t.Cleanup(controller.Close)
+		cache := gofast.NewCache(8, gofast.FIFO)
+		_, err := controller.Register(cache)
+		require.NoError(t, err)
+
+		source.set(1e6, 0)
+		require.NoError(t, controller.Check())
+		assert.Equal(t, 0.25, controller.Scale())
+		assert.Equal(t, 2, cache.(gofast.Inspector).Limit())
+	})
+
+	t.Run("register and unregister", func(t *testing.T) {
+		source := &fakeSource{}
+		controller := NewController(source, WithTarget(100))
+		t.Cleanup(controller.Close)
+		source.set(200, 0)
+		require.NoError(t, controller.Check())
+
+		// Caches registered under pressure are shrunk at once.
+		cache := gofast.NewCache(10, gofast.SIEVE)
+		fill(cache, 10)
+		unregister, err := controller.Register(cache)
+		require.NoError(t, err)
+		assert.Equal(t, 5, cache.Len())
+
+		unregister()
+		unregister()
+		assert.Equal(t, 10, cache.(gofast.Inspector).Limit())
+		source.set(400, 0)
+		require.NoError(t, controller.Check())
+		assert.Equal(t, 10, cache.(gofast.Inspector).Limit())
+
+		_, err = controller.Register(struct{ gofast.Cache }{cache})
+		assert.ErrorIs(t, err, ErrNotResizable)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		source := &fakeSource{}
+		clock := gofast.NewFakeClock(time.Now())
+		var errs []error
+		controller := NewController(source, WithClock(clock), WithErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+
+		// Without WithTarget, the source must report a limit.
+		source.set(100, 0)
+		clock.Advance(DefaultInterval)
+		source.err = errors.New("unavailable")
+		clock.Advance(DefaultInterval)
+		require.Len(t, errs, 2)
+		assert.ErrorIs(t, errs[0], ErrNoTarget)
+		assert.EqualError(t, errs[1], "unavailable")
+
+		controller.Close()
+		clock.Advance(time.Hour)
+		assert.Len(t, errs, 2)
+	})
+}
@@ -0,0 +1,199 @@
+package pressure
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+)
+
+// DefaultCgroupDir is where the cgroup file system of the process is usually
+// mounted in a container.
+const DefaultCgroupDir = "/sys/fs/cgroup"
+
+// Usage is a sample of the memory usage of the process.
+type Usage struct {
+	// Used is the number of bytes in use.
+	Used uint64
+	// Limit is the number of bytes the process may use, or 0 if it is unknown
+	// or unlimited.
+	Limit uint64
+	// Epoch is the number of garbage collections the sample accounts for. The
+	// memory of evicted entries is only freed by the next collection, so a
+	// Controller shrinks the caches at most once per epoch.
+	Epoch uint64
+}
+
+// Source samples the memory usage of the process.
+type Source interface {
+	// Usage returns the current memory usage.
+	Usage() (Usage, error)
+}
+
+// SourceFunc adapts a function to Source.
+type SourceFunc func() (Usage, error)
+
+// Usage calls f.
+func (f SourceFunc) Usage() (Usage, error) {
+	return f()
+}
+
+// Heap returns a source sampling the heap of the Go runtime with
+// runtime/metrics: the live heap as of the last garbage collection where the
+// runtime reports it, and otherwise the bytes of heap objects, which include
+// the unreachable objects not collected yet. Its limit is the soft memory
+// limit of the runtime (see runtime/debug.SetMemoryLimit) where it reports one.
+// The epoch of the samples is the number of collections run.
+func Heap() Source {
+	used := "/memory/classes/heap/objects:bytes"
+	limit := ""
+	for _, desc := range metrics.All() {
+		switch desc.Name {
+		case "/gc/heap/live:bytes":
+			used = desc.Name
+		case "/gc/gomemlimit:bytes":
+			limit = desc.Name
+		}
+	}
+	return heapSource{used: used, limit: limit}
+}
+
+// heapSource is the Source returned by Heap.
+type heapSource struct {
+	used, limit string
+}
+
+func (s heapSource) Usage() (Usage, error) {
+	samples := []metrics.Sample{{Name: s.used}, {Name: gcCycles}}
+	if s.limit != "" {
+		samples = append(samples, metrics.Sample{Name: s.limit})
+	}
+	metrics.Read(samples)
+
+	var usage Usage
+	if samples[0].Value.Kind() != metrics.KindUint64 {
+		return usage, fmt.Errorf("pressure: runtime metric %s is not supported", s.used)
+	}
+	usage.Used = samples[0].Value.Uint64()
+	usage.Epoch = samples[1].Value.Uint64()
+	// The runtime reports math.MaxInt64 when there is no memory limit.
+	if len(samples) > 2 && samples[2].Value.Kind() == metrics.KindUint64 && samples[2].Value.Uint64() < math.MaxInt64 {
+		usage.Limit = samples[2].Value.Uint64()
+	}
+	return usage, nil
+}
+
+// Cgroup returns a source reading the memory usage and limit of the cgroup
+// mounted at dir, usually DefaultCgroupDir. Both cgroup v2 and the memory
+// controller of cgroup v1 are supported. Like the kubelet, it reports the
+// working set: the memory in use minus the inactive page cache, which the
+// kernel reclaims before running out of memory. The epoch of the samples is
+// the number of collections run by the Go runtime.
+func Cgroup(dir string) Source {
+	return cgroupSource{dir: dir}
+}
+
+// cgroupSource is the Source returned by Cgroup.
+type cgroupSource struct {
+	dir string
+}
+
+// cgroupFiles are the files holding the usage, limit and statistics of a
+// cgroup, and the key of the inactive page cache in the statistics.
+type cgroupFiles struct {
+	usage, limit, stat, inactive string
+}
+
+var (
+	cgroupV2 = cgroupFiles{"memory.current", "memory.max", "memory.stat", "inactive_file"}
+	cgroupV1 = cgroupFiles{
+		"memory/memory.usage_in_bytes",
+		"memory/memory.limit_in_bytes",
+		"memory/memory.stat",
+		"total_inactive_file",
+	}
+)
+
+func (s cgroupSource) Usage() (Usage, error) {
+	files := cgroupV2
+	used, err := readCgroupValue(filepath.Join(s.dir, files.usage))
+	if errors.Is(err, os.ErrNotExist) {
+		files = cgroupV1
+		used, err = readCgroupValue(filepath.Join(s.dir, files.usage))
+	}
+	if err != nil {
+		return Usage{}, err
+	}
+	limit, err := readCgroupValue(filepath.Join(s.dir, files.limit))
+	if err != nil {
+		return Usage{}, err
+	}
+	// Cgroup v1 reports no limit as a page-aligned math.MaxInt64.
+	if limit >= math.MaxInt64/2 {
+		limit = 0
+	}
+
+	inactive, err := readCgroupStat(filepath.Join(s.dir, files.stat), files.inactive)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Usage{}, err
+	}
+	if inactive < used {
+		used -= inactive
+	}
+	return Usage{Used: used, Limit: limit, Epoch: collections()}, nil
+}
+
+// gcCycles is the runtime metric counting garbage collections.
+const gcCycles = "/gc/cycles/total:gc-cycles"
+
+// collections returns the number of garbage collections run.
+func collections() uint64 {
+	samples := []metrics.Sample{{Name: gcCycles}}
+	metrics.Read(samples)
+	return samples[0].Value.Uint64()
+}
+
+// readCgroupValue reads a file holding a number of bytes, or "max" for no limit.
+func readCgroupValue(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("pressure: parsing %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// readCgroupStat returns the value of key in a memory.stat file, or 0 if the
+// file does not have it.
+func readCgroupStat(path, key string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || name != key {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("pressure: parsing %s: %w", path, err)
+		}
+		return n, nil
+	}
+	return 0, scanner.Err()
+}
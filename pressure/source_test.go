@@ -0,0 +1,88 @@
+package pressure
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes the files of a fake cgroup in dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+// withoutEpoch returns usage with a zero epoch, which depends on the runtime.
+func withoutEpoch(usage Usage) Usage {
+	usage.Epoch = 0
+	return usage
+}
+
+func TestHeap(t *testing.T) {
+	// The live heap is only known once a collection has run.
+	runtime.GC()
+	usage, err := Heap().Usage()
+	require.NoError(t, err)
+	assert.NotZero(t, usage.Used)
+
+	runtime.GC()
+	next, err := Heap().Usage()
+	require.NoError(t, err)
+	assert.Greater(t, next.Epoch, usage.Epoch)
+}
+
+func TestCgroup(t *testing.T) {
+	t.Run("v2", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"memory.current": "1000\n",
+			"memory.max":     "4000\n",
+			"memory.stat":    "anon 600\nfile 400\ninactive_file 300\nactive_file 100\n",
+		})
+		usage, err := Cgroup(dir).Usage()
+		require.NoError(t, err)
+		assert.Equal(t, Usage{Used: 700, Limit: 4000}, withoutEpoch(usage))
+
+		writeFiles(t, dir, map[string]string{"memory.max": "max\n"})
+		usage, err = Cgroup(dir).Usage()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), usage.Limit)
+	})
+
+	t.Run("v1", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"memory/memory.usage_in_bytes": "1000\n",
+			"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		})
+		usage, err := Cgroup(dir).Usage()
+		require.NoError(t, err)
+		assert.Equal(t, Usage{Used: 1000}, withoutEpoch(usage))
+
+		writeFiles(t, dir, map[string]string{
+			"memory/memory.limit_in_bytes": "2000\n",
+			"memory/memory.stat":           "cache 500\ntotal_inactive_file 200\n",
+		})
+		usage, err = Cgroup(dir).Usage()
+		require.NoError(t, err)
+		assert.Equal(t, Usage{Used: 800, Limit: 2000}, withoutEpoch(usage))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Cgroup(t.TempDir()).Usage()
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"memory.current": "lots", "memory.max": "max"})
+		_, err = Cgroup(dir).Usage()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,298 @@
+package gofast
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RefreshingOption configures a Refreshing cache.
+type RefreshingOption func(r *Refreshing)
+
+// WithRefreshErrorHandler sets fn to be called with the errors of the loads,
+// which the Cache methods and background refreshes cannot return.
+// By default those errors are dropped.
+func WithRefreshErrorHandler(fn func(key string, err error)) RefreshingOption {
+	return func(r *Refreshing) {
+		r.onError = fn
+	}
+}
+
+// WithRefreshClock sets the clock telling the age of entries. Defaults to RealClock.
+func WithRefreshClock(clock Clock) RefreshingOption {
+	return func(r *Refreshing) {
+		r.clock = clock
+	}
+}
+
+// WithRefreshTimeout bounds the background refreshes. Defaults to no timeout.
+func WithRefreshTimeout(timeout time.Duration) RefreshingOption {
+	return func(r *Refreshing) {
+		r.refreshTimeout = timeout
+	}
+}
+
+// Refreshing wraps a Cache and loads its entries with a loader, serving stale
+// values while they are reloaded.
+//
+// An entry younger than refreshAfter is served as is. An entry older than
+// refreshAfter but younger than expireAfter is served as is too, and triggers
+// a reload in the background, one at a time per key; if the reload fails the
+// stale value keeps being served. An entry older than expireAfter, or a
+// missing one, is loaded before being returned. Concurrent loads and reloads
+// of a key are shared: a caller finding one running waits for its result.
+type Refreshing struct {
+	cache          Cache
+	load           LoaderFunc
+	clock          Clock
+	refreshAfter   time.Duration
+	expireAfter    time.Duration
+	refreshTimeout time.Duration
+	onError        func(key string, err error)
+
+	// mu makes storing a load atomic with respect to writes: writes hold the
+	// read lock, while storing a load holds the write lock and checks the
+	// entry it replaces was not replaced or removed meanwhile.
+	mu *sync.RWMutex
+	// refreshing holds the running load of each key, guarded by mu.
+	refreshing map[string]*refreshCall
+}
+
+// refreshCall is a running load or background reload of a key.
+type refreshCall struct {
+	// done is closed once val and err are set.
+	done chan struct{}
+	val  any
+	err  error
+}
+
+// refreshEntry is the value stored in the wrapped cache.
+type refreshEntry struct {
+	value    any
+	loadedAt time.Time
+}
+
+// NewRefreshing returns a cache storing its entries in c and loading them with
+// load. Entries are reloaded in the background once older than refreshAfter,
+// and no longer served once older than expireAfter; an expireAfter of 0 means
+// stale entries are served until a reload succeeds.
+func NewRefreshing(c Cache, load LoaderFunc, refreshAfter, expireAfter time.Duration, opts ...RefreshingOption) *Refreshing {
+	if expireAfter > 0 && refreshAfter > expireAfter {
+		panic("gofast: refreshAfter must not be greater than expireAfter")
+	}
+	r := &Refreshing{
+		cache:        c,
+		load:         load,
+		clock:        RealClock(),
+		refreshAfter: refreshAfter,
+		expireAfter:  expireAfter,
+		mu:           &sync.RWMutex{},
+		refreshing:   make(map[string]*refreshCall),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Get returns the value of key, loading it with a background context if it is
+// missing or expired. It returns false if the load fails.
+func (r *Refreshing) Get(key string) (any, bool) {
+	val, err := r.GetOrLoadCtx(context.Background(), key, r.load)
+	if err != nil {
+		r.report(key, err)
+		return nil, false
+	}
+	return val, true
+}
+
+// GetCtx returns the cached value of key if it is not expired, without loading
+// it, and triggers a background reload if it is stale.
+func (r *Refreshing) GetCtx(ctx context.Context, key string) (any, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	entry, ok := r.lookup(key)
+	if !ok || r.expired(entry) {
+		return nil, false, nil
+	}
+	r.maybeRefresh(key, entry)
+	return entry.value, true, nil
+}
+
+// GetOrLoadCtx returns the value of key, calling load instead of the loader of
+// the cache if it is missing or expired. Background reloads always use the
+// loader of the cache.
+//
+// Concurrent calls for the same key share one load, made with the context of
+// the first call: if it is canceled, they all fail with its error, and the
+// result of a load completing after that is dropped. A call finding a
+// background reload of key running waits for it instead.
+func (r *Refreshing) GetOrLoadCtx(ctx context.Context, key string, load LoaderFunc) (any, error) {
+	val, ok, err := r.GetCtx(ctx, key)
+	if err != nil || ok {
+		return val, err
+	}
+
+	r.mu.Lock()
+	entry, ok := r.lookup(key)
+	if ok && !r.expired(entry) {
+		// The key was loaded or written since.
+		r.mu.Unlock()
+		return entry.value, nil
+	}
+	call, running := r.refreshing[key]
+	if !running {
+		call = &refreshCall{done: make(chan struct{})}
+		r.refreshing[key] = call
+		go r.loadNow(ctx, key, entry, load, call)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.val, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Put adds a new key-value pair to the cache, loaded now.
+func (r *Refreshing) Put(key string, val any) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.cache.Put(key, &refreshEntry{value: val, loadedAt: r.clock.Now()})
+}
+
+// PutCtx adds a new key-value pair to the cache unless ctx is already done.
+func (r *Refreshing) PutCtx(ctx context.Context, key string, val any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Put(key, val)
+	return nil
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (r *Refreshing) Remove(key string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.cache.Remove(key)
+}
+
+// Len returns the number of items in the cache, including expired items.
+func (r *Refreshing) Len() int {
+	return r.cache.Len()
+}
+
+// Clear removes all items from the cache.
+func (r *Refreshing) Clear() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.cache.Clear()
+}
+
+// Contains checks if a key is present in the cache and not expired.
+func (r *Refreshing) Contains(key string) bool {
+	entry, ok := r.lookup(key)
+	return ok && !r.expired(entry)
+}
+
+// Stats returns the stats of the wrapped cache, or zero stats if it does not
+// implement StatsReporter.
+func (r *Refreshing) Stats() Stats {
+	if reporter, ok := r.cache.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return Stats{}
+}
+
+// Unwrap returns the wrapped cache.
+func (r *Refreshing) Unwrap() Cache {
+	return r.cache
+}
+
+// lookup returns the entry of key in the wrapped cache, expired or not.
+func (r *Refreshing) lookup(key string) (*refreshEntry, bool) {
+	val, ok := r.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return val.(*refreshEntry), true
+}
+
+// expired reports whether the entry must be loaded again before being served.
+func (r *Refreshing) expired(entry *refreshEntry) bool {
+	return r.expireAfter > 0 && r.age(entry) >= r.expireAfter
+}
+
+// age returns the time since the entry was loaded.
+func (r *Refreshing) age(entry *refreshEntry) time.Duration {
+	return r.clock.Now().Sub(entry.loadedAt)
+}
+
+// maybeRefresh reloads a stale entry in the background, unless a load of
+// key is already running.
+func (r *Refreshing) maybeRefresh(key string, entry *refreshEntry) {
+	if r.age(entry) < r.refreshAfter {
+		return
+	}
+	r.mu.Lock()
+	if _, ok := r.refreshing[key]; ok {
+		r.mu.Unlock()
+		return
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	r.refreshing[key] = call
+	r.mu.Unlock()
+
+	go r.refresh(key, entry, call)
+}
+
+// refresh reloads key in the background and stores the value if entry is still current.
+func (r *Refreshing) refresh(key string, entry *refreshEntry, call *refreshCall) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if r.refreshTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.refreshTimeout)
+	}
+	defer cancel()
+	val, err := r.load(ctx, key)
+	r.finish(key, entry, call, val, err)
+	r.report(key, err)
+}
+
+// loadNow loads key with load for the callers of GetOrLoadCtx and stores the
+// value if entry, nil if key was missing, is still current. The result is
+// dropped if ctx is done by then, as the first caller has returned.
+func (r *Refreshing) loadNow(ctx context.Context, key string, entry *refreshEntry, load LoaderFunc, call *refreshCall) {
+	val, err := load(ctx, key)
+	if err == nil && ctx.Err() != nil {
+		val, err = nil, ctx.Err()
+	}
+	r.finish(key, entry, call, val, err)
+}
+
+// finish ends the load of key, storing val if the load succeeded and entry,
+// nil if key was missing, is still current, and hands the result to the
+// callers waiting for it.
+func (r *Refreshing) finish(key string, entry *refreshEntry, call *refreshCall, val any, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.refreshing, key)
+	if err == nil {
+		current, ok := r.lookup(key)
+		if ok == (entry != nil) && current == entry {
+			r.cache.Put(key, &refreshEntry{value: val, loadedAt: r.clock.Now()})
+		}
+	}
+	call.val, call.err = val, err
+	close(call.done)
+}
+
+// report passes a non-nil error to the error handler.
+func (r *Refreshing) report(key string, err error) {
+	if err != nil && r.onError != nil {
+		r.onError(key, err)
+	}
+}
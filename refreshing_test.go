@@ -0,0 +1,174 @@
+package gofast
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ContextCache = (*Refreshing)(nil)
+
+// versionLoader returns a loader counting its calls and returning the count.
+func versionLoader(calls *int64, release <-chan struct{}) LoaderFunc {
+	return func(ctx context.Context, key string) (any, error) {
+		n := atomic.AddInt64(calls, 1)
+		if release != nil {
+			<-release
+		}
+		return n, nil
+	}
+}
+
+func TestRefreshing(t *testing.T) {
+	t.Run("fresh entries are served", func(t *testing.T) {
+		var calls int64
+		cache := NewRefreshing(NewCache(10, LRU), versionLoader(&calls, nil), time.Hour, 2*time.Hour)
+
+		for i := 0; i < 3; i++ {
+			val, ok := cache.Get("a")
+			assert.True(t, ok)
+			assert.Equal(t, int64(1), val)
+		}
+		assert.Equal(t, int64(1), atomic.LoadInt64(&calls))
+	})
+
+	t.Run("stale entries are served while reloading once", func(t *testing.T) {
+		var calls int64
+		release := make(chan struct{})
+		clock := NewFakeClock(time.Now())
+		cache := NewRefreshing(NewCache(10, LRU), versionLoader(&calls, release), 10*time.Millisecond, time.Hour, WithRefreshClock(clock))
+		cache.Put("a", int64(0))
+		clock.Advance(20 * time.Millisecond)
+
+		for i := 0; i < 5; i++ {
+			val, ok := cache.Get("a")
+			assert.True(t, ok)
+			assert.Equal(t, int64(0), val)
+		}
+		close(release)
+		require.Eventually(t, func() bool {
+			val, _ := cache.Get("a")
+			return val == int64(1)
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, int64(1), atomic.LoadInt64(&calls))
+	})
+
+	t.Run("expired entries are loaded", func(t *testing.T) {
+		var calls int64
+		clock := NewFakeClock(time.Now())
+		cache := NewRefreshing(NewCache(10, LRU), versionLoader(&calls, nil), 5*time.Millisecond, 10*time.Millisecond, WithRefreshClock(clock))
+		cache.Put("a", int64(0))
+		clock.Advance(20 * time.Millisecond)
+
+		assert.False(t, cache.Contains("a"))
+		val, ok := cache.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, int64(1), val)
+	})
+
+	t.Run("failed refreshes keep the stale value", func(t *testing.T) {
+		var mu sync.Mutex
+		var errs []error
+		load := func(ctx context.Context, key string) (any, error) {
+			return nil, errors.New("db down")
+		}
+		clock := NewFakeClock(time.Now())
+		cache := NewRefreshing(NewCache(10, LRU), load, 10*time.Millisecond, 0, WithRefreshClock(clock),
+			WithRefreshErrorHandler(func(key string, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err)
+			}))
+		cache.Put("a", 0)
+		clock.Advance(20 * time.Millisecond)
+
+		val, ok := cache.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 0, val)
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(errs) == 1
+		}, time.Second, time.Millisecond)
+
+		_, ok = cache.Get("missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("refreshes do not resurrect removed keys", func(t *testing.T) {
+		var calls int64
+		release := make(chan struct{})
+		clock := NewFakeClock(time.Now())
+		cache := NewRefreshing(NewCache(10, LRU), versionLoader(&calls, release), 10*time.Millisecond, time.Hour, WithRefreshClock(clock))
+		cache.Put("a", int64(0))
+		clock.Advance(20 * time.Millisecond)
+
+		cache.Get("a")
+		cache.Remove("a")
+		close(release)
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt64(&calls) == 1
+		}, time.Second, time.Millisecond)
+		require.Eventually(t, func() bool {
+			cache.mu.RLock()
+			defer cache.mu.RUnlock()
+			return len(cache.refreshing) == 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 0, cache.Len())
+	})
+	t.Run("concurrent loads of a key are shared", func(t *testing.T) {
+		var calls int64
+		release := make(chan struct{})
+		cache := NewRefreshing(NewCache(10, LRU), versionLoader(&calls, release), time.Hour, 2*time.Hour)
+
+		var started, wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			started.Add(1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				started.Done()
+				val, ok := cache.Get("a")
+				assert.True(t, ok)
+				assert.Equal(t, int64(1), val)
+			}()
+		}
+		started.Wait()
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt64(&calls) == 1
+		}, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int64(1), atomic.LoadInt64(&calls))
+	})
+
+	t.Run("loads the caller stopped waiting for are dropped", func(t *testing.T) {
+		var calls int64
+		release := make(chan struct{})
+		cache := NewRefreshing(NewCache(10, LRU), versionLoader(&calls, release), time.Hour, 2*time.Hour)
+		ctx, cancel := context.WithCancel(context.Background())
+		errc := make(chan error)
+		go func() {
+			_, err := cache.GetOrLoadCtx(ctx, "a", cache.load)
+			errc <- err
+		}()
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt64(&calls) == 1
+		}, time.Second, time.Millisecond)
+		cancel()
+		assert.ErrorIs(t, <-errc, context.Canceled)
+		close(release)
+		require.Eventually(t, func() bool {
+			cache.mu.RLock()
+			defer cache.mu.RUnlock()
+			return len(cache.refreshing) == 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 0, cache.Len())
+	})
+}
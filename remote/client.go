@@ -0,0 +1,271 @@
+package remote
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/raghavgh/gofast"
+	"github.com/raghavgh/gofast/codec"
+	"github.com/raghavgh/gofast/remote/cachepb"
+)
+
+// defaultTimeout bounds the calls made by the gofast.Cache methods of a Client.
+const defaultTimeout = time.Second
+
+// ClientOption configures a Client.
+type ClientOption func(c *Client)
+
+// WithCodec sets the codec used to encode values. Defaults to codec.Gob.
+// Use the same codec in every client of a server.
+func WithCodec(cd codec.Codec) ClientOption {
+	return func(c *Client) {
+		c.codec = cd
+	}
+}
+
+// WithTimeout sets the timeout of the calls made by the methods without a
+// context argument. Defaults to one second.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithErrorHandler sets fn to be called with the errors of the methods
+// without an error result. By default those errors are dropped, and the
+// methods behave as if the cache were empty.
+func WithErrorHandler(fn func(err error)) ClientOption {
+	return func(c *Client) {
+		c.onError = fn
+	}
+}
+
+// Client is a gofast.Cache and gofast.ContextCache backed by a remote Server.
+type Client struct {
+	rpc     cachepb.CacheClient
+	codec   codec.Codec
+	timeout time.Duration
+	onError func(err error)
+}
+
+// Event is a change to the remote cache.
+type Event struct {
+	Type cachepb.Event_Type
+	// Key is empty for TYPE_CLEAR events.
+	Key string
+}
+
+// NewClient returns a client calling the server over cc.
+func NewClient(cc grpc.ClientConnInterface, opts ...ClientOption) *Client {
+	c := &Client{
+		rpc:     cachepb.NewCacheClient(cc),
+		codec:   codec.Gob{},
+		timeout: defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get retrieves a value from the cache for a specific key.
+func (c *Client) Get(key string) (any, bool) {
+	ctx, cancel := c.context()
+	defer cancel()
+	val, ok, err := c.GetCtx(ctx, key)
+	c.report(err)
+	return val, ok
+}
+
+// Put adds a new key-value pair to the cache.
+func (c *Client) Put(key string, val any) {
+	ctx, cancel := c.context()
+	defer cancel()
+	c.report(c.PutCtx(ctx, key, val))
+}
+
+// Remove deletes a specific key-value pair from the cache.
+func (c *Client) Remove(key string) {
+	ctx, cancel := c.context()
+	defer cancel()
+	c.report(c.RemoveCtx(ctx, key))
+}
+
+// Len returns the number of items in the cache.
+func (c *Client) Len() int {
+	ctx, cancel := c.context()
+	defer cancel()
+	n, err := c.LenCtx(ctx)
+	c.report(err)
+	return n
+}
+
+// Clear removes all items from the cache.
+func (c *Client) Clear() {
+	ctx, cancel := c.context()
+	defer cancel()
+	c.report(c.ClearCtx(ctx))
+}
+
+// Contains checks if a key is present in the cache.
+func (c *Client) Contains(key string) bool {
+	ctx, cancel := c.context()
+	defer cancel()
+	ok, err := c.ContainsCtx(ctx, key)
+	c.report(err)
+	return ok
+}
+
+// GetCtx retrieves a value from the cache for a specific key.
+func (c *Client) GetCtx(ctx context.Context, key string) (any, bool, error) {
+	resp, err := c.rpc.Get(ctx, &cachepb.GetRequest{Key: key})
+	if err != nil || !resp.GetFound() {
+		return nil, false, err
+	}
+	val, err := c.codec.Decode(resp.GetValue())
+	if err != nil {
+		return nil, false, err
+	}
+	return val, true, nil
+}
+
+// PutCtx adds a new key-value pair to the cache.
+func (c *Client) PutCtx(ctx context.Context, key string, val any) error {
+	return c.PutWithTTLCtx(ctx, key, val, 0)
+}
+
+// PutWithTTLCtx adds a new key-value pair to the cache that expires after
+// ttl, or never if ttl is 0. The server's cache must support per-entry time to live.
+func (c *Client) PutWithTTLCtx(ctx context.Context, key string, val any, ttl time.Duration) error {
+	data, err := c.codec.Encode(val)
+	if err != nil {
+		return err
+	}
+	_, err = c.rpc.Put(ctx, &cachepb.PutRequest{Key: key, Value: data, Ttl: toDuration(ttl)})
+	return err
+}
+
+// GetOrLoadCtx returns the cached value of key, calling load and caching its
+// result on a miss. Concurrent misses, even from different clients, all call load.
+func (c *Client) GetOrLoadCtx(ctx context.Context, key string, load gofast.LoaderFunc) (any, error) {
+	val, ok, err := c.GetCtx(ctx, key)
+	if err != nil || ok {
+		return val, err
+	}
+	val, err = load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return val, c.PutCtx(ctx, key, val)
+}
+
+// RemoveCtx deletes a specific key-value pair from the cache.
+func (c *Client) RemoveCtx(ctx context.Context, key string) error {
+	_, err := c.rpc.Remove(ctx, &cachepb.RemoveRequest{Key: key})
+	return err
+}
+
+// LenCtx returns the number of items in the cache.
+func (c *Client) LenCtx(ctx context.Context) (int, error) {
+	resp, err := c.rpc.Len(ctx, &cachepb.LenRequest{})
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.GetLen()), nil
+}
+
+// ClearCtx removes all items from the cache.
+func (c *Client) ClearCtx(ctx context.Context) error {
+	_, err := c.rpc.Clear(ctx, &cachepb.ClearRequest{})
+	return err
+}
+
+// ContainsCtx checks if a key is present in the cache.
+func (c *Client) ContainsCtx(ctx context.Context, key string) (bool, error) {
+	resp, err := c.rpc.Contains(ctx, &cachepb.ContainsRequest{Key: key})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetFound(), nil
+}
+
+// GetMany returns the values of the keys that are in the cache, in one call.
+func (c *Client) GetMany(ctx context.Context, keys []string) (map[string]any, error) {
+	resp, err := c.rpc.GetMany(ctx, &cachepb.GetManyRequest{Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	vals := make(map[string]any, len(resp.GetEntries()))
+	for _, entry := range resp.GetEntries() {
+		val, err := c.codec.Decode(entry.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		vals[entry.GetKey()] = val
+	}
+	return vals, nil
+}
+
+// PutMany adds several key-value pairs to the cache in one call. They expire
+// after ttl, or never if ttl is 0.
+func (c *Client) PutMany(ctx context.Context, vals map[string]any, ttl time.Duration) error {
+	req := &cachepb.PutManyRequest{Ttl: toDuration(ttl)}
+	for key, val := range vals {
+		data, err := c.codec.Encode(val)
+		if err != nil {
+			return err
+		}
+		req.Entries = append(req.Entries, &cachepb.Entry{Key: key, Value: data})
+	}
+	_, err := c.rpc.PutMany(ctx, req)
+	return err
+}
+
+// RemoveMany deletes several keys from the cache in one call.
+func (c *Client) RemoveMany(ctx context.Context, keys []string) error {
+	_, err := c.rpc.RemoveMany(ctx, &cachepb.RemoveManyRequest{Keys: keys})
+	return err
+}
+
+// Watch calls fn with the changes to the keys starting with prefix until ctx
+// is done, returning ctx.Err() then, or until the stream fails. The stream
+// fails with codes.ResourceExhausted if fn is too slow to keep up.
+func (c *Client) Watch(ctx context.Context, prefix string, fn func(Event)) error {
+	stream, err := c.rpc.Watch(ctx, &cachepb.WatchRequest{Prefix: prefix})
+	if err != nil {
+		return err
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		fn(Event{Type: event.GetType(), Key: event.GetKey()})
+	}
+}
+
+// context returns the context of the methods without a context argument.
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+// report passes a non-nil error to the error handler.
+func (c *Client) report(err error) {
+	if err != nil && c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// toDuration returns ttl as a protobuf duration, nil for 0.
+func toDuration(ttl time.Duration) *durationpb.Duration {
+	if ttl == 0 {
+		return nil
+	}
+	return durationpb.New(ttl)
+}
@@ -0,0 +1,185 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/raghavgh/gofast"
+	"github.com/raghavgh/gofast/remote/cachepb"
+)
+
+// newClient serves c over an in-memory connection and returns a client for it.
+func newClient(t *testing.T, c gofast.Cache, opts ...ClientOption) *Client {
+	t.Helper()
+	l := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	cachepb.RegisterCacheServer(srv, NewServer(c))
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn, opts...)
+}
+
+// The client is a drop-in replacement for a local cache.
+var _ gofast.Cache = (*Client)(nil)
+var _ gofast.ContextCache = (*Client)(nil)
+
+func TestClient(t *testing.T) {
+	t.Run("cache methods", func(t *testing.T) {
+		client := newClient(t, gofast.NewCache(10, gofast.LRU))
+
+		_, ok := client.Get("a")
+		assert.False(t, ok)
+		client.Put("a", 1)
+		client.Put("b", "two")
+
+		val, ok := client.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+		assert.True(t, client.Contains("b"))
+		assert.Equal(t, 2, client.Len())
+
+		client.Remove("a")
+		assert.False(t, client.Contains("a"))
+		client.Clear()
+		assert.Equal(t, 0, client.Len())
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		client := newClient(t, gofast.NewCache(10, gofast.LRU))
+		ctx := context.Background()
+
+		require.NoError(t, client.PutMany(ctx, map[string]any{"a": 1, "b": 2, "c": 3}, 0))
+		vals, err := client.GetMany(ctx, []string{"a", "c", "x"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": 1, "c": 3}, vals)
+
+		require.NoError(t, client.RemoveMany(ctx, []string{"a", "b"}))
+		n, err := client.LenCtx(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		cache := gofast.NewExpiring(gofast.NewCache(10, gofast.LRU))
+		client := newClient(t, cache)
+		ctx := context.Background()
+
+		require.NoError(t, client.PutWithTTLCtx(ctx, "a", 1, time.Hour))
+		ttl, ok := cache.TTL("a")
+		assert.True(t, ok)
+		assert.InDelta(t, time.Hour, ttl, float64(time.Minute))
+
+		plain := newClient(t, gofast.NewCache(10, gofast.LRU))
+		err := plain.PutWithTTLCtx(ctx, "a", 1, time.Hour)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("get or load", func(t *testing.T) {
+		client := newClient(t, gofast.NewCache(10, gofast.LRU))
+		ctx := context.Background()
+		calls := 0
+		load := func(ctx context.Context, key string) (any, error) {
+			calls++
+			return "loaded " + key, nil
+		}
+
+		for i := 0; i < 2; i++ {
+			val, err := client.GetOrLoadCtx(ctx, "a", load)
+			require.NoError(t, err)
+			assert.Equal(t, "loaded a", val)
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cache := gofast.NewCache(10, gofast.LRU)
+		cache.Put("local", 1)
+		var errs []error
+		client := newClient(t, cache, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+
+		_, ok := client.Get("local")
+		assert.False(t, ok)
+		require.Len(t, errs, 1)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(errs[0]))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := client.GetCtx(ctx, "a")
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	})
+}
+
+func TestClient_Watch(t *testing.T) {
+	client := newClient(t, gofast.NewCache(2, gofast.LRU))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Watch(ctx, "user:", func(e Event) { events <- e })
+	}()
+
+	// Wait for the stream to be registered: puts made before are not seen.
+	require.Eventually(t, func() bool {
+		client.Put("user:probe", 0)
+		select {
+		case <-events:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+	client.Remove("user:probe")
+	<-events
+	client.Clear()
+	<-events
+
+	client.Put("user:1", 1)
+	client.Put("other", 0)
+	client.Put("user:2", 2)
+	client.Put("user:3", 3)
+	client.Remove("user:3")
+	client.Clear()
+
+	var got []Event
+	for len(got) < 6 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %v, want 6 events", got)
+		}
+	}
+	assert.Equal(t, []Event{
+		{cachepb.Event_TYPE_PUT, "user:1"},
+		{cachepb.Event_TYPE_EVICT, "user:1"},
+		{cachepb.Event_TYPE_PUT, "user:2"},
+		{cachepb.Event_TYPE_PUT, "user:3"},
+		{cachepb.Event_TYPE_REMOVE, "user:3"},
+		{cachepb.Event_TYPE_CLEAR, ""},
+	}, got)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
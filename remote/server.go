@@ -0,0 +1,242 @@
+// Package remote serves a gofast cache over gRPC and provides a client that
+// implements gofast.Cache, so services can swap a local cache for a remote one.
+//
+// The service is defined in cachepb/cache.proto. Regenerate the Go code with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//		--go-grpc_out=. --go-grpc_opt=paths=source_relative cachepb/cache.proto
+package remote
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/raghavgh/gofast"
+	"github.com/raghavgh/gofast/remote/cachepb"
+)
+
+// watchBuffer is the number of events buffered for each Watch stream.
+const watchBuffer = 256
+
+// ttlCache is implemented by caches supporting per-entry time to live, such as gofast.Expiring.
+type ttlCache interface {
+	PutWithTTL(key string, val any, ttl time.Duration)
+}
+
+// Server implements cachepb.CacheServer on top of a cache. Register it with
+// cachepb.RegisterCacheServer. Values are stored in the cache as []byte.
+type Server struct {
+	cachepb.UnimplementedCacheServer
+
+	cache gofast.Cache
+	ttl   ttlCache
+
+	mu       *sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+// watcher is a Watch stream.
+type watcher struct {
+	prefix string
+	events chan *cachepb.Event
+	// lagged is closed when an event is dropped because events is full.
+	lagged chan struct{}
+	once   sync.Once
+}
+
+// NewServer returns a server for c. Puts with a ttl require c to support
+// per-entry time to live, as gofast.Expiring does. If c implements
+// gofast.EvictionNotifier, its evictions are sent to watchers.
+func NewServer(c gofast.Cache) *Server {
+	s := &Server{
+		cache:    c,
+		mu:       &sync.Mutex{},
+		watchers: make(map[*watcher]struct{}),
+	}
+	s.ttl, _ = c.(ttlCache)
+	if notifier, ok := c.(gofast.EvictionNotifier); ok {
+		notifier.AddEvictionHook(func(key string, val any) {
+			s.publish(cachepb.Event_TYPE_EVICT, key)
+		})
+	}
+	return s
+}
+
+// Get returns the value of a key.
+func (s *Server) Get(ctx context.Context, req *cachepb.GetRequest) (*cachepb.GetResponse, error) {
+	val, ok, err := s.get(req.GetKey())
+	if err != nil || !ok {
+		return &cachepb.GetResponse{}, err
+	}
+	return &cachepb.GetResponse{Found: true, Value: val}, nil
+}
+
+// Put sets the value of a key.
+func (s *Server) Put(ctx context.Context, req *cachepb.PutRequest) (*cachepb.PutResponse, error) {
+	ttl, err := s.ttlOf(req)
+	if err != nil {
+		return nil, err
+	}
+	s.put(req.GetKey(), req.GetValue(), ttl)
+	return &cachepb.PutResponse{}, nil
+}
+
+// Remove removes a key.
+func (s *Server) Remove(ctx context.Context, req *cachepb.RemoveRequest) (*cachepb.RemoveResponse, error) {
+	s.remove(req.GetKey())
+	return &cachepb.RemoveResponse{}, nil
+}
+
+// Contains reports whether the cache holds a key.
+func (s *Server) Contains(ctx context.Context, req *cachepb.ContainsRequest) (*cachepb.ContainsResponse, error) {
+	return &cachepb.ContainsResponse{Found: s.cache.Contains(req.GetKey())}, nil
+}
+
+// Len returns the number of items in the cache.
+func (s *Server) Len(ctx context.Context, req *cachepb.LenRequest) (*cachepb.LenResponse, error) {
+	return &cachepb.LenResponse{Len: int64(s.cache.Len())}, nil
+}
+
+// Clear removes all items from the cache.
+func (s *Server) Clear(ctx context.Context, req *cachepb.ClearRequest) (*cachepb.ClearResponse, error) {
+	s.cache.Clear()
+	s.publish(cachepb.Event_TYPE_CLEAR, "")
+	return &cachepb.ClearResponse{}, nil
+}
+
+// GetMany returns the values of the keys that are in the cache.
+func (s *Server) GetMany(ctx context.Context, req *cachepb.GetManyRequest) (*cachepb.GetManyResponse, error) {
+	resp := &cachepb.GetManyResponse{}
+	for _, key := range req.GetKeys() {
+		val, ok, err := s.get(key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			resp.Entries = append(resp.Entries, &cachepb.Entry{Key: key, Value: val})
+		}
+	}
+	return resp, nil
+}
+
+// PutMany sets the values of several keys.
+func (s *Server) PutMany(ctx context.Context, req *cachepb.PutManyRequest) (*cachepb.PutManyResponse, error) {
+	ttl, err := s.ttlOf(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range req.GetEntries() {
+		s.put(entry.GetKey(), entry.GetValue(), ttl)
+	}
+	return &cachepb.PutManyResponse{}, nil
+}
+
+// RemoveMany removes several keys.
+func (s *Server) RemoveMany(ctx context.Context, req *cachepb.RemoveManyRequest) (*cachepb.RemoveManyResponse, error) {
+	for _, key := range req.GetKeys() {
+		s.remove(key)
+	}
+	return &cachepb.RemoveManyResponse{}, nil
+}
+
+// Watch streams the changes made through the server and the evictions of the
+// cache. Events are buffered per stream; a stream that falls behind by more
+// than the buffer is ended with codes.ResourceExhausted rather than blocking
+// the cache, and the client should resynchronize and watch again.
+func (s *Server) Watch(req *cachepb.WatchRequest, stream cachepb.Cache_WatchServer) error {
+	w := &watcher{
+		prefix: req.GetPrefix(),
+		events: make(chan *cachepb.Event, watchBuffer),
+		lagged: make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.watchers[w] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, w)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-w.events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-w.lagged:
+			return status.Error(codes.ResourceExhausted, "watcher fell behind, events were dropped")
+		}
+	}
+}
+
+// get returns the value of key, which must be []byte.
+func (s *Server) get(key string) ([]byte, bool, error) {
+	val, ok := s.cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	b, ok := val.([]byte)
+	if !ok {
+		return nil, false, status.Errorf(codes.FailedPrecondition, "value of %q is a %T, not []byte", key, val)
+	}
+	return b, true, nil
+}
+
+// ttlOf returns the ttl of a put request.
+func (s *Server) ttlOf(req interface{ GetTtl() *durationpb.Duration }) (time.Duration, error) {
+	d := req.GetTtl()
+	if d == nil {
+		return 0, nil
+	}
+	if err := d.CheckValid(); err != nil {
+		return 0, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ttl := d.AsDuration()
+	if ttl < 0 {
+		return 0, status.Error(codes.InvalidArgument, "negative ttl")
+	}
+	if ttl > 0 && s.ttl == nil {
+		return 0, status.Error(codes.FailedPrecondition, "expiration is not supported by this cache")
+	}
+	return ttl, nil
+}
+
+func (s *Server) put(key string, val []byte, ttl time.Duration) {
+	if s.ttl != nil {
+		s.ttl.PutWithTTL(key, val, ttl)
+	} else {
+		s.cache.Put(key, val)
+	}
+	s.publish(cachepb.Event_TYPE_PUT, key)
+}
+
+func (s *Server) remove(key string) {
+	s.cache.Remove(key)
+	s.publish(cachepb.Event_TYPE_REMOVE, key)
+}
+
+// publish sends an event to the watchers of key without blocking. It may be
+// called with the cache's lock held, from an eviction hook.
+func (s *Server) publish(typ cachepb.Event_Type, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for w := range s.watchers {
+		if typ != cachepb.Event_TYPE_CLEAR && !strings.HasPrefix(key, w.prefix) {
+			continue
+		}
+		select {
+		case w.events <- &cachepb.Event{Type: typ, Key: key}:
+		default:
+			w.once.Do(func() { close(w.lagged) })
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package memcache
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// metaFlags are the flags of a meta command: a letter, optionally followed by a token.
+type metaFlags []string
+
+// has reports whether flag is set.
+func (f metaFlags) has(flag byte) bool {
+	_, ok := f.get(flag)
+	return ok
+}
+
+// get returns the token of flag, and whether flag is set.
+func (f metaFlags) get(flag byte) (string, bool) {
+	for _, token := range f {
+		if token[0] == flag {
+			return token[1:], true
+		}
+	}
+	return "", false
+}
+
+// ret returns the flags returned by a meta command for key and it, among the
+// requested flags allowed for the command: c (CAS value), f (client flags),
+// k (key), O (opaque token), s (size) and t (remaining time to live in
+// seconds, -1 for none). it may be nil, in which case c, f, s and t are omitted.
+func (c *conn) ret(flags metaFlags, allowed string, key string, it *item) string {
+	var b strings.Builder
+	for _, token := range flags {
+		flag := token[0]
+		if strings.IndexByte(allowed, flag) < 0 {
+			continue
+		}
+		switch flag {
+		case 'k':
+			b.WriteString(" k" + key)
+		case 'O':
+			b.WriteString(" " + token)
+		}
+		if it == nil {
+			continue
+		}
+		switch flag {
+		case 'c':
+			b.WriteString(" c" + strconv.FormatUint(it.cas, 10))
+		case 'f':
+			b.WriteString(" f" + strconv.FormatUint(uint64(it.flags), 10))
+		case 's':
+			b.WriteString(" s" + strconv.Itoa(len(it.value)))
+		case 't':
+			ttl := int64(-1)
+			if d := c.s.remaining(key); d > 0 {
+				ttl = int64((d + time.Second - 1) / time.Second)
+			}
+			b.WriteString(" t" + strconv.FormatInt(ttl, 10))
+		}
+	}
+	return b.String()
+}
+
+// metaKey checks the key and flags of a meta command, replying with an error
+// and returning false if they are invalid.
+func (c *conn) metaKey(args []string) bool {
+	if len(args) == 0 || !validKey(args[0]) {
+		c.clientError("bad command line format")
+		return false
+	}
+	for _, token := range args[1:] {
+		if token[0] == 'b' {
+			c.clientError("base64 keys are not supported")
+			return false
+		}
+	}
+	return true
+}
+
+// metaGet implements "mg <key> <flags>*". Besides the returned flags, it
+// supports v (return the value), q (no reply on miss) and T<exptime> (touch).
+func (c *conn) metaGet(args []string) error {
+	if !c.metaKey(args) {
+		return nil
+	}
+	key, flags := args[0], metaFlags(args[1:])
+
+	atomic.AddUint64(&c.s.cmdGet, 1)
+	if exptime, ok := flags.get('T'); ok {
+		ttl, expired, ok := c.exptime(exptime)
+		if !ok {
+			return nil
+		}
+		c.s.touch(key, ttl, expired)
+	}
+
+	it, ok := c.s.lookup(key)
+	if !ok {
+		if !flags.has('q') {
+			c.reply("EN" + c.ret(flags, "kO", key, nil))
+		}
+		return nil
+	}
+	ret := c.ret(flags, "cfkOst", key, it)
+	if !flags.has('v') {
+		c.reply("HD" + ret)
+		return nil
+	}
+	c.reply("VA " + strconv.Itoa(len(it.value)) + ret)
+	c.w.Write(it.value)
+	c.reply("")
+	return nil
+}
+
+// metaSet implements "ms <key> <datalen> <flags>*". It supports F<flags>,
+// T<exptime>, C<cas>, q (no reply on success), the returned flags c, k and O,
+// and M<mode> with the modes E (add), A (append), P (prepend), R (replace)
+// and S (set, the default).
+func (c *conn) metaSet(args []string) error {
+	if !c.metaKey(args) {
+		return nil
+	}
+	if len(args) < 2 {
+		c.clientError("bad command line format")
+		return nil
+	}
+	size, err := strconv.Atoi(args[1])
+	if err != nil || size < 0 {
+		c.clientError("bad data chunk")
+		return nil
+	}
+	key, flags := args[0], metaFlags(args[2:])
+
+	value, err := c.readData(size)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		c.serverError("object too large for cache")
+		return nil
+	}
+
+	req := storeRequest{key: key, value: value}
+	if token, ok := flags.get('F'); ok {
+		f, err := strconv.ParseUint(token, 10, 32)
+		if err != nil {
+			c.clientError("bad token in command line format")
+			return nil
+		}
+		req.flags = uint32(f)
+	}
+	if token, ok := flags.get('C'); ok {
+		if req.cas, err = strconv.ParseUint(token, 10, 64); err != nil {
+			c.clientError("bad token in command line format")
+			return nil
+		}
+		req.hasCAS = true
+	}
+	if token, ok := flags.get('M'); ok {
+		switch strings.ToUpper(token) {
+		case "S":
+			req.mode = modeSet
+		case "E":
+			req.mode = modeAdd
+		case "A":
+			req.mode = modeAppend
+		case "P":
+			req.mode = modePrepend
+		case "R":
+			req.mode = modeReplace
+		default:
+			c.clientError("invalid mode for ms")
+			return nil
+		}
+	}
+	if token, ok := flags.get('T'); ok {
+		var ok bool
+		if req.ttl, req.expired, ok = c.exptime(token); !ok {
+			return nil
+		}
+	}
+
+	result, it := c.s.store(req)
+	if result == stored && flags.has('q') {
+		return nil
+	}
+	code := [...]string{stored: "HD", notStored: "NS", exists: "EX", notFound: "NF"}[result]
+	c.reply(code + c.ret(flags, "ckO", key, it))
+	return nil
+}
+
+// metaDelete implements "md <key> <flags>*". It supports C<cas>, q (no reply
+// on success or miss) and the returned flags k and O.
+func (c *conn) metaDelete(args []string) error {
+	if !c.metaKey(args) {
+		return nil
+	}
+	key, flags := args[0], metaFlags(args[1:])
+
+	var cas uint64
+	token, hasCAS := flags.get('C')
+	if hasCAS {
+		var err error
+		if cas, err = strconv.ParseUint(token, 10, 64); err != nil {
+			c.clientError("bad token in command line format")
+			return nil
+		}
+	}
+
+	result := c.s.remove(key, cas, hasCAS)
+	if result != exists && flags.has('q') {
+		return nil
+	}
+	code := [...]string{stored: "HD", exists: "EX", notFound: "NF"}[result]
+	c.reply(code + c.ret(flags, "kO", key, nil))
+	return nil
+}
+
+// metaArith implements "ma <key> <flags>*". It supports D<delta> (default 1),
+// M<mode> with the modes I (incr, the default) and D (decr), N<exptime> and
+// J<initial> to create missing items, C<cas>, q (no reply on success or
+// miss), v (return the value) and the returned flags c, k, O and t.
+func (c *conn) metaArith(args []string) error {
+	if !c.metaKey(args) {
+		return nil
+	}
+	key, flags := args[0], metaFlags(args[1:])
+
+	req := arithRequest{key: key, delta: 1}
+	var err error
+	for _, token := range flags {
+		value := token[1:]
+		switch token[0] {
+		case 'D':
+			req.delta, err = strconv.ParseUint(value, 10, 64)
+		case 'J':
+			req.initial, err = strconv.ParseUint(value, 10, 64)
+		case 'C':
+			req.cas, err = strconv.ParseUint(value, 10, 64)
+			req.hasCAS = true
+		case 'M':
+			switch value {
+			case "I", "i", "+":
+				req.decr = false
+			case "D", "d", "-":
+				req.decr = true
+			default:
+				c.clientError("invalid mode for ma")
+				return nil
+			}
+		case 'N':
+			var ok bool
+			var expired bool
+			if req.ttl, expired, ok = c.exptime(value); !ok {
+				return nil
+			}
+			req.autovivify = !expired
+		}
+		if err != nil {
+			c.clientError("bad token in command line format")
+			return nil
+		}
+	}
+
+	result, n, it := c.s.arith(req)
+	switch result {
+	case arithNonNumeric:
+		c.clientError("cannot increment or decrement non-numeric value")
+		return nil
+	case arithExists:
+		c.reply("EX" + c.ret(flags, "kO", key, nil))
+		return nil
+	case arithNotFound:
+		if !flags.has('q') {
+			c.reply("NF" + c.ret(flags, "kO", key, nil))
+		}
+		return nil
+	}
+
+	ret := c.ret(flags, "ckOt", key, it)
+	if flags.has('v') {
+		value := strconv.FormatUint(n, 10)
+		c.reply("VA " + strconv.Itoa(len(value)) + ret)
+		c.reply(value)
+		return nil
+	}
+	if !flags.has('q') {
+		c.reply("HD" + ret)
+	}
+	return nil
+}
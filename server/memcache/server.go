@@ -0,0 +1,437 @@
+// Package memcache serves a gofast cache over the memcached text protocol, so
+// that memcached clients can use it.
+//
+// The server understands the storage commands (set, add, replace, append,
+// prepend and cas), the retrieval commands (get, gets, gat and gats), delete,
+// incr, decr, touch, flush_all, stats, version, verbosity and quit, as well as
+// the meta commands mg, ms, md, ma and mn. Expiration times, as for touch,
+// require the cache to support per-entry time to live, as gofast.Expiring does.
+//
+// Items are stored with their client flags and CAS value, so the cache should
+// not be shared with code putting other values; []byte and string values put
+// by such code are served with flags 0, and values of other types are ignored.
+package memcache
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/raghavgh/gofast"
+	"github.com/raghavgh/gofast/internal/netserver"
+)
+
+const (
+	// maxKeyLen is the longest key accepted, as in memcached.
+	maxKeyLen = 250
+	// maxItemSize is the largest value accepted, memcached's default item size.
+	maxItemSize = 1 << 20
+	// maxLineLen is the longest command line accepted.
+	maxLineLen = 2048
+	// maxRelativeExptime is the largest expiration time that memcached takes as
+	// a number of seconds; larger ones are unix timestamps.
+	maxRelativeExptime = 60 * 60 * 24 * 30
+)
+
+// ErrServerClosed is returned by Serve and ListenAndServe after Close.
+var ErrServerClosed = netserver.ErrServerClosed
+
+// errNoTTL is replied to commands setting an expiration time on a cache without time to live.
+var errNoTTL = errors.New("expiration is not supported by this cache")
+
+// ttlCache is implemented by caches supporting per-entry time to live, such as gofast.Expiring.
+type ttlCache interface {
+	PutWithTTL(key string, val any, ttl time.Duration)
+	TTL(key string) (time.Duration, bool)
+	GetWithTTL(key string) (any, time.Duration, bool)
+	Touch(key string, ttl time.Duration) bool
+}
+
+// item is the value stored in the cache.
+type item struct {
+	value []byte
+	flags uint32
+	cas   uint64
+}
+
+// Option configures a Server.
+type Option func(s *Server)
+
+// WithClock sets the clock of the expiration times, flush delays and uptime. Defaults to gofast.RealClock.
+func WithClock(clock gofast.Clock) Option {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// Server serves a cache to memcached clients.
+type Server struct {
+	cache   gofast.Cache
+	ttl     ttlCache
+	clock   gofast.Clock
+	started time.Time
+
+	// writeMu makes the commands that read before they write, such as add,
+	// cas and incr, atomic with respect to each other.
+	writeMu *sync.Mutex
+
+	// flushMu guards flushTimer, the pending delayed flush_all.
+	flushMu    *sync.Mutex
+	flushTimer gofast.Timer
+
+	net *netserver.Server
+
+	casUnique uint64
+	cmdGet    uint64
+	cmdSet    uint64
+	cmdTouch  uint64
+	cmdFlush  uint64
+}
+
+// NewServer returns a server for c.
+func NewServer(c gofast.Cache, opts ...Option) *Server {
+	s := &Server{
+		cache:   c,
+		clock:   gofast.RealClock(),
+		writeMu: &sync.Mutex{},
+		flushMu: &sync.Mutex{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.started = s.clock.Now()
+	s.ttl, _ = c.(ttlCache)
+	s.net = netserver.New(s.serveConn)
+	return s
+}
+
+// ListenAndServe listens on the TCP address addr and serves clients connecting to it.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves each of them in its own goroutine,
+// until l fails or the server is closed. It always returns a non-nil error.
+func (s *Server) Serve(l net.Listener) error {
+	return s.net.Serve(l)
+}
+
+// Close closes the listeners and the client connections, and waits for the
+// connections to be done.
+func (s *Server) Close() error {
+	return s.net.Close()
+}
+
+// serveConn reads commands from nc and writes their replies, flushing them
+// once no more pipelined commands are buffered.
+func (s *Server) serveConn(nc net.Conn) {
+	c := &conn{
+		s: s,
+		r: bufio.NewReader(nc),
+		w: bufio.NewWriter(nc),
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			if errors.Is(err, errLineTooLong) {
+				c.clientError("line too long")
+				c.w.Flush()
+			}
+			return
+		}
+
+		if err := c.exec(line); err != nil {
+			c.w.Flush()
+			return
+		}
+		if c.r.Buffered() == 0 {
+			if err := c.w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// lookup returns the item of key.
+func (s *Server) lookup(key string) (*item, bool) {
+	it, _, ok := s.lookupTTL(key)
+	return it, ok
+}
+
+// lookupTTL returns the item of key and its remaining time to live, 0 if it
+// never expires. Both are read from the same entry, so an item expiring
+// meanwhile is not mistaken for one that never expires.
+func (s *Server) lookupTTL(key string) (*item, time.Duration, bool) {
+	var val any
+	var ttl time.Duration
+	var ok bool
+	if s.ttl != nil {
+		val, ttl, ok = s.ttl.GetWithTTL(key)
+	} else {
+		val, ok = s.cache.Get(key)
+	}
+	if !ok {
+		return nil, 0, false
+	}
+	switch v := val.(type) {
+	case *item:
+		return v, ttl, true
+	case []byte:
+		return &item{value: v}, ttl, true
+	case string:
+		return &item{value: []byte(v)}, ttl, true
+	default:
+		return nil, 0, false
+	}
+}
+
+// put stores a new version of key with a fresh CAS value, expiring after ttl
+// if it is not 0.
+func (s *Server) put(key string, value []byte, flags uint32, ttl time.Duration) *item {
+	it := &item{value: value, flags: flags, cas: atomic.AddUint64(&s.casUnique, 1)}
+	if s.ttl != nil {
+		s.ttl.PutWithTTL(key, it, ttl)
+	} else {
+		s.cache.Put(key, it)
+	}
+	return it
+}
+
+// remaining returns the remaining time to live of key, 0 if it never expires.
+func (s *Server) remaining(key string) time.Duration {
+	if s.ttl == nil {
+		return 0
+	}
+	ttl, _ := s.ttl.TTL(key)
+	return ttl
+}
+
+// expiry converts a memcached expiration time into a time to live: 0 never
+// expires, up to 30 days is a number of seconds and anything larger is a unix
+// timestamp. expired is true for times in the past, which expire the item at once.
+func (s *Server) expiry(exptime int64) (ttl time.Duration, expired bool, err error) {
+	switch {
+	case exptime == 0:
+		return 0, false, nil
+	case exptime < 0:
+		return 0, true, nil
+	case exptime <= maxRelativeExptime:
+		ttl = time.Duration(exptime) * time.Second
+	default:
+		ttl = time.Unix(exptime, 0).Sub(s.clock.Now())
+		if ttl <= 0 {
+			return 0, true, nil
+		}
+	}
+	if s.ttl == nil {
+		return 0, false, errNoTTL
+	}
+	return ttl, false, nil
+}
+
+// storeMode is the behavior of a storage command.
+type storeMode int
+
+const (
+	modeSet storeMode = iota
+	modeAdd
+	modeReplace
+	modeAppend
+	modePrepend
+)
+
+// storeResult is the outcome of a storage command.
+type storeResult int
+
+const (
+	stored storeResult = iota
+	notStored
+	exists
+	notFound
+)
+
+// storeRequest is a storage command.
+type storeRequest struct {
+	mode    storeMode
+	key     string
+	flags   uint32
+	ttl     time.Duration
+	expired bool
+	value   []byte
+	// cas, when hasCAS is set, must match the CAS value of the stored item.
+	cas    uint64
+	hasCAS bool
+}
+
+// store executes req, returning the stored item on success.
+func (s *Server) store(req storeRequest) (storeResult, *item) {
+	atomic.AddUint64(&s.cmdSet, 1)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var cur *item
+	var remaining time.Duration
+	found := false
+	if req.hasCAS || req.mode == modeAppend || req.mode == modePrepend {
+		cur, remaining, found = s.lookupTTL(req.key)
+	} else if req.mode != modeSet {
+		found = s.cache.Contains(req.key)
+	}
+
+	switch {
+	case req.hasCAS && !found:
+		return notFound, nil
+	case req.hasCAS && cur.cas != req.cas:
+		return exists, nil
+	case req.mode == modeAdd && found:
+		return notStored, nil
+	case req.mode != modeSet && req.mode != modeAdd && !found:
+		return notStored, nil
+	}
+
+	value, flags, ttl := req.value, req.flags, req.ttl
+	switch req.mode {
+	case modeAppend, modePrepend:
+		// Appending keeps the flags and expiration time of the item.
+		joined := make([]byte, 0, len(cur.value)+len(value))
+		if req.mode == modeAppend {
+			joined = append(append(joined, cur.value...), value...)
+		} else {
+			joined = append(append(joined, value...), cur.value...)
+		}
+		value, flags, ttl = joined, cur.flags, remaining
+	default:
+		if req.expired {
+			s.cache.Remove(req.key)
+			return stored, nil
+		}
+	}
+	return stored, s.put(req.key, value, flags, ttl)
+}
+
+// arithResult is the outcome of incr and decr.
+type arithResult int
+
+const (
+	arithOK arithResult = iota
+	arithNotFound
+	arithNonNumeric
+	arithExists
+)
+
+// arithRequest is an incr or decr command.
+type arithRequest struct {
+	key   string
+	decr  bool
+	delta uint64
+	// cas, when hasCAS is set, must match the CAS value of the stored item.
+	cas    uint64
+	hasCAS bool
+	// autovivify, when set, creates missing items with the value initial,
+	// expiring after ttl.
+	autovivify bool
+	initial    uint64
+	ttl        time.Duration
+}
+
+// arith executes req. Incrementing wraps around at 2^64 and decrementing
+// stops at 0, as in memcached.
+func (s *Server) arith(req arithRequest) (arithResult, uint64, *item) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	cur, remaining, ok := s.lookupTTL(req.key)
+	if !ok {
+		if !req.autovivify {
+			return arithNotFound, 0, nil
+		}
+		it := s.put(req.key, []byte(strconv.FormatUint(req.initial, 10)), 0, req.ttl)
+		return arithOK, req.initial, it
+	}
+	if req.hasCAS && cur.cas != req.cas {
+		return arithExists, 0, nil
+	}
+
+	n, err := strconv.ParseUint(string(cur.value), 10, 64)
+	if err != nil {
+		return arithNonNumeric, 0, nil
+	}
+	switch {
+	case !req.decr:
+		n += req.delta
+	case req.delta > n:
+		n = 0
+	default:
+		n -= req.delta
+	}
+	it := s.put(req.key, []byte(strconv.FormatUint(n, 10)), cur.flags, remaining)
+	return arithOK, n, it
+}
+
+// touch sets the expiration time of key and reports whether it was found.
+func (s *Server) touch(key string, ttl time.Duration, expired bool) bool {
+	atomic.AddUint64(&s.cmdTouch, 1)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if expired {
+		if !s.cache.Contains(key) {
+			return false
+		}
+		s.cache.Remove(key)
+		return true
+	}
+	if s.ttl == nil {
+		return s.cache.Contains(key)
+	}
+	return s.ttl.Touch(key, ttl)
+}
+
+// remove deletes key, reporting whether it was found. If hasCAS is set, the
+// item is only deleted if its CAS value matches cas.
+func (s *Server) remove(key string, cas uint64, hasCAS bool) storeResult {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if hasCAS {
+		cur, ok := s.lookup(key)
+		if !ok {
+			return notFound
+		}
+		if cur.cas != cas {
+			return exists
+		}
+	} else if !s.cache.Contains(key) {
+		return notFound
+	}
+	s.cache.Remove(key)
+	return stored
+}
+
+// flush removes all items, after delay if it is positive. As in memcached, a
+// delayed flush invalidates every item stored before it takes effect,
+// including those stored after the command, and a later flush_all replaces
+// the pending one.
+func (s *Server) flush(delay time.Duration) {
+	atomic.AddUint64(&s.cmdFlush, 1)
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	if s.flushTimer != nil {
+		s.flushTimer.Stop()
+		s.flushTimer = nil
+	}
+	if delay > 0 {
+		s.flushTimer = s.clock.AfterFunc(delay, s.cache.Clear)
+		return
+	}
+	s.cache.Clear()
+}
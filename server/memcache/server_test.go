@@ -0,0 +1,351 @@
+package memcache
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raghavgh/gofast"
+)
+
+// client is a minimal memcached client for the tests.
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// startServer serves c on a loopback port and returns a client connected to it.
+func startServer(t *testing.T, c gofast.Cache, opts ...Option) (*Server, *client) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := NewServer(c, opts...)
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+	t.Cleanup(func() {
+		assert.NoError(t, srv.Close())
+		assert.ErrorIs(t, <-done, ErrServerClosed)
+	})
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return srv, &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// send writes lines, each terminated by CRLF.
+func (c *client) send(lines ...string) {
+	c.t.Helper()
+	_, err := c.conn.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+	require.NoError(c.t, err)
+}
+
+// line reads a reply line without its terminator.
+func (c *client) line() string {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	line, err := c.r.ReadString('\n')
+	require.NoError(c.t, err)
+	return strings.TrimSuffix(line, "\r\n")
+}
+
+// do sends lines and returns the first reply line.
+func (c *client) do(lines ...string) string {
+	c.t.Helper()
+	c.send(lines...)
+	return c.line()
+}
+
+// lines reads reply lines up to and including END.
+func (c *client) lines() []string {
+	c.t.Helper()
+	var lines []string
+	for {
+		line := c.line()
+		lines = append(lines, line)
+		if line == "END" {
+			return lines
+		}
+	}
+}
+
+// stats returns the statistics reported by the stats command.
+func (c *client) stats() map[string]string {
+	c.t.Helper()
+	c.send("stats")
+	stats := map[string]string{}
+	for _, line := range c.lines() {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "STAT" {
+			stats[fields[1]] = fields[2]
+		}
+	}
+	return stats
+}
+
+func TestServer(t *testing.T) {
+	t.Run("storage and retrieval", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Equal(t, "STORED", c.do("set a 5 0 3", "foo"))
+		c.send("get a b")
+		assert.Equal(t, []string{"VALUE a 5 3", "foo", "END"}, c.lines())
+
+		assert.Equal(t, "NOT_STORED", c.do("add a 0 0 1", "x"))
+		assert.Equal(t, "STORED", c.do("add b 0 0 1", "x"))
+		assert.Equal(t, "NOT_STORED", c.do("replace c 0 0 1", "x"))
+		assert.Equal(t, "STORED", c.do("replace b 0 0 1", "y"))
+		assert.Equal(t, "STORED", c.do("append a 0 0 3", "bar"))
+		assert.Equal(t, "STORED", c.do("prepend a 0 0 1", ">"))
+		assert.Equal(t, "NOT_STORED", c.do("append c 0 0 1", "x"))
+
+		c.send("get a b")
+		assert.Equal(t, []string{"VALUE a 5 7", ">foobar", "VALUE b 0 1", "y", "END"}, c.lines())
+	})
+
+	t.Run("cas", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Equal(t, "NOT_FOUND", c.do("cas a 0 0 1 1", "x"))
+		c.do("set a 0 0 1", "x")
+		c.send("gets a")
+		lines := c.lines()
+		require.Len(t, lines, 3)
+		cas := strings.Fields(lines[0])[4]
+
+		assert.Equal(t, "STORED", c.do("cas a 0 0 1 "+cas, "y"))
+		assert.Equal(t, "EXISTS", c.do("cas a 0 0 1 "+cas, "z"))
+		c.send("get a")
+		assert.Equal(t, []string{"VALUE a 0 1", "y", "END"}, c.lines())
+	})
+
+	t.Run("delete incr decr", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		c.do("set n 0 0 2", "10")
+		assert.Equal(t, "15", c.do("incr n 5"))
+		assert.Equal(t, "0", c.do("decr n 100"))
+		assert.Equal(t, "NOT_FOUND", c.do("incr m 1"))
+		c.do("set s 0 0 3", "abc")
+		assert.Equal(t, "CLIENT_ERROR cannot increment or decrement non-numeric value", c.do("incr s 1"))
+		c.do("set max 0 0 20", "18446744073709551615")
+		assert.Equal(t, "1", c.do("incr max 2"))
+
+		assert.Equal(t, "DELETED", c.do("delete n"))
+		assert.Equal(t, "NOT_FOUND", c.do("delete n"))
+	})
+
+	t.Run("noreply", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		c.send("set a 0 0 1 noreply", "1", "incr a 1 noreply", "delete b noreply")
+		c.send("get a")
+		assert.Equal(t, []string{"VALUE a 0 1", "2", "END"}, c.lines())
+	})
+
+	t.Run("touch and expiration", func(t *testing.T) {
+		clock := gofast.NewFakeClock(time.Now())
+		_, c := startServer(t, gofast.NewExpiring(gofast.NewCache(10, gofast.LRU), gofast.WithExpiringClock(clock)), WithClock(clock))
+
+		c.do("set a 0 1 1", "x")
+		c.do("set b 0 1 1", "x")
+		c.do("set gone 0 -1 1", "x")
+		assert.Equal(t, "TOUCHED", c.do("touch a 100"))
+		assert.Equal(t, "NOT_FOUND", c.do("touch c 100"))
+		c.send("gat 0 b")
+		assert.Equal(t, []string{"VALUE b 0 1", "x", "END"}, c.lines())
+		c.do("set short 0 1 1", "x")
+		absolute := strconv.FormatInt(clock.Now().Add(time.Hour).Unix(), 10)
+		c.do("set abs 0 "+absolute+" 1", "x")
+
+		clock.Advance(1100 * time.Millisecond)
+		c.send("get a b short gone")
+		assert.Equal(t, []string{"VALUE a 0 1", "x", "VALUE b 0 1", "x", "END"}, c.lines())
+		assert.Equal(t, "1", c.stats()["get_expired"])
+
+		clock.Advance(2 * time.Hour)
+		c.send("get abs")
+		assert.Equal(t, []string{"END"}, c.lines())
+	})
+
+	t.Run("append and incr keep the expiration", func(t *testing.T) {
+		clock := gofast.NewFakeClock(time.Now())
+		_, c := startServer(t, gofast.NewExpiring(gofast.NewCache(10, gofast.LRU), gofast.WithExpiringClock(clock)), WithClock(clock))
+
+		c.do("set s 0 10 1", "x")
+		c.do("set n 0 10 1", "1")
+		clock.Advance(5 * time.Second)
+		assert.Equal(t, "STORED", c.do("append s 0 0 1", "y"))
+		assert.Equal(t, "2", c.do("incr n 1"))
+
+		clock.Advance(5 * time.Second)
+		c.send("get s n")
+		assert.Equal(t, []string{"END"}, c.lines())
+	})
+
+	t.Run("expiration requires a ttl cache", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Equal(t, "SERVER_ERROR expiration is not supported by this cache", c.do("set a 0 10 1", "x"))
+		assert.Equal(t, "STORED", c.do("set a 0 0 1", "x"))
+		assert.Equal(t, "TOUCHED", c.do("touch a 0"))
+	})
+
+	t.Run("flush_all", func(t *testing.T) {
+		clock := gofast.NewFakeClock(time.Now())
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU), WithClock(clock))
+
+		c.do("set a 0 0 1", "x")
+		assert.Equal(t, "OK", c.do("flush_all"))
+		c.send("get a")
+		assert.Equal(t, []string{"END"}, c.lines())
+
+		c.do("set a 0 0 1", "x")
+		assert.Equal(t, "OK", c.do("flush_all 10"))
+		c.send("get a")
+		assert.Equal(t, []string{"VALUE a 0 1", "x", "END"}, c.lines())
+		clock.Advance(10 * time.Second)
+		c.send("get a")
+		assert.Equal(t, []string{"END"}, c.lines())
+	})
+
+	t.Run("flush_all replaces the pending one", func(t *testing.T) {
+		clock := gofast.NewFakeClock(time.Now())
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU), WithClock(clock))
+
+		c.do("set a 0 0 1", "x")
+		assert.Equal(t, "OK", c.do("flush_all 10"))
+		assert.Equal(t, "OK", c.do("flush_all 20"))
+		clock.Advance(10 * time.Second)
+		c.send("get a")
+		assert.Equal(t, []string{"VALUE a 0 1", "x", "END"}, c.lines())
+
+		// Items stored before the flush takes effect are invalidated too.
+		c.do("set b 0 0 1", "y")
+		clock.Advance(10 * time.Second)
+		c.send("get a b")
+		assert.Equal(t, []string{"END"}, c.lines())
+
+		assert.Equal(t, "OK", c.do("flush_all 10"))
+		assert.Equal(t, "OK", c.do("flush_all"))
+		c.do("set c 0 0 1", "z")
+		clock.Advance(10 * time.Second)
+		c.send("get c")
+		assert.Equal(t, []string{"VALUE c 0 1", "z", "END"}, c.lines())
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(1, gofast.LRU))
+
+		c.do("set a 0 0 1", "x")
+		c.do("set b 0 0 1", "x")
+		c.send("get a b")
+		c.lines()
+
+		stats := c.stats()
+		assert.Equal(t, "1", stats["get_hits"])
+		assert.Equal(t, "1", stats["get_misses"])
+		assert.Equal(t, "1", stats["evictions"])
+		assert.Equal(t, "1", stats["curr_items"])
+		assert.Equal(t, "2", stats["cmd_get"])
+		assert.Equal(t, "2", stats["cmd_set"])
+		assert.Equal(t, "1", stats["curr_connections"])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Equal(t, "ERROR", c.do("bogus"))
+		assert.Equal(t, "ERROR", c.do("get"))
+		assert.Equal(t, "ERROR", c.do("get "+strings.Repeat("k", maxKeyLen+1)))
+		assert.Equal(t, "CLIENT_ERROR bad command line format", c.do("set a x 0 1"))
+		assert.Equal(t, "VERSION gofast", c.do("version"))
+
+		c.send("set big 0 0 "+strconv.Itoa(maxItemSize+1), strings.Repeat("x", maxItemSize+1))
+		assert.Equal(t, "SERVER_ERROR object too large for cache", c.line())
+
+		assert.Equal(t, "CLIENT_ERROR bad data chunk", c.do("set a 0 0 1", "xyz"))
+		_, err := c.r.ReadByte()
+		assert.Error(t, err)
+	})
+
+	t.Run("quit", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		c.send("quit")
+		_, err := c.r.ReadByte()
+		assert.Error(t, err)
+	})
+}
+
+func TestServer_Meta(t *testing.T) {
+	t.Run("get and set", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewExpiring(gofast.NewCache(10, gofast.LRU)))
+
+		assert.Equal(t, "EN", c.do("mg a v"))
+		assert.Equal(t, "HD kb O123", c.do("ms b 3 F7 T100 kb O123", "foo"))
+		assert.Equal(t, "VA 3 f7 s3 t100 kb", c.do("mg b v f s t k"))
+		assert.Equal(t, "foo", c.line())
+		assert.Equal(t, "HD", c.do("mg b"))
+
+		assert.Equal(t, "NS", c.do("ms b 1 ME", "x"))
+		assert.Equal(t, "NS", c.do("ms c 1 MR", "x"))
+		assert.Equal(t, "HD", c.do("ms b 3 MA", "bar"))
+		assert.Equal(t, "VA 6", c.do("mg b v"))
+		assert.Equal(t, "foobar", c.line())
+		assert.Equal(t, "CLIENT_ERROR invalid mode for ms", c.do("ms b 1 MX", "x"))
+	})
+
+	t.Run("cas", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		reply := c.do("ms a 1 c", "x")
+		require.True(t, strings.HasPrefix(reply, "HD c"))
+		cas := strings.TrimPrefix(reply, "HD c")
+
+		assert.Equal(t, "HD c"+cas, c.do("mg a c"))
+		assert.Equal(t, "EX", c.do("ms a 1 C999999", "y"))
+		assert.Equal(t, "NF", c.do("ms b 1 C1", "y"))
+		assert.Equal(t, "EX", c.do("md a C999999"))
+		assert.Equal(t, "HD", c.do("md a C"+cas))
+		assert.Equal(t, "NF", c.do("md a"))
+	})
+
+	t.Run("quiet mode", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		c.send("mg a v q", "ms a 1 q", "x", "md b q", "mg a v q", "mn")
+		assert.Equal(t, "VA 1", c.line())
+		assert.Equal(t, "x", c.line())
+		assert.Equal(t, "MN", c.line())
+	})
+
+	t.Run("arithmetic", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewExpiring(gofast.NewCache(10, gofast.LRU)))
+
+		assert.Equal(t, "NF", c.do("ma n"))
+		assert.Equal(t, "VA 2", c.do("ma n N100 J10 v"))
+		assert.Equal(t, "10", c.line())
+		assert.Equal(t, "VA 2 t100", c.do("ma n D5 v t"))
+		assert.Equal(t, "15", c.line())
+		assert.Equal(t, "HD", c.do("ma n MD D20"))
+		assert.Equal(t, "VA 1", c.do("mg n v"))
+		assert.Equal(t, "0", c.line())
+	})
+
+	t.Run("touch", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewExpiring(gofast.NewCache(10, gofast.LRU)))
+
+		c.do("ms a 1", "x")
+		assert.Equal(t, "HD t-1", c.do("mg a t"))
+		assert.Equal(t, "HD t50", c.do("mg a T50 t"))
+	})
+}
@@ -0,0 +1,451 @@
+package memcache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/raghavgh/gofast"
+)
+
+var (
+	// errLineTooLong is returned for command lines longer than maxLineLen.
+	errLineTooLong = errors.New("line too long")
+	// errBadDataChunk is returned when a data block is not terminated by CRLF.
+	errBadDataChunk = errors.New("bad data chunk")
+	// errQuit is returned by the quit command to close the connection.
+	errQuit = errors.New("quit")
+)
+
+// conn is a client connection.
+type conn struct {
+	s *Server
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// readLine reads a line terminated by "\r\n" or "\n", without the terminator.
+func (c *conn) readLine() (string, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := c.r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxLineLen {
+			return "", errLineTooLong
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
+// readData reads a data block of n bytes followed by CRLF. Blocks larger than
+// maxItemSize are discarded, and nil is returned for them.
+func (c *conn) readData(n int) ([]byte, error) {
+	if n > maxItemSize {
+		_, err := io.CopyN(io.Discard, c.r, int64(n)+2)
+		return nil, err
+	}
+	buf := make([]byte, n+2)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return nil, err
+	}
+	if buf[n] != '\r' || buf[n+1] != '\n' {
+		c.clientError(errBadDataChunk.Error())
+		return nil, errBadDataChunk
+	}
+	return buf[:n], nil
+}
+
+func (c *conn) reply(s string) {
+	c.w.WriteString(s)
+	c.w.WriteString("\r\n")
+}
+
+func (c *conn) clientError(msg string) {
+	c.reply("CLIENT_ERROR " + msg)
+}
+
+func (c *conn) serverError(msg string) {
+	c.reply("SERVER_ERROR " + msg)
+}
+
+func (c *conn) value(key string, it *item, withCAS bool) {
+	c.w.WriteString("VALUE ")
+	c.w.WriteString(key)
+	c.w.WriteByte(' ')
+	c.w.WriteString(strconv.FormatUint(uint64(it.flags), 10))
+	c.w.WriteByte(' ')
+	c.w.WriteString(strconv.Itoa(len(it.value)))
+	if withCAS {
+		c.w.WriteByte(' ')
+		c.w.WriteString(strconv.FormatUint(it.cas, 10))
+	}
+	c.w.WriteString("\r\n")
+	c.w.Write(it.value)
+	c.w.WriteString("\r\n")
+}
+
+// exec runs the command line and writes its reply. It returns an error when
+// the connection must be closed.
+func (c *conn) exec(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		c.reply("ERROR")
+		return nil
+	}
+
+	args := fields[1:]
+	switch fields[0] {
+	case "get":
+		return c.get(args, false)
+	case "gets":
+		return c.get(args, true)
+	case "gat":
+		return c.gat(args, false)
+	case "gats":
+		return c.gat(args, true)
+	case "set":
+		return c.storage(args, modeSet, false)
+	case "add":
+		return c.storage(args, modeAdd, false)
+	case "replace":
+		return c.storage(args, modeReplace, false)
+	case "append":
+		return c.storage(args, modeAppend, false)
+	case "prepend":
+		return c.storage(args, modePrepend, false)
+	case "cas":
+		return c.storage(args, modeSet, true)
+	case "delete":
+		return c.delete(args)
+	case "incr":
+		return c.arith(args, false)
+	case "decr":
+		return c.arith(args, true)
+	case "touch":
+		return c.touch(args)
+	case "flush_all":
+		return c.flushAll(args)
+	case "stats":
+		return c.stats(args)
+	case "version":
+		c.reply("VERSION gofast")
+		return nil
+	case "verbosity":
+		if noreply(args) {
+			return nil
+		}
+		c.reply("OK")
+		return nil
+	case "quit":
+		return errQuit
+	case "mg":
+		return c.metaGet(args)
+	case "ms":
+		return c.metaSet(args)
+	case "md":
+		return c.metaDelete(args)
+	case "ma":
+		return c.metaArith(args)
+	case "mn":
+		c.reply("MN")
+		return nil
+	default:
+		c.reply("ERROR")
+		return nil
+	}
+}
+
+// get implements "get <key>*" and "gets <key>*".
+func (c *conn) get(keys []string, withCAS bool) error {
+	if len(keys) == 0 || !validKeys(keys) {
+		c.reply("ERROR")
+		return nil
+	}
+	for _, key := range keys {
+		atomic.AddUint64(&c.s.cmdGet, 1)
+		if it, ok := c.s.lookup(key); ok {
+			c.value(key, it, withCAS)
+		}
+	}
+	c.reply("END")
+	return nil
+}
+
+// gat implements "gat <exptime> <key>*" and "gats <exptime> <key>*", which
+// touch the keys before getting them.
+func (c *conn) gat(args []string, withCAS bool) error {
+	if len(args) < 2 || !validKeys(args[1:]) {
+		c.reply("ERROR")
+		return nil
+	}
+	ttl, expired, ok := c.exptime(args[0])
+	if !ok {
+		return nil
+	}
+	for _, key := range args[1:] {
+		if c.s.touch(key, ttl, expired) {
+			atomic.AddUint64(&c.s.cmdGet, 1)
+			if it, ok := c.s.lookup(key); ok {
+				c.value(key, it, withCAS)
+			}
+		}
+	}
+	c.reply("END")
+	return nil
+}
+
+// storage implements "<command> <key> <flags> <exptime> <bytes> [noreply]"
+// and "cas <key> <flags> <exptime> <bytes> <cas unique> [noreply]".
+func (c *conn) storage(args []string, mode storeMode, withCAS bool) error {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	want := 4
+	if withCAS {
+		want = 5
+	}
+	if len(args) != want || !validKey(args[0]) {
+		c.reply("ERROR")
+		return nil
+	}
+
+	flags, err1 := strconv.ParseUint(args[1], 10, 32)
+	exptime, err2 := strconv.ParseInt(args[2], 10, 64)
+	size, err3 := strconv.Atoi(args[3])
+	if err1 != nil || err2 != nil || err3 != nil || size < 0 {
+		c.clientError("bad command line format")
+		return nil
+	}
+	req := storeRequest{mode: mode, key: args[0], flags: uint32(flags)}
+	if withCAS {
+		cas, err := strconv.ParseUint(args[4], 10, 64)
+		if err != nil {
+			c.clientError("bad command line format")
+			return nil
+		}
+		req.cas, req.hasCAS = cas, true
+	}
+
+	value, err := c.readData(size)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		c.serverError("object too large for cache")
+		return nil
+	}
+	req.value = value
+
+	req.ttl, req.expired, err = c.s.expiry(exptime)
+	if err != nil {
+		c.serverError(err.Error())
+		return nil
+	}
+	result, _ := c.s.store(req)
+	if !quiet {
+		c.reply([...]string{stored: "STORED", notStored: "NOT_STORED", exists: "EXISTS", notFound: "NOT_FOUND"}[result])
+	}
+	return nil
+}
+
+// delete implements "delete <key> [noreply]".
+func (c *conn) delete(args []string) error {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	// Old clients send a hold time of 0, which memcached still accepts.
+	if len(args) == 2 && args[1] == "0" {
+		args = args[:1]
+	}
+	if len(args) != 1 || !validKey(args[0]) {
+		c.clientError("bad command line format.  Usage: delete <key> [noreply]")
+		return nil
+	}
+
+	result := c.s.remove(args[0], 0, false)
+	if !quiet {
+		if result == stored {
+			c.reply("DELETED")
+		} else {
+			c.reply("NOT_FOUND")
+		}
+	}
+	return nil
+}
+
+// arith implements "incr <key> <value> [noreply]" and "decr <key> <value> [noreply]".
+func (c *conn) arith(args []string, decr bool) error {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	if len(args) != 2 || !validKey(args[0]) {
+		c.reply("ERROR")
+		return nil
+	}
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		c.clientError("invalid numeric delta argument")
+		return nil
+	}
+
+	result, n, _ := c.s.arith(arithRequest{key: args[0], decr: decr, delta: delta})
+	if quiet {
+		return nil
+	}
+	switch result {
+	case arithNotFound:
+		c.reply("NOT_FOUND")
+	case arithNonNumeric:
+		c.clientError("cannot increment or decrement non-numeric value")
+	default:
+		c.reply(strconv.FormatUint(n, 10))
+	}
+	return nil
+}
+
+// touch implements "touch <key> <exptime> [noreply]".
+func (c *conn) touch(args []string) error {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	if len(args) != 2 || !validKey(args[0]) {
+		c.reply("ERROR")
+		return nil
+	}
+	ttl, expired, ok := c.exptime(args[1])
+	if !ok {
+		return nil
+	}
+
+	found := c.s.touch(args[0], ttl, expired)
+	if !quiet {
+		if found {
+			c.reply("TOUCHED")
+		} else {
+			c.reply("NOT_FOUND")
+		}
+	}
+	return nil
+}
+
+// flushAll implements "flush_all [delay] [noreply]".
+func (c *conn) flushAll(args []string) error {
+	quiet := noreply(args)
+	if quiet {
+		args = args[:len(args)-1]
+	}
+	var delay int64
+	if len(args) > 1 {
+		c.reply("ERROR")
+		return nil
+	}
+	if len(args) == 1 {
+		var err error
+		if delay, err = strconv.ParseInt(args[0], 10, 64); err != nil || delay < 0 {
+			c.clientError("bad command line format")
+			return nil
+		}
+	}
+
+	c.s.flush(time.Duration(delay) * time.Second)
+	if !quiet {
+		c.reply("OK")
+	}
+	return nil
+}
+
+// stats implements "stats", mapping the cache statistics onto memcached's:
+// hits and misses to get_hits and get_misses, evictions to evictions and
+// expired entries dropped on read to get_expired. Other groups of statistics
+// are empty.
+func (c *conn) stats(args []string) error {
+	if len(args) > 0 {
+		c.reply("END")
+		return nil
+	}
+
+	var st gofast.Stats
+	if reporter, ok := c.s.cache.(gofast.StatsReporter); ok {
+		st = reporter.Stats()
+	}
+	now := c.s.clock.Now()
+	stat := func(name string, val any) {
+		c.reply(fmt.Sprintf("STAT %s %v", name, val))
+	}
+	stat("pid", os.Getpid())
+	stat("uptime", int64(now.Sub(c.s.started)/time.Second))
+	stat("time", now.Unix())
+	stat("version", "gofast")
+	stat("curr_connections", c.s.net.Clients())
+	stat("total_connections", c.s.net.Accepted())
+	stat("cmd_get", atomic.LoadUint64(&c.s.cmdGet))
+	stat("cmd_set", atomic.LoadUint64(&c.s.cmdSet))
+	stat("cmd_flush", atomic.LoadUint64(&c.s.cmdFlush))
+	stat("cmd_touch", atomic.LoadUint64(&c.s.cmdTouch))
+	stat("get_hits", st.Hits)
+	stat("get_misses", st.Misses)
+	stat("get_expired", st.Expirations)
+	stat("evictions", st.Evictions)
+	stat("curr_items", c.s.cache.Len())
+	c.reply("END")
+	return nil
+}
+
+// exptime parses an expiration time argument, replying with an error and
+// returning false if it is invalid or the cache does not support it.
+func (c *conn) exptime(arg string) (time.Duration, bool, bool) {
+	exptime, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		c.clientError("invalid exptime argument")
+		return 0, false, false
+	}
+	ttl, expired, err := c.s.expiry(exptime)
+	if err != nil {
+		c.serverError(err.Error())
+		return 0, false, false
+	}
+	return ttl, expired, true
+}
+
+// noreply reports whether the last argument is "noreply".
+func noreply(args []string) bool {
+	return len(args) > 0 && args[len(args)-1] == "noreply"
+}
+
+// validKey reports whether key is a valid memcached key: at most 250 bytes
+// without spaces or control characters.
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeyLen {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func validKeys(keys []string) bool {
+	for _, key := range keys {
+		if !validKey(key) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// maxBulkLen is the largest bulk string accepted from a client, as in Redis.
+	maxBulkLen = 512 << 20
+	// maxArgs is the largest number of arguments accepted in one command.
+	maxArgs = 1 << 20
+	// maxInlineLen is the longest inline command accepted from a client.
+	maxInlineLen = 64 << 10
+	// maxPreallocArgs is the largest number of arguments allocated for before
+	// they arrive, since the count sent by a client cannot be trusted.
+	maxPreallocArgs = 16
+	// maxPreallocBulk is the largest bulk string allocated for before it
+	// arrives; longer ones grow as their bytes are read.
+	maxPreallocBulk = 64 << 10
+)
+
+// errProtocol is returned for malformed requests; the connection is closed after replying.
+var errProtocol = errors.New("protocol error")
+
+// reader reads commands sent by a client.
+type reader struct {
+	r *bufio.Reader
+}
+
+func newReader(r io.Reader) *reader {
+	return &reader{r: bufio.NewReader(r)}
+}
+
+// buffered reports whether more input is already buffered, which is the case
+// when the client pipelines commands.
+func (r *reader) buffered() bool {
+	return r.r.Buffered() > 0
+}
+
+// readCommand reads a command, either as a RESP array of bulk strings or as an
+// inline command of space separated words. It returns an empty command for a blank line.
+func (r *reader) readCommand() ([][]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return bytes.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n < 0 || n > maxArgs {
+		return nil, fmt.Errorf("%w: invalid multibulk length", errProtocol)
+	}
+	size := n
+	if size > maxPreallocArgs {
+		size = maxPreallocArgs
+	}
+	args := make([][]byte, 0, size)
+	for i := 0; i < n; i++ {
+		arg, err := r.readBulk()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// readBulk reads a "$<len>\r\n<data>\r\n" bulk string.
+func (r *reader) readBulk() ([]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '$' {
+		return nil, fmt.Errorf("%w: expected '$', got '%s'", errProtocol, line)
+	}
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n < 0 || n > maxBulkLen {
+		return nil, fmt.Errorf("%w: invalid bulk length", errProtocol)
+	}
+
+	size := n + 2
+	if size > maxPreallocBulk {
+		size = maxPreallocBulk
+	}
+	buf := make([]byte, 0, size)
+	for len(buf) < n+2 {
+		if len(buf) == cap(buf) {
+			size = 2 * cap(buf)
+			if size > n+2 {
+				size = n + 2
+			}
+			grown := make([]byte, len(buf), size)
+			copy(grown, buf)
+			buf = grown
+		}
+		read, err := io.ReadFull(r.r, buf[len(buf):cap(buf)])
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:len(buf)+read]
+	}
+	if buf[n] != '\r' || buf[n+1] != '\n' {
+		return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", errProtocol)
+	}
+	return buf[:n], nil
+}
+
+// readLine reads a line terminated by "\r\n" or "\n", without the terminator.
+func (r *reader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxInlineLen {
+			return nil, fmt.Errorf("%w: too big inline request", errProtocol)
+		}
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
+
+// writer writes RESP replies.
+type writer struct {
+	w *bufio.Writer
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{w: bufio.NewWriter(w)}
+}
+
+func (w *writer) simple(s string) {
+	w.w.WriteByte('+')
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *writer) error(s string) {
+	w.w.WriteByte('-')
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *writer) integer(n int64) {
+	w.w.WriteByte(':')
+	w.w.WriteString(strconv.FormatInt(n, 10))
+	w.w.WriteString("\r\n")
+}
+
+func (w *writer) bulk(b []byte) {
+	w.w.WriteByte('$')
+	w.w.WriteString(strconv.Itoa(len(b)))
+	w.w.WriteString("\r\n")
+	w.w.Write(b)
+	w.w.WriteString("\r\n")
+}
+
+func (w *writer) null() {
+	w.w.WriteString("$-1\r\n")
+}
+
+func (w *writer) array(n int) {
+	w.w.WriteByte('*')
+	w.w.WriteString(strconv.Itoa(n))
+	w.w.WriteString("\r\n")
+}
+
+func (w *writer) flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,49 @@
+package resp
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_readCommand(t *testing.T) {
+	t.Run("negative multibulk length", func(t *testing.T) {
+		_, err := newReader(strings.NewReader("*-5\r\n")).readCommand()
+		assert.ErrorIs(t, err, errProtocol)
+	})
+
+	t.Run("huge multibulk length", func(t *testing.T) {
+		r := newReader(strings.NewReader("*1048576\r\n$3\r\nGET\r\n"))
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := r.readCommand()
+		runtime.ReadMemStats(&after)
+
+		// The client hung up after one argument, and the count it sent was
+		// not allocated for.
+		assert.Error(t, err)
+		assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+	})
+
+	t.Run("huge bulk length", func(t *testing.T) {
+		r := newReader(strings.NewReader("*1\r\n$536870912\r\nGET"))
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := r.readCommand()
+		runtime.ReadMemStats(&after)
+
+		assert.Error(t, err)
+		assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+	})
+
+	t.Run("long bulk string", func(t *testing.T) {
+		val := strings.Repeat("v", 3*maxPreallocBulk+5)
+		args, err := newReader(strings.NewReader("*2\r\n$3\r\nGET\r\n$" + strconv.Itoa(len(val)) + "\r\n" + val + "\r\n")).readCommand()
+		require.NoError(t, err)
+		assert.Equal(t, val, string(args[1]))
+	})
+}
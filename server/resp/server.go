@@ -0,0 +1,458 @@
+// Package resp serves a gofast cache over the Redis serialization protocol
+// (RESP), so that Redis clients and tools such as redis-cli can use it.
+//
+// The server understands a subset of the Redis string commands: PING, ECHO,
+// GET, SET (with EX, PX, NX and XX), DEL, EXISTS, TTL, PTTL, DBSIZE,
+// FLUSHDB, FLUSHALL, MGET, MSET, INFO, SELECT 0 and QUIT. Values are stored
+// as []byte.
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/raghavgh/gofast"
+	"github.com/raghavgh/gofast/internal/netserver"
+)
+
+// ErrServerClosed is returned by Serve and ListenAndServe after Close.
+var ErrServerClosed = netserver.ErrServerClosed
+
+// ttlCache is implemented by caches supporting per-entry time to live, such as gofast.Expiring.
+type ttlCache interface {
+	PutWithTTL(key string, val any, ttl time.Duration)
+	TTL(key string) (time.Duration, bool)
+}
+
+// Option configures a Server.
+type Option func(s *Server)
+
+// WithClock sets the clock of the uptime. Defaults to gofast.RealClock.
+func WithClock(clock gofast.Clock) Option {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// Server serves a cache to RESP clients.
+type Server struct {
+	cache   gofast.Cache
+	ttl     ttlCache
+	clock   gofast.Clock
+	started time.Time
+
+	// writeMu makes the commands that read before they write, such as SET NX
+	// and MSET, atomic with respect to each other.
+	writeMu *sync.Mutex
+
+	net      *netserver.Server
+	commands uint64
+}
+
+// NewServer returns a server for c. SET with EX or PX requires c to support
+// per-entry time to live, as gofast.Expiring does.
+func NewServer(c gofast.Cache, opts ...Option) *Server {
+	s := &Server{
+		cache:   c,
+		clock:   gofast.RealClock(),
+		writeMu: &sync.Mutex{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.started = s.clock.Now()
+	s.ttl, _ = c.(ttlCache)
+	s.net = netserver.New(s.serveConn)
+	return s
+}
+
+// ListenAndServe listens on the TCP address addr and serves clients connecting to it.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves each of them in its own goroutine,
+// until l fails or the server is closed. It always returns a non-nil error.
+func (s *Server) Serve(l net.Listener) error {
+	return s.net.Serve(l)
+}
+
+// Close closes the listeners and the client connections, and waits for the
+// connections to be done.
+func (s *Server) Close() error {
+	return s.net.Close()
+}
+
+// serveConn reads commands from conn and writes their replies, flushing them
+// once no more pipelined commands are buffered.
+func (s *Server) serveConn(conn net.Conn) {
+	r := newReader(conn)
+	w := newWriter(conn)
+	for {
+		args, err := r.readCommand()
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				w.error("ERR " + err.Error())
+				w.flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		atomic.AddUint64(&s.commands, 1)
+		quit := s.exec(w, args)
+		if !r.buffered() || quit {
+			if err := w.flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// command is the implementation of a command. args excludes the command name.
+type command struct {
+	// min and max bound the number of arguments; max is -1 for no bound.
+	min, max int
+	run      func(s *Server, w *writer, args [][]byte)
+}
+
+var commands = map[string]command{
+	"PING":     {0, 1, (*Server).ping},
+	"ECHO":     {1, 1, (*Server).echo},
+	"SELECT":   {1, 1, (*Server).selectDB},
+	"COMMAND":  {0, -1, (*Server).command},
+	"GET":      {1, 1, (*Server).get},
+	"SET":      {2, -1, (*Server).set},
+	"DEL":      {1, -1, (*Server).del},
+	"EXISTS":   {1, -1, (*Server).exists},
+	"TTL":      {1, 1, (*Server).ttlSeconds},
+	"PTTL":     {1, 1, (*Server).ttlMillis},
+	"DBSIZE":   {0, 0, (*Server).dbsize},
+	"FLUSHDB":  {0, 1, (*Server).flush},
+	"FLUSHALL": {0, 1, (*Server).flush},
+	"MGET":     {1, -1, (*Server).mget},
+	"MSET":     {2, -1, (*Server).mset},
+	"INFO":     {0, 1, (*Server).info},
+}
+
+// exec runs the command args and writes its reply. It reports whether the
+// client asked to close the connection.
+func (s *Server) exec(w *writer, args [][]byte) bool {
+	name := strings.ToUpper(string(args[0]))
+	if name == "QUIT" {
+		w.simple("OK")
+		return true
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		w.error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+		return false
+	}
+	n := len(args) - 1
+	if n < cmd.min || (cmd.max >= 0 && n > cmd.max) {
+		w.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+		return false
+	}
+	cmd.run(s, w, args[1:])
+	return false
+}
+
+func (s *Server) ping(w *writer, args [][]byte) {
+	if len(args) == 0 {
+		w.simple("PONG")
+		return
+	}
+	w.bulk(args[0])
+}
+
+func (s *Server) echo(w *writer, args [][]byte) {
+	w.bulk(args[0])
+}
+
+func (s *Server) selectDB(w *writer, args [][]byte) {
+	if string(args[0]) != "0" {
+		w.error("ERR DB index is out of range")
+		return
+	}
+	w.simple("OK")
+}
+
+// command replies with an empty command table, which is enough for clients
+// probing the server on connect.
+func (s *Server) command(w *writer, args [][]byte) {
+	w.array(0)
+}
+
+func (s *Server) get(w *writer, args [][]byte) {
+	val, ok := s.cache.Get(string(args[0]))
+	if !ok {
+		w.null()
+		return
+	}
+	b, ok := toBytes(val)
+	if !ok {
+		w.error("WRONGTYPE Operation against a key holding the wrong kind of value")
+		return
+	}
+	w.bulk(b)
+}
+
+func (s *Server) set(w *writer, args [][]byte) {
+	key, val := string(args[0]), args[1]
+
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(string(args[i])); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if ttl != 0 || i+1 == len(args) {
+				w.error("ERR syntax error")
+				return
+			}
+			i++
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				w.error("ERR value is not an integer or out of range")
+				return
+			}
+			unit := time.Second
+			if opt == "PX" {
+				unit = time.Millisecond
+			}
+			if n <= 0 || n > math.MaxInt64/int64(unit) {
+				w.error("ERR invalid expire time in 'set' command")
+				return
+			}
+			ttl = time.Duration(n) * unit
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+	if nx && xx {
+		w.error("ERR syntax error")
+		return
+	}
+	if ttl != 0 && s.ttl == nil {
+		w.error("ERR expiration is not supported by this cache")
+		return
+	}
+
+	if nx || xx {
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+		if s.cache.Contains(key) != xx {
+			w.null()
+			return
+		}
+	}
+	s.put(key, val, ttl)
+	w.simple("OK")
+}
+
+func (s *Server) del(w *writer, args [][]byte) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var n int64
+	for _, arg := range args {
+		key := string(arg)
+		if s.cache.Contains(key) {
+			s.cache.Remove(key)
+			n++
+		}
+	}
+	w.integer(n)
+}
+
+func (s *Server) exists(w *writer, args [][]byte) {
+	var n int64
+	for _, arg := range args {
+		if s.cache.Contains(string(arg)) {
+			n++
+		}
+	}
+	w.integer(n)
+}
+
+func (s *Server) ttlSeconds(w *writer, args [][]byte) {
+	s.writeTTL(w, string(args[0]), time.Second)
+}
+
+func (s *Server) ttlMillis(w *writer, args [][]byte) {
+	s.writeTTL(w, string(args[0]), time.Millisecond)
+}
+
+// writeTTL replies with the remaining time to live of key in unit, -1 if it
+// never expires and -2 if it does not exist, as Redis does.
+func (s *Server) writeTTL(w *writer, key string, unit time.Duration) {
+	if s.ttl == nil {
+		if s.cache.Contains(key) {
+			w.integer(-1)
+		} else {
+			w.integer(-2)
+		}
+		return
+	}
+
+	ttl, ok := s.ttl.TTL(key)
+	switch {
+	case !ok:
+		w.integer(-2)
+	case ttl == 0:
+		w.integer(-1)
+	default:
+		// Round up, so that a key expiring in 1.5s reports 2s rather than 1s.
+		w.integer(int64((ttl + unit - 1) / unit))
+	}
+}
+
+func (s *Server) dbsize(w *writer, args [][]byte) {
+	w.integer(int64(s.cache.Len()))
+}
+
+func (s *Server) flush(w *writer, args [][]byte) {
+	if len(args) == 1 {
+		if mode := strings.ToUpper(string(args[0])); mode != "ASYNC" && mode != "SYNC" {
+			w.error("ERR syntax error")
+			return
+		}
+	}
+	s.cache.Clear()
+	w.simple("OK")
+}
+
+func (s *Server) mget(w *writer, args [][]byte) {
+	w.array(len(args))
+	for _, arg := range args {
+		val, ok := s.cache.Get(string(arg))
+		if !ok {
+			w.null()
+			continue
+		}
+		// Unlike GET, MGET replies nil for values of the wrong type.
+		b, ok := toBytes(val)
+		if !ok {
+			w.null()
+			continue
+		}
+		w.bulk(b)
+	}
+}
+
+func (s *Server) mset(w *writer, args [][]byte) {
+	if len(args)%2 != 0 {
+		w.error("ERR wrong number of arguments for 'mset' command")
+		return
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	for i := 0; i < len(args); i += 2 {
+		s.put(string(args[i]), args[i+1], 0)
+	}
+	w.simple("OK")
+}
+
+// put stores val under key, expiring after ttl if it is not 0.
+func (s *Server) put(key string, val []byte, ttl time.Duration) {
+	if s.ttl != nil {
+		s.ttl.PutWithTTL(key, val, ttl)
+		return
+	}
+	s.cache.Put(key, val)
+}
+
+// info replies with the server, clients, stats and keyspace sections, or only
+// with the section given as argument.
+func (s *Server) info(w *writer, args [][]byte) {
+	section := "all"
+	if len(args) == 1 {
+		section = strings.ToLower(string(args[0]))
+	}
+
+	var b strings.Builder
+	sections := []struct {
+		name  string
+		write func(b io.Writer)
+	}{
+		{"server", s.infoServer},
+		{"clients", s.infoClients},
+		{"stats", s.infoStats},
+		{"keyspace", s.infoKeyspace},
+	}
+	for _, sec := range sections {
+		if section != "all" && section != "default" && section != "everything" && section != sec.name {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\r\n")
+		}
+		fmt.Fprintf(&b, "# %s\r\n", strings.ToUpper(sec.name[:1])+sec.name[1:])
+		sec.write(&b)
+	}
+	w.bulk([]byte(b.String()))
+}
+
+func (s *Server) infoServer(b io.Writer) {
+	uptime := s.clock.Now().Sub(s.started)
+	fmt.Fprintf(b, "gofast_mode:standalone\r\n")
+	fmt.Fprintf(b, "uptime_in_seconds:%d\r\n", int64(uptime/time.Second))
+	fmt.Fprintf(b, "uptime_in_days:%d\r\n", int64(uptime/(24*time.Hour)))
+}
+
+func (s *Server) infoClients(b io.Writer) {
+	fmt.Fprintf(b, "connected_clients:%d\r\n", s.net.Clients())
+}
+
+func (s *Server) infoStats(b io.Writer) {
+	var stats gofast.Stats
+	if reporter, ok := s.cache.(gofast.StatsReporter); ok {
+		stats = reporter.Stats()
+	}
+	fmt.Fprintf(b, "total_connections_received:%d\r\n", s.net.Accepted())
+	fmt.Fprintf(b, "total_commands_processed:%d\r\n", atomic.LoadUint64(&s.commands))
+	fmt.Fprintf(b, "expired_keys:%d\r\n", stats.Expirations)
+	fmt.Fprintf(b, "evicted_keys:%d\r\n", stats.Evictions)
+	fmt.Fprintf(b, "keyspace_hits:%d\r\n", stats.Hits)
+	fmt.Fprintf(b, "keyspace_misses:%d\r\n", stats.Misses)
+	fmt.Fprintf(b, "keyspace_hit_ratio:%.4f\r\n", stats.HitRatio())
+}
+
+func (s *Server) infoKeyspace(b io.Writer) {
+	if n := s.cache.Len(); n > 0 {
+		fmt.Fprintf(b, "db0:keys=%d\r\n", n)
+	}
+}
+
+// toBytes returns val as bytes if it is a []byte or a string, which is the
+// case for values stored by the server or by Go code sharing the cache.
+func toBytes(val any) ([]byte, bool) {
+	switch v := val.(type) {
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,261 @@
+package resp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/raghavgh/gofast"
+)
+
+// client is a minimal RESP client for the tests.
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// startServer serves c on a loopback port and returns a client connected to it.
+func startServer(t *testing.T, c gofast.Cache) (*Server, *client) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := NewServer(c)
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+	t.Cleanup(func() {
+		assert.NoError(t, srv.Close())
+		assert.ErrorIs(t, <-done, ErrServerClosed)
+	})
+
+	return srv, dial(t, l.Addr().String())
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// do sends args as a RESP array and returns the reply.
+func (c *client) do(args ...string) any {
+	c.t.Helper()
+	c.send(args...)
+	return c.reply()
+}
+
+func (c *client) send(args ...string) {
+	c.t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := c.conn.Write([]byte(b.String()))
+	require.NoError(c.t, err)
+}
+
+// reply reads a reply: simple strings as string, errors as error, integers as
+// int64, bulk strings as []byte, nil as nil and arrays as []any.
+func (c *client) reply() any {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	line, err := c.r.ReadString('\n')
+	require.NoError(c.t, err)
+	line = strings.TrimSuffix(line, "\r\n")
+
+	switch line[0] {
+	case '+':
+		return line[1:]
+	case '-':
+		return fmt.Errorf("%s", line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		require.NoError(c.t, err)
+		return n
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		require.NoError(c.t, err)
+		if n < 0 {
+			return nil
+		}
+		buf := make([]byte, n+2)
+		_, err = io.ReadFull(c.r, buf)
+		require.NoError(c.t, err)
+		return buf[:n]
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		require.NoError(c.t, err)
+		items := make([]any, n)
+		for i := range items {
+			items[i] = c.reply()
+		}
+		return items
+	}
+	c.t.Fatalf("unexpected reply %q", line)
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	t.Run("get set del exists", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Equal(t, "PONG", c.do("PING"))
+		assert.Equal(t, []byte("hi"), c.do("ECHO", "hi"))
+		assert.Nil(t, c.do("GET", "a"))
+		assert.Equal(t, "OK", c.do("SET", "a", "1"))
+		assert.Equal(t, []byte("1"), c.do("GET", "a"))
+		assert.Equal(t, int64(1), c.do("EXISTS", "a", "b"))
+		assert.Equal(t, int64(1), c.do("DBSIZE"))
+		assert.Equal(t, int64(1), c.do("DEL", "a", "b"))
+		assert.Equal(t, int64(0), c.do("EXISTS", "a"))
+	})
+
+	t.Run("set nx xx", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Nil(t, c.do("SET", "a", "1", "XX"))
+		assert.Equal(t, "OK", c.do("SET", "a", "1", "NX"))
+		assert.Nil(t, c.do("SET", "a", "2", "NX"))
+		assert.Equal(t, "OK", c.do("SET", "a", "3", "XX"))
+		assert.Equal(t, []byte("3"), c.do("GET", "a"))
+		assert.Error(t, c.do("SET", "a", "1", "NX", "XX").(error))
+		assert.Error(t, c.do("SET", "a", "1", "BOGUS").(error))
+	})
+
+	t.Run("set with expiration", func(t *testing.T) {
+		clock := gofast.NewFakeClock(time.Now())
+		_, c := startServer(t, gofast.NewExpiring(gofast.NewCache(10, gofast.LRU), gofast.WithExpiringClock(clock)))
+
+		assert.Equal(t, "OK", c.do("SET", "a", "1", "PX", "30"))
+		assert.Equal(t, "OK", c.do("SET", "b", "1", "EX", "100"))
+		assert.Equal(t, "OK", c.do("SET", "c", "1"))
+		assert.Equal(t, int64(100), c.do("TTL", "b"))
+		assert.Equal(t, int64(-1), c.do("TTL", "c"))
+		assert.Equal(t, int64(-2), c.do("PTTL", "d"))
+		assert.Error(t, c.do("SET", "a", "1", "EX", "0").(error))
+		assert.Error(t, c.do("SET", "a", "1", "EX", "9223372036854775807").(error))
+		assert.Error(t, c.do("SET", "a", "1", "PX", "9223372036854775807").(error))
+
+		clock.Advance(50 * time.Millisecond)
+		assert.Nil(t, c.do("GET", "a"))
+		assert.Equal(t, []byte("1"), c.do("GET", "b"))
+	})
+
+	t.Run("expiration requires a ttl cache", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Error(t, c.do("SET", "a", "1", "EX", "10").(error))
+		assert.Equal(t, int64(-2), c.do("TTL", "a"))
+	})
+
+	t.Run("mget mset flushdb", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Equal(t, "OK", c.do("MSET", "a", "1", "b", "2"))
+		assert.Equal(t, []any{[]byte("1"), nil, []byte("2")}, c.do("MGET", "a", "x", "b"))
+		assert.Error(t, c.do("MSET", "a").(error))
+		assert.Equal(t, "OK", c.do("FLUSHDB"))
+		assert.Equal(t, int64(0), c.do("DBSIZE"))
+	})
+
+	t.Run("values of other types", func(t *testing.T) {
+		cache := gofast.NewCache(10, gofast.LRU)
+		cache.Put("s", "str")
+		cache.Put("n", 1)
+		_, c := startServer(t, cache)
+
+		assert.Equal(t, []byte("str"), c.do("GET", "s"))
+		assert.Contains(t, c.do("GET", "n").(error).Error(), "WRONGTYPE")
+		assert.Equal(t, []any{nil}, c.do("MGET", "n"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.EqualError(t, c.do("NOPE").(error), "ERR unknown command 'NOPE'")
+		assert.EqualError(t, c.do("GET").(error), "ERR wrong number of arguments for 'get' command")
+		assert.Error(t, c.do("SELECT", "1").(error))
+		assert.Equal(t, "OK", c.do("SELECT", "0"))
+	})
+
+	t.Run("info reports hit stats", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(1, gofast.LRU))
+
+		c.do("SET", "a", "1")
+		c.do("SET", "b", "2")
+		c.do("GET", "b")
+		c.do("GET", "a")
+
+		info := string(c.do("INFO").([]byte))
+		assert.Contains(t, info, "# Stats\r\n")
+		assert.Contains(t, info, "keyspace_hits:1\r\n")
+		assert.Contains(t, info, "keyspace_misses:1\r\n")
+		assert.Contains(t, info, "evicted_keys:1\r\n")
+		assert.Contains(t, info, "db0:keys=1\r\n")
+
+		info = string(c.do("INFO", "clients").([]byte))
+		assert.Equal(t, "# Clients\r\nconnected_clients:1\r\n", info)
+	})
+
+	t.Run("pipelining and inline commands", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		_, err := c.conn.Write([]byte("SET a 1\r\nGET a\r\n\r\nPING\r\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "OK", c.reply())
+		assert.Equal(t, []byte("1"), c.reply())
+		assert.Equal(t, "PONG", c.reply())
+	})
+
+	t.Run("quit closes the connection", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		assert.Equal(t, "OK", c.do("QUIT"))
+		_, err := c.r.ReadByte()
+		assert.Error(t, err)
+	})
+
+	t.Run("protocol errors close the connection", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		_, err := c.conn.Write([]byte("*1\r\n+GET\r\n"))
+		require.NoError(t, err)
+		assert.Contains(t, c.reply().(error).Error(), "protocol error")
+		_, err = c.r.ReadByte()
+		assert.Error(t, err)
+	})
+
+	t.Run("negative multibulk length", func(t *testing.T) {
+		_, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+
+		_, err := c.conn.Write([]byte("*-5\r\n"))
+		require.NoError(t, err)
+		assert.Contains(t, c.reply().(error).Error(), "invalid multibulk length")
+
+		// The server survives the malformed request.
+		other := dial(t, c.conn.RemoteAddr().String())
+		assert.Equal(t, "PONG", other.do("PING"))
+	})
+}
+
+func TestServer_Close(t *testing.T) {
+	srv, c := startServer(t, gofast.NewCache(10, gofast.LRU))
+	assert.Equal(t, "PONG", c.do("PING"))
+
+	require.NoError(t, srv.Close())
+	_, err := c.r.ReadByte()
+	assert.Error(t, err)
+	assert.ErrorIs(t, srv.ListenAndServe("127.0.0.1:0"), ErrServerClosed)
+}
@@ -0,0 +1,13 @@
+package gofast
+
+import "github.com/raghavgh/gofast/internal/cache/stats"
+
+// Stats is a snapshot of the hit, miss, eviction and expiration counters of a cache.
+type Stats = stats.Stats
+
+// StatsReporter is implemented by caches that keep Stats.
+// All caches returned by NewCache implement it.
+type StatsReporter interface {
+	// Stats returns the current counters of the cache.
+	Stats() Stats
+}
@@ -0,0 +1,340 @@
+package gofast
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/raghavgh/gofast/internal/cache/tags"
+)
+
+// L2 is the second, larger and slower tier of a Tiered cache, such as another
+// gofast cache, an on-disk store or a remote cache.
+type L2 interface {
+	// Get returns the value (if any) and a boolean representing whether the value was found or not.
+	Get(ctx context.Context, key string) (any, bool, error)
+	// Put adds a value to the tier.
+	Put(ctx context.Context, key string, val any) error
+	// Remove removes a value from the tier.
+	Remove(ctx context.Context, key string) error
+	// Len returns the number of elements of the tier.
+	Len(ctx context.Context) (int, error)
+	// Clear clears the tier.
+	Clear(ctx context.Context) error
+}
+
+// CacheL2 adapts an in-memory Cache for use as the second tier of a Tiered cache.
+func CacheL2(c Cache) L2 {
+	return &cacheL2{cache: WithContext(c)}
+}
+
+// cacheL2 is the L2 adapter of a Cache.
+type cacheL2 struct {
+	cache ContextCache
+}
+
+// Get retrieves a value from the cache unless ctx is already done.
+func (c *cacheL2) Get(ctx context.Context, key string) (any, bool, error) {
+	return c.cache.GetCtx(ctx, key)
+}
+
+// Put adds a new key-value pair to the cache unless ctx is already done.
+func (c *cacheL2) Put(ctx context.Context, key string, val any) error {
+	return c.cache.PutCtx(ctx, key, val)
+}
+
+// Remove deletes a specific key-value pair from the cache unless ctx is already done.
+func (c *cacheL2) Remove(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.cache.Remove(key)
+	return nil
+}
+
+// Len returns the number of items in the cache unless ctx is already done.
+func (c *cacheL2) Len(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.cache.Len(), nil
+}
+
+// Clear removes all items from the cache unless ctx is already done.
+func (c *cacheL2) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.cache.Clear()
+	return nil
+}
+
+// TieredOption configures a Tiered cache.
+type TieredOption func(t *Tiered)
+
+// WithTieredErrorHandler sets fn to be called with the L2 errors of the
+// Cache methods of a Tiered cache, which have no error result.
+// By default those errors are dropped.
+func WithTieredErrorHandler(fn func(err error)) TieredOption {
+	return func(t *Tiered) {
+		t.onError = fn
+	}
+}
+
+// Tiered is a two-tier cache: a small, fast in-memory L1 in front of a larger,
+// slower L2.
+//
+// Reads go through L1 then L2, and L2 hits are promoted into L1.
+// Writes go through to both tiers, L2 first. Entries evicted from L1 are
+// demoted into L2 so they stay available there even if L2 dropped them.
+//
+// The Cache methods use a background context and report L2 errors to the
+// handler set with WithTieredErrorHandler; use the ContextCache methods to
+// bound L2 calls and get their errors.
+//
+// Tiered keeps the tags of its entries itself, so that InvalidateTag removes
+// them from both tiers whatever L1 and L2 are.
+type Tiered struct {
+	// writes counts the writes to the tiers, for reads to tell whether the L2
+	// value they promote may be stale. It is incremented when a write is done,
+	// with writeMu still held, and comes first to be 64-bit aligned for the
+	// atomic package.
+	writes uint64
+
+	l1      Cache
+	l2      L2
+	onError func(err error)
+
+	// writeMu orders writes, so that the entries a write evicts from L1 are
+	// demoted before the next write to either tier, which could otherwise be
+	// undone by a demotion of an older value.
+	writeMu sync.Mutex
+
+	// demoted holds the entries evicted from L1 until they are written to L2.
+	// Evictions are reported with L1's lock held, so the (possibly slow) L2
+	// writes happen afterwards, in the goroutine that caused the eviction.
+	mu      sync.Mutex
+	demoted []demotion
+	// tags is guarded by mu, and changes with writeMu held too.
+	tags tags.Index
+}
+
+// demotion is an entry evicted from L1.
+type demotion struct {
+	key string
+	val any
+}
+
+// NewTiered returns a tiered cache with l1 in front of l2.
+// If l1 implements EvictionNotifier, which every cache returned by NewCache
+// does, its evictions are demoted into l2.
+func NewTiered(l1 Cache, l2 L2, opts ...TieredOption) *Tiered {
+	t := &Tiered{l1: l1, l2: l2}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if notifier, ok := l1.(EvictionNotifier); ok {
+		notifier.AddEvictionHook(t.onL1Evict)
+	}
+	return t
+}
+
+// Get retrieves a value from L1, falling back to L2.
+func (t *Tiered) Get(key string) (any, bool) {
+	val, ok, err := t.GetCtx(context.Background(), key)
+	t.report(err)
+	return val, ok
+}
+
+// Put adds a new key-value pair to both tiers.
+func (t *Tiered) Put(key string, val any) {
+	t.report(t.PutCtx(context.Background(), key, val))
+}
+
+// PutWithTags adds a new key-value pair to both tiers carrying tags.
+func (t *Tiered) PutWithTags(key string, val any, tags ...string) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	t.report(t.put(context.Background(), key, val, tags))
+}
+
+// Remove deletes a specific key-value pair from both tiers.
+func (t *Tiered) Remove(key string) {
+	t.report(t.RemoveCtx(context.Background(), key))
+}
+
+// InvalidateTag removes every entry carrying tag from both tiers and returns
+// the number of entries removed, including those L2 dropped on its own.
+func (t *Tiered) InvalidateTag(tag string) int {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	keys := t.TaggedKeys(tag)
+	for _, key := range keys {
+		t.report(t.remove(context.Background(), key))
+	}
+	return len(keys)
+}
+
+// TaggedKeys returns the keys carrying tag, in no particular order.
+func (t *Tiered) TaggedKeys(tag string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tags.Keys(tag)
+}
+
+// Len returns the number of items in L2. Writes go through to L2, so it holds
+// every entry of L1 unless L2 evicted some on its own.
+func (t *Tiered) Len() int {
+	n, err := t.l2.Len(context.Background())
+	t.report(err)
+	return n
+}
+
+// Clear removes all items from both tiers.
+func (t *Tiered) Clear() {
+	t.report(t.ClearCtx(context.Background()))
+}
+
+// Contains checks if a key is present in either tier, without promoting it.
+func (t *Tiered) Contains(key string) bool {
+	if t.l1.Contains(key) {
+		return true
+	}
+	_, ok, err := t.l2.Get(context.Background(), key)
+	t.report(err)
+	return ok
+}
+
+// GetCtx retrieves a value from L1, falling back to L2 and promoting L2 hits into L1.
+func (t *Tiered) GetCtx(ctx context.Context, key string) (any, bool, error) {
+	if val, ok := t.l1.Get(key); ok {
+		return val, true, nil
+	}
+
+	writes := atomic.LoadUint64(&t.writes)
+	val, ok, err := t.l2.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if atomic.LoadUint64(&t.writes) != writes {
+		// A write since the read may have replaced or removed the value, which
+		// must not be promoted: read it again, now that writes are held off.
+		val, ok, err = t.l2.Get(ctx, key)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+	}
+	t.l1.Put(key, val)
+	return val, true, t.demote(ctx)
+}
+
+// PutCtx adds a new key-value pair to L2, then to L1.
+// L1 is left untouched when the L2 write fails.
+func (t *Tiered) PutCtx(ctx context.Context, key string, val any) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.put(ctx, key, val, nil)
+}
+
+// GetOrLoadCtx returns the value of key from either tier, calling load and
+// writing its result to both tiers when neither has it.
+func (t *Tiered) GetOrLoadCtx(ctx context.Context, key string, load LoaderFunc) (any, error) {
+	val, ok, err := t.GetCtx(ctx, key)
+	if err != nil || ok {
+		return val, err
+	}
+	return loadCtx(ctx, t, key, load)
+}
+
+// RemoveCtx deletes a specific key-value pair from both tiers.
+func (t *Tiered) RemoveCtx(ctx context.Context, key string) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.remove(ctx, key)
+}
+
+// ClearCtx removes all items from both tiers.
+func (t *Tiered) ClearCtx(ctx context.Context) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	defer atomic.AddUint64(&t.writes, 1)
+	t.l1.Clear()
+	t.mu.Lock()
+	t.demoted = nil
+	t.tags.Clear()
+	t.mu.Unlock()
+	return t.l2.Clear(ctx)
+}
+
+// put adds key to L2, then to L1, carrying tags. t.writeMu must be held.
+func (t *Tiered) put(ctx context.Context, key string, val any, tags []string) error {
+	defer atomic.AddUint64(&t.writes, 1)
+	t.forget(key)
+	if err := t.l2.Put(ctx, key, val); err != nil {
+		return err
+	}
+	t.l1.Put(key, val)
+	t.mu.Lock()
+	t.tags.Set(key, tags)
+	t.mu.Unlock()
+	return t.demote(ctx)
+}
+
+// remove deletes key from both tiers. t.writeMu must be held.
+func (t *Tiered) remove(ctx context.Context, key string) error {
+	defer atomic.AddUint64(&t.writes, 1)
+	t.forget(key)
+	t.mu.Lock()
+	t.tags.Remove(key)
+	t.mu.Unlock()
+	t.l1.Remove(key)
+	return t.l2.Remove(ctx, key)
+}
+
+// onL1Evict queues an entry evicted from L1 for demotion.
+func (t *Tiered) onL1Evict(key string, val any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.demoted = append(t.demoted, demotion{key: key, val: val})
+}
+
+// forget drops the pending demotions of key, which is about to be rewritten
+// or removed. They are only left by evictions outside the writes of Tiered,
+// such as L1 being resized.
+func (t *Tiered) forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	kept := t.demoted[:0]
+	for _, d := range t.demoted {
+		if d.key != key {
+			kept = append(kept, d)
+		}
+	}
+	t.demoted = kept
+}
+
+// demote writes the entries evicted from L1 to L2, returning the first error.
+// t.writeMu must be held.
+func (t *Tiered) demote(ctx context.Context) error {
+	t.mu.Lock()
+	demoted := t.demoted
+	t.demoted = nil
+	t.mu.Unlock()
+
+	var firstErr error
+	for _, d := range demoted {
+		if err := t.l2.Put(ctx, d.key, d.val); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// report passes a non-nil error to the error handler.
+func (t *Tiered) report(err error) {
+	if err != nil && t.onError != nil {
+		t.onError(err)
+	}
+}
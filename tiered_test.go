@@ -0,0 +1,294 @@
+package gofast
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeL2 is an in-memory L2 that records calls and can be made to fail.
+type fakeL2 struct {
+	mu    sync.Mutex
+	items map[string]any
+	gets  int
+	puts  int
+	err   error
+	// afterGet, if set, is called by Get once it has read the value.
+	afterGet func()
+}
+
+func newFakeL2() *fakeL2 {
+	return &fakeL2{items: map[string]any{}}
+}
+
+func (f *fakeL2) Get(ctx context.Context, key string) (any, bool, error) {
+	f.mu.Lock()
+	f.gets++
+	val, ok := f.items[key]
+	err, afterGet := f.err, f.afterGet
+	f.mu.Unlock()
+	if afterGet != nil {
+		afterGet()
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return val, ok, nil
+}
+
+func (f *fakeL2) Put(ctx context.Context, key string, val any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.puts++
+	if f.err != nil {
+		return f.err
+	}
+	f.items[key] = val
+	return nil
+}
+
+func (f *fakeL2) Remove(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, key)
+	return f.err
+}
+
+func (f *fakeL2) Len(ctx context.Context) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.items), f.err
+}
+
+func (f *fakeL2) Clear(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items = map[string]any{}
+	return f.err
+}
+
+func TestTiered(t *testing.T) {
+	t.Run("write through", func(t *testing.T) {
+		l1, l2 := NewCache(2, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+
+		tiered.Put("1", 1)
+		assert.True(t, l1.Contains("1"))
+		assert.Equal(t, 1, l2.items["1"])
+		assert.Equal(t, 1, tiered.Len())
+	})
+
+	t.Run("read through and promote", func(t *testing.T) {
+		l1, l2 := NewCache(2, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+		l2.items["1"] = 1
+
+		val, ok := tiered.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+		assert.True(t, l1.Contains("1"))
+
+		// the second read is served by L1.
+		gets := l2.gets
+		tiered.Get("1")
+		assert.Equal(t, gets, l2.gets)
+	})
+
+	t.Run("miss in both tiers", func(t *testing.T) {
+		tiered := NewTiered(NewCache(2, LRU), newFakeL2())
+		_, ok := tiered.Get("1")
+		assert.False(t, ok)
+		assert.False(t, tiered.Contains("1"))
+	})
+
+	t.Run("L1 evictions are demoted", func(t *testing.T) {
+		l1, l2 := NewCache(2, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+		for i := 0; i < 5; i++ {
+			tiered.Put(strconv.Itoa(i), i)
+		}
+		// L2 dropped an entry on its own, the eviction from L1 brings it back.
+		delete(l2.items, "3")
+		tiered.Put("5", 5)
+
+		assert.False(t, l1.Contains("3"))
+		assert.Equal(t, 3, l2.items["3"])
+		for i := 0; i < 6; i++ {
+			assert.True(t, tiered.Contains(strconv.Itoa(i)))
+		}
+	})
+
+	t.Run("removed and rewritten keys are not demoted", func(t *testing.T) {
+		l1, l2 := NewCache(1, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+		tiered.Put("a", 1)
+		tiered.Put("b", 1)
+
+		// Evictions outside the writes of tiered wait for the next one.
+		l1.Put("x", 0)
+		tiered.Remove("b")
+		l1.Put("y", 0)
+		tiered.Put("a", 2)
+		tiered.Put("c", 3)
+
+		assert.NotContains(t, l2.items, "b")
+		assert.Equal(t, 2, l2.items["a"])
+	})
+
+	t.Run("concurrent removes are not undone by demotions", func(t *testing.T) {
+		l1, l2 := NewCache(4, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					key := strconv.Itoa(g) + ":" + strconv.Itoa(i)
+					tiered.Put(key, i)
+					tiered.Remove(key)
+				}
+			}(g)
+		}
+		wg.Wait()
+		assert.Equal(t, 0, tiered.Len())
+	})
+
+	t.Run("values removed while read are not promoted", func(t *testing.T) {
+		l1, l2 := NewCache(4, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+		l2.items["a"] = 1
+		read, removed := make(chan struct{}), make(chan struct{})
+		l2.afterGet = func() {
+			l2.afterGet = nil
+			close(read)
+			<-removed
+		}
+
+		done := make(chan bool)
+		go func() {
+			_, ok, err := tiered.GetCtx(context.Background(), "a")
+			assert.NoError(t, err)
+			done <- ok
+		}()
+		<-read
+		require.NoError(t, tiered.RemoveCtx(context.Background(), "a"))
+		close(removed)
+
+		assert.False(t, <-done)
+		assert.False(t, l1.Contains("a"))
+		assert.False(t, tiered.Contains("a"))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		l1, l2 := NewCache(1, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+		tiered.PutWithTags("a", 1, "t")
+		tiered.PutWithTags("b", 2, "t")
+		tiered.Put("c", 3)
+		// "a" and "b" were demoted to L2 only, where the tags still apply.
+		assert.Equal(t, []string{"c"}, l1.(Inspector).Keys())
+
+		assert.ElementsMatch(t, []string{"a", "b"}, tiered.TaggedKeys("t"))
+		assert.Equal(t, 2, tiered.InvalidateTag("t"))
+		assert.False(t, tiered.Contains("a"))
+		assert.False(t, tiered.Contains("b"))
+		assert.Equal(t, 1, tiered.Len())
+
+		tiered.PutWithTags("a", 1, "t")
+		tiered.Put("a", 2)
+		assert.Equal(t, 0, tiered.InvalidateTag("t"))
+	})
+
+	t.Run("remove and clear", func(t *testing.T) {
+		l1, l2 := NewCache(2, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+		tiered.Put("1", 1)
+		tiered.Put("2", 2)
+
+		tiered.Remove("1")
+		assert.False(t, l1.Contains("1"))
+		assert.NotContains(t, l2.items, "1")
+
+		tiered.Clear()
+		assert.Equal(t, 0, l1.Len())
+		assert.Equal(t, 0, tiered.Len())
+	})
+
+	t.Run("another gofast cache as L2", func(t *testing.T) {
+		tiered := NewTiered(NewCache(1, LRU), CacheL2(NewCache(10, LFU)))
+		tiered.Put("1", 1)
+		tiered.Put("2", 2)
+
+		val, ok := tiered.Get("1")
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+		assert.Equal(t, 2, tiered.Len())
+	})
+}
+
+func TestTiered_Errors(t *testing.T) {
+	errL2 := errors.New("l2 down")
+
+	t.Run("failed write leaves L1 untouched", func(t *testing.T) {
+		l1, l2 := NewCache(2, LRU), newFakeL2()
+		tiered := NewTiered(l1, l2)
+		l2.err = errL2
+
+		assert.ErrorIs(t, tiered.PutCtx(context.Background(), "1", 1), errL2)
+		assert.False(t, l1.Contains("1"))
+	})
+
+	t.Run("cache methods report to the handler", func(t *testing.T) {
+		var reported []error
+		l2 := newFakeL2()
+		tiered := NewTiered(NewCache(2, LRU), l2, WithTieredErrorHandler(func(err error) {
+			reported = append(reported, err)
+		}))
+		l2.err = errL2
+
+		tiered.Put("1", 1)
+		_, ok := tiered.Get("1")
+		assert.False(t, ok)
+		require.Len(t, reported, 2)
+		assert.ErrorIs(t, reported[0], errL2)
+	})
+
+	t.Run("L1 hits do not need L2", func(t *testing.T) {
+		l2 := newFakeL2()
+		tiered := NewTiered(NewCache(2, LRU), l2)
+		tiered.Put("1", 1)
+		l2.err = errL2
+
+		val, ok, err := tiered.GetCtx(context.Background(), "1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 1, val)
+	})
+}
+
+func TestTiered_ContextCache(t *testing.T) {
+	var cache ContextCache = NewTiered(NewCache(2, LRU), newFakeL2())
+	ctx := context.Background()
+
+	val, err := cache.GetOrLoadCtx(ctx, "1", func(ctx context.Context, key string) (any, error) {
+		return "loaded", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "loaded", val)
+
+	val, ok, err := cache.GetCtx(ctx, "1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "loaded", val)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err = WithContext(NewTiered(NewCache(1, LRU), CacheL2(NewCache(1, LRU)))).GetCtx(cancelled, "x")
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,191 @@
+// Package workload generates synthetic, seeded key streams for testing and
+// benchmarking caches: zipfian, uniform, scan, loop, shifting hotspot and
+// mixed read/write workloads.
+//
+// Generators are deterministic for a given seed and are not safe for
+// concurrent use; give each goroutine its own generator or pre-generate
+// the keys with Keys.
+package workload
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// Generator produces an endless stream of keys.
+type Generator interface {
+	// Next returns the next key of the stream.
+	Next() string
+}
+
+// Op is a single cache operation of a mixed workload.
+type Op struct {
+	Key string
+	// Write is true when the key should be written with Put, false when it should be read with Get.
+	Write bool
+}
+
+// key formats the n-th key of a key space.
+func key(n uint64) string {
+	return "key-" + strconv.FormatUint(n, 10)
+}
+
+// Keys returns the next n keys of g.
+func Keys(g Generator, n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = g.Next()
+	}
+	return keys
+}
+
+// Zipf draws keys from a zipfian distribution, where a few keys are very popular
+// and the rest form a long tail.
+type Zipf struct {
+	zipf *rand.Zipf
+}
+
+// NewZipf returns a zipfian generator over keySpace keys.
+// skew must be greater than 1, larger values make the popular keys hotter.
+func NewZipf(seed int64, skew float64, keySpace uint64) *Zipf {
+	if keySpace == 0 {
+		panic("workload: key space must be greater than 0")
+	}
+	zipf := rand.NewZipf(rand.New(rand.NewSource(seed)), skew, 1, keySpace-1)
+	if zipf == nil {
+		panic("workload: zipf skew must be greater than 1")
+	}
+	return &Zipf{zipf: zipf}
+}
+
+// Next returns the next key of the stream.
+func (z *Zipf) Next() string {
+	return key(z.zipf.Uint64())
+}
+
+// Uniform draws every key of the key space with the same probability.
+type Uniform struct {
+	rnd      *rand.Rand
+	keySpace uint64
+}
+
+// NewUniform returns a uniform generator over keySpace keys.
+func NewUniform(seed int64, keySpace uint64) *Uniform {
+	if keySpace == 0 {
+		panic("workload: key space must be greater than 0")
+	}
+	return &Uniform{rnd: rand.New(rand.NewSource(seed)), keySpace: keySpace}
+}
+
+// Next returns the next key of the stream.
+func (u *Uniform) Next() string {
+	return key(uint64(u.rnd.Int63n(int64(u.keySpace))))
+}
+
+// Scan returns each key once, in increasing order, and never repeats a key.
+// It models one-off traversals that pollute recency based caches.
+type Scan struct {
+	next uint64
+}
+
+// NewScan returns a scan generator starting at key start.
+func NewScan(start uint64) *Scan {
+	return &Scan{next: start}
+}
+
+// Next returns the next key of the stream.
+func (s *Scan) Next() string {
+	k := key(s.next)
+	s.next++
+	return k
+}
+
+// Loop cycles over the key space in order. When the key space is larger than
+// the cache, LRU and FIFO miss on every access.
+type Loop struct {
+	next     uint64
+	keySpace uint64
+}
+
+// NewLoop returns a loop generator over keySpace keys.
+func NewLoop(keySpace uint64) *Loop {
+	if keySpace == 0 {
+		panic("workload: key space must be greater than 0")
+	}
+	return &Loop{keySpace: keySpace}
+}
+
+// Next returns the next key of the stream.
+func (l *Loop) Next() string {
+	k := key(l.next)
+	l.next = (l.next + 1) % l.keySpace
+	return k
+}
+
+// Hotspot sends a fraction of accesses to a small hot set and the rest uniformly
+// over the key space. The hot set moves to the next hotSize keys every shiftEvery
+// accesses, so caches must adapt to a changing working set.
+type Hotspot struct {
+	rnd         *rand.Rand
+	keySpace    uint64
+	hotSize     uint64
+	hotFraction float64
+	shiftEvery  int
+	offset      uint64
+	count       int
+}
+
+// NewHotspot returns a hotspot generator over keySpace keys with a hot set of hotSize keys
+// receiving hotFraction of the accesses. A shiftEvery of 0 never moves the hot set.
+func NewHotspot(seed int64, keySpace, hotSize uint64, hotFraction float64, shiftEvery int) *Hotspot {
+	if keySpace == 0 || hotSize == 0 || hotSize > keySpace {
+		panic("workload: hot set size must be between 1 and the key space")
+	}
+	return &Hotspot{
+		rnd:         rand.New(rand.NewSource(seed)),
+		keySpace:    keySpace,
+		hotSize:     hotSize,
+		hotFraction: hotFraction,
+		shiftEvery:  shiftEvery,
+	}
+}
+
+// Next returns the next key of the stream.
+func (h *Hotspot) Next() string {
+	if h.shiftEvery > 0 && h.count > 0 && h.count%h.shiftEvery == 0 {
+		h.offset = (h.offset + h.hotSize) % h.keySpace
+	}
+	h.count++
+
+	if h.rnd.Float64() < h.hotFraction {
+		return key((h.offset + uint64(h.rnd.Int63n(int64(h.hotSize)))) % h.keySpace)
+	}
+	return key(uint64(h.rnd.Int63n(int64(h.keySpace))))
+}
+
+// Mixed turns a key stream into a stream of reads and writes.
+type Mixed struct {
+	keys       Generator
+	rnd        *rand.Rand
+	writeRatio float64
+}
+
+// NewMixed returns a generator of operations on the keys of g,
+// where writeRatio of the operations are writes.
+func NewMixed(seed int64, g Generator, writeRatio float64) *Mixed {
+	return &Mixed{keys: g, rnd: rand.New(rand.NewSource(seed)), writeRatio: writeRatio}
+}
+
+// Next returns the next operation of the stream.
+func (m *Mixed) Next() Op {
+	return Op{Key: m.keys.Next(), Write: m.rnd.Float64() < m.writeRatio}
+}
+
+// Ops returns the next n operations of m.
+func (m *Mixed) Ops(n int) []Op {
+	ops := make([]Op, n)
+	for i := range ops {
+		ops[i] = m.Next()
+	}
+	return ops
+}
@@ -0,0 +1,90 @@
+package workload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministic(t *testing.T) {
+	generators := map[string]func() Generator{
+		"zipf":    func() Generator { return NewZipf(7, 1.1, 1000) },
+		"uniform": func() Generator { return NewUniform(7, 1000) },
+		"hotspot": func() Generator { return NewHotspot(7, 1000, 10, 0.9, 100) },
+	}
+
+	for name, newGen := range generators {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, Keys(newGen(), 500), Keys(newGen(), 500))
+		})
+	}
+
+	assert.NotEqual(t, Keys(NewUniform(1, 1000), 100), Keys(NewUniform(2, 1000), 100))
+}
+
+func TestZipf(t *testing.T) {
+	counts := map[string]int{}
+	for _, k := range Keys(NewZipf(1, 1.2, 10000), 10000) {
+		counts[k]++
+	}
+	// the most popular key of a skewed distribution gets a large share of accesses.
+	assert.Greater(t, counts["key-0"], 1000)
+	assert.Less(t, len(counts), 10000)
+}
+
+func TestUniform(t *testing.T) {
+	counts := map[string]int{}
+	for _, k := range Keys(NewUniform(1, 10), 10000) {
+		counts[k]++
+	}
+	assert.Len(t, counts, 10)
+	for _, c := range counts {
+		assert.InDelta(t, 1000, c, 200)
+	}
+}
+
+func TestScan(t *testing.T) {
+	assert.Equal(t, []string{"key-5", "key-6", "key-7"}, Keys(NewScan(5), 3))
+}
+
+func TestLoop(t *testing.T) {
+	assert.Equal(t, []string{"key-0", "key-1", "key-2", "key-0", "key-1"}, Keys(NewLoop(3), 5))
+}
+
+func TestHotspot(t *testing.T) {
+	h := NewHotspot(1, 1000, 10, 1, 100)
+
+	first := map[string]bool{}
+	for _, k := range Keys(h, 100) {
+		first[k] = true
+	}
+	second := map[string]bool{}
+	for _, k := range Keys(h, 100) {
+		second[k] = true
+	}
+
+	// every access went to the hot set, which moved after 100 accesses.
+	assert.LessOrEqual(t, len(first), 10)
+	assert.LessOrEqual(t, len(second), 10)
+	for k := range second {
+		assert.False(t, first[k], "key %s should not be hot twice", k)
+	}
+}
+
+func TestMixed(t *testing.T) {
+	writes := 0
+	for _, op := range NewMixed(1, NewLoop(10), 0.25).Ops(10000) {
+		if op.Write {
+			writes++
+		}
+	}
+	assert.InDelta(t, 2500, writes, 250)
+}
+
+func TestInvalidArguments(t *testing.T) {
+	assert.Panics(t, func() { NewZipf(1, 1, 100) })
+	assert.Panics(t, func() { NewZipf(1, 1.1, 0) })
+	assert.Panics(t, func() { NewUniform(1, 0) })
+	assert.Panics(t, func() { NewLoop(0) })
+	assert.Panics(t, func() { NewHotspot(1, 10, 20, 0.5, 0) })
+}
@@ -0,0 +1,112 @@
+package gofast
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/raghavgh/gofast/workload"
+)
+
+const (
+	benchKeySpace = 100000
+	benchLimit    = 10000
+	benchOps      = 1 << 18
+)
+
+// benchWorkloads are the read-only key streams every algorithm is benchmarked against.
+var benchWorkloads = []struct {
+	name string
+	gen  func() workload.Generator
+}{
+	{name: "zipf", gen: func() workload.Generator { return workload.NewZipf(1, 1.1, benchKeySpace) }},
+	{name: "uniform", gen: func() workload.Generator { return workload.NewUniform(1, benchKeySpace) }},
+	{name: "scan", gen: func() workload.Generator { return workload.NewScan(0) }},
+	{name: "loop", gen: func() workload.Generator { return workload.NewLoop(benchLimit + benchLimit/10) }},
+	{name: "hotspot", gen: func() workload.Generator {
+		return workload.NewHotspot(1, benchKeySpace, benchLimit/2, 0.9, benchOps/8)
+	}},
+}
+
+// BenchmarkWorkload replays each workload against each algorithm, putting every miss,
+// and reports the hit ratio next to ns/op.
+func BenchmarkWorkload(b *testing.B) {
+	for _, w := range benchWorkloads {
+		keys := workload.Keys(w.gen(), benchOps)
+		for _, algo := range Algorithms() {
+			b.Run(w.name+"/"+algo.String(), func(b *testing.B) {
+				cache := NewCache(benchLimit, algo)
+				hits := 0
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					key := keys[i%len(keys)]
+					if _, ok := cache.Get(key); ok {
+						hits++
+						continue
+					}
+					cache.Put(key, i)
+				}
+				b.ReportMetric(float64(hits)/float64(b.N), "hit-ratio")
+			})
+		}
+	}
+}
+
+// BenchmarkWorkload_Mixed runs a zipfian stream with 20% writes against each algorithm.
+func BenchmarkWorkload_Mixed(b *testing.B) {
+	ops := workload.NewMixed(1, workload.NewZipf(1, 1.1, benchKeySpace), 0.2).Ops(benchOps)
+	for _, algo := range Algorithms() {
+		b.Run(algo.String(), func(b *testing.B) {
+			cache := NewCache(benchLimit, algo)
+			hits, reads := 0, 0
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				op := ops[i%len(ops)]
+				if op.Write {
+					cache.Put(op.Key, i)
+					continue
+				}
+				reads++
+				if _, ok := cache.Get(op.Key); ok {
+					hits++
+					continue
+				}
+				cache.Put(op.Key, i)
+			}
+			if reads > 0 {
+				b.ReportMetric(float64(hits)/float64(reads), "hit-ratio")
+			}
+		})
+	}
+}
+
+// BenchmarkPut_Evicting puts new keys into full LRU, MRU and LFU caches, evicting
+// an entry on every Put, and reports the heap objects held by each cache once full.
+func BenchmarkPut_Evicting(b *testing.B) {
+	keys := make([]string, 1<<16)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	// A boxed value, so that only the cache's own allocations are counted.
+	var val any = benchLimit
+
+	for _, algo := range []Algorithm{LRU, MRU, LFU} {
+		b.Run(algo.String(), func(b *testing.B) {
+			before := heapObjects()
+			cache := NewCache(benchLimit, algo)
+			for i := 0; i < benchLimit; i++ {
+				cache.Put(keys[i], val)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cache.Put(keys[i%len(keys)], val)
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(heapObjects()-before), "heap-objects")
+			runtime.KeepAlive(cache)
+		})
+	}
+}